@@ -0,0 +1,20 @@
+// Package docs holds the OpenAPI spec of the API and the page that renders it.
+package docs
+
+import "embed"
+
+// Spec is the OpenAPI spec, it is the reference for the API and the contract tests.
+//
+//go:embed api.yaml
+var Spec []byte
+
+// Page renders Spec with Swagger UI.
+//
+//go:embed index.html
+var Page []byte
+
+// SwaggerUI is the swagger-ui-dist release Page loads, vendored by make swagger-ui so the page
+// does not run scripts from a CDN. The release is named in swagger-ui/VERSION.
+//
+//go:embed swagger-ui
+var SwaggerUI embed.FS
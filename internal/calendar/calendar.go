@@ -35,9 +35,9 @@ func New(ctx context.Context, log *logrus.Logger) *Calendar {
 	}
 }
 
-func (c *Calendar) Events() []models.Event {
+func (c *Calendar) Events(calendarID string) []models.Event {
 	t := time.Now().Format(time.RFC3339)
-	events, err := c.srv.Events.List("1504342299bb251fb7f535b0707b29fbc9b9e4f0d6fba28f0cb8f7f8e1cd3355@group.calendar.google.com").ShowDeleted(false).
+	events, err := c.srv.Events.List(calendarID).ShowDeleted(false).
 		SingleEvents(true).TimeMin(t).MaxResults(10).OrderBy("startTime").Do()
 	if err != nil {
 		c.log.Panicf("Unable to retrieve next ten of the user's events: %v", err)
@@ -66,6 +66,35 @@ func (c *Calendar) Events() []models.Event {
 	return result
 }
 
+// BusyIntervals returns periods between from and to which are occupied by events of the calendar.
+// All-day and transparent events do not block time.
+func (c *Calendar) BusyIntervals(ctx context.Context, calendarID string, from, to time.Time) ([]models.Interval, error) {
+	var result []models.Interval
+	err := c.srv.Events.List(calendarID).ShowDeleted(false).SingleEvents(true).
+		TimeMin(from.Format(time.RFC3339)).TimeMax(to.Format(time.RFC3339)).OrderBy("startTime").
+		Pages(ctx, func(events *calendar.Events) error {
+			for _, item := range events.Items {
+				if item.Transparency == "transparent" || item.Start == nil || item.End == nil || item.Start.DateTime == "" {
+					continue
+				}
+				start, err := time.Parse(time.RFC3339, item.Start.DateTime)
+				if err != nil {
+					return fmt.Errorf("parse start of event %s faild: %w", item.Id, err)
+				}
+				end, err := time.Parse(time.RFC3339, item.End.DateTime)
+				if err != nil {
+					return fmt.Errorf("parse end of event %s faild: %w", item.Id, err)
+				}
+				result = append(result, models.Interval{Start: start, End: end})
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("list events of calendar %s faild: %w", calendarID, err)
+	}
+	return result, nil
+}
+
 func calendarService(ctx context.Context) *calendar.Service {
 	b, err := credentials.ReadFile("credentials/credentials.json")
 	if err != nil {
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/ratelimit"
+)
+
+func (s *Server) refreshHandler(w http.ResponseWriter, r *http.Request) {
+	var data models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if data.RefreshToken == nil || *data.RefreshToken == "" {
+		s.writeResponse(w, http.StatusBadRequest, errors.New("refreshToken is required"))
+		return
+	}
+	tokens, err := s.app.Refresh(r.Context(), *data.RefreshToken)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, tokens)
+}
+
+// logoutHandler revokes the session of the access token the request is made with.
+func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := s.app.Logout(ctx, s.getClaims(ctx).SessionID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// jwksHandler publishes the public keys tokens are signed with.
+func (s *Server) jwksHandler(w http.ResponseWriter, _ *http.Request) {
+	s.writeResponse(w, http.StatusOK, s.keys.JWKS())
+}
+
+// refuseLogin answers 429 with Retry-After when err is a refusal of the limiter. It reports whether it did.
+func (s *Server) refuseLogin(w http.ResponseWriter, r *http.Request, err error) bool {
+	var limited *ratelimit.LimitedError
+	if !errors.As(err, &limited) {
+		return false
+	}
+	retryAfter := int(math.Ceil(limited.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	refused, reason := models.ErrTooManyAttempts, "rate_limited"
+	if limited.Locked {
+		refused, reason = models.ErrLoginLocked, "locked"
+	}
+	metrics.LoginRefused.WithLabelValues(reason).Inc()
+	s.log.Infof("login from %s refused: %v", clientIP(r), err)
+	s.writeError(w, r, refused.Errorf("retry in %d seconds", retryAfter))
+	return true
+}
+
+// loginFailed counts the failed login against the phone and saves its audit record.
+func (s *Server) loginFailed(ctx context.Context, r *http.Request, ip, phone, login string) {
+	failure := models.LoginFailure{Phone: login, IP: ip, UserAgent: r.UserAgent()}
+	lockout, err := s.limiter.Fail(ctx, phone)
+	if err != nil {
+		s.log.Warnf("err counting login failure: %v", err)
+	}
+	if lockout > 0 {
+		lockedUntil := time.Now().Add(lockout)
+		failure.LockedUntil = &lockedUntil
+	}
+	if err = s.app.RecordLoginFailure(ctx, failure); err != nil {
+		s.log.Warnf("err during login failure audit: %v", err)
+	}
+}
+
+// clientIP returns the address the request came from. Forwarding headers are ignored as they are
+// set by the client unless a trusted proxy overwrites them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// phoneKey keeps the digits of the login, so the same phone written differently shares its limits.
+func phoneKey(login string) string {
+	return strings.Map(func(c rune) rune {
+		if c < '0' || c > '9' {
+			return -1
+		}
+		return c
+	}, login)
+}
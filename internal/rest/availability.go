@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+const (
+	defaultSlotsPeriod  = 7 * 24 * time.Hour
+	defaultSlotDuration = time.Hour
+)
+
+func (s *Server) getAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	availability, err := s.app.GetAvailability(ctx, id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	// Anyone may see the availability, the calendar is shown to its coach only.
+	if s.getClaims(ctx).UserID != id {
+		availability.CalendarID = ""
+	}
+	s.writeResponse(w, http.StatusOK, availability)
+}
+
+func (s *Server) setAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
+	}
+	var data models.AvailabilityRequest
+	if err = json.NewDecoder(r.Body).Decode(&data); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	availability, err := s.app.SetAvailability(ctx, id, data)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, availability)
+}
+
+func (s *Server) saveAvailabilityOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
+	}
+	var override models.AvailabilityOverride
+	if err = json.NewDecoder(r.Body).Decode(&override); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	savedOverride, err := s.app.SaveAvailabilityOverride(ctx, id, override)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusCreated, savedOverride)
+}
+
+func (s *Server) deleteAvailabilityOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	overrideID, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "overrideID"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
+	}
+	deletedOverride, err := s.app.DeleteAvailabilityOverride(ctx, id, overrideID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, deletedOverride)
+}
+
+func (s *Server) getSlotsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	query := r.URL.Query()
+	from := time.Now()
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			s.writeResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	to := from.Add(defaultSlotsPeriod)
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			s.writeResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	duration := defaultSlotDuration
+	if v := query.Get("duration"); v != "" {
+		if duration, err = time.ParseDuration(v); err != nil {
+			s.writeResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	slots, err := s.app.GetSlots(ctx, id, from, to, duration)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, slots)
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) createCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
+	}
+	feed, err := s.app.CreateCalendarToken(ctx, id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	feed.URL = fmt.Sprintf("/api/v1/users/%d/calendar.ics?token=%s", id, url.QueryEscape(feed.Token))
+	s.writeResponse(w, http.StatusCreated, feed)
+}
+
+func (s *Server) revokeCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
+	}
+	if err = s.app.RevokeCalendarToken(ctx, id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// calendarHandler serves the calendar feed of the user. Calendar apps can't send a bearer token,
+// so the feed is authenticated by the calendar token in the URL.
+func (s *Server) calendarHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	calendar, err := s.app.GetCalendar(ctx, id, r.URL.Query().Get("token"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err = calendar.Encode(&buf); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	s.writeRaw(w, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// maxCalendarSize limits iCalendar uploads.
+const maxCalendarSize = 1 << 20
+
+// importMeetingsHandler creates meetings of the coach from the iCalendar file in the body.
+// With dryRun=true nothing is created and the report tells what would be.
+func (s *Server) importMeetingsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
+	}
+	var dryRun bool
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			s.writeResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	report, err := s.app.ImportMeetings(ctx, id, http.MaxBytesReader(w, r.Body, maxCalendarSize), dryRun)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	s.writeResponse(w, status, report)
+}
@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *Server) getCancellationPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	policy, err := s.app.GetCancellationPolicy(ctx, id)
+	if err != nil {
+		s.log.Warnf("err during getting cancellation policy: %v", err)
+		s.writeResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, policy)
+}
+
+func (s *Server) setCancellationPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
+	}
+	var data models.CancellationPolicyRequest
+	if err = json.NewDecoder(r.Body).Decode(&data); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	policy, err := s.app.SetCancellationPolicy(ctx, id, data)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, policy)
+}
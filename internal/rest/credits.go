@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *Server) grantCreditsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	res, err := s.userResource(ctx, id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if !s.authorize(w, r, res) {
+		return
+	}
+	var data models.CreditPackageRequest
+	if err = json.NewDecoder(r.Body).Decode(&data); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	pkg, err := s.app.GrantCredits(ctx, s.getClaims(ctx).UserID, id, data)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusCreated, pkg)
+}
+
+func (s *Server) getCreditsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, coach, ok := s.creditsRequest(w, r)
+	if !ok {
+		return
+	}
+	balance, err := s.app.GetCreditBalance(ctx, id, coach)
+	if err != nil {
+		s.log.Warnf("err during getting credits: %v", err)
+		s.writeResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, balance)
+}
+
+func (s *Server) getCreditLedgerHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, coach, ok := s.creditsRequest(w, r)
+	if !ok {
+		return
+	}
+	entries, err := s.app.GetCreditLedger(ctx, id, coach)
+	if err != nil {
+		s.log.Warnf("err during getting credit ledger: %v", err)
+		s.writeResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, entries)
+}
+
+// creditsRequest parses the client and the optional coach filter. Credits are visible to the client and the coaches of their roster.
+func (s *Server) creditsRequest(w http.ResponseWriter, r *http.Request) (int, *int, bool) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return 0, nil, false
+	}
+	res, err := s.userResource(ctx, id)
+	if err != nil {
+		s.log.Warnf("err during getting credits: %v", err)
+		s.writeResponse(w, http.StatusInternalServerError, err)
+		return 0, nil, false
+	}
+	if !s.authorize(w, r, res) {
+		return 0, nil, false
+	}
+	var coach *int
+	if value := r.URL.Query().Get("coach"); value != "" {
+		coachID, err := strconv.Atoi(value)
+		if err != nil {
+			s.writeResponse(w, http.StatusBadRequest, err)
+			return 0, nil, false
+		}
+		coach = &coachID
+	}
+	return id, coach, true
+}
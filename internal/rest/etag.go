@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+var errIfMatchWithScope = errors.New("series scopes do not support If-Match")
+
+// etag identifies a version of a user or a meeting by the moment it was last updated.
+func etag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+func setETag(w http.ResponseWriter, updatedAt time.Time) {
+	w.Header().Set("ETag", etag(updatedAt))
+}
+
+// parseIfMatch returns the versions listed in the If-Match header, a comma-separated list of entity tags.
+// anyVersion is set when the header is missing or "*". Tags no version can match are skipped: weak ones and
+// ones not issued by etag.
+func parseIfMatch(r *http.Request) (versions []time.Time, anyVersion bool) {
+	value := strings.TrimSpace(strings.Join(r.Header.Values("If-Match"), ","))
+	if value == "" || value == "*" {
+		return nil, true
+	}
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		micros, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, time.UnixMicro(micros))
+	}
+	return versions, false
+}
+
+// ifMatch checks the If-Match header against the current version of the resource and answers 412 when no tag
+// matches it. It returns the version the update must still find, nil if any version will do.
+func (s *Server) ifMatch(w http.ResponseWriter, r *http.Request, current time.Time) (*time.Time, bool) {
+	versions, anyVersion := parseIfMatch(r)
+	if anyVersion {
+		return nil, true
+	}
+	for _, version := range versions {
+		if version.Equal(current) {
+			return &version, true
+		}
+	}
+	s.writeError(w, r, models.ErrPreconditionFailed)
+	return nil, false
+}
+
+// userIfMatch is ifMatch for the user with id. The user is only loaded when the header names versions.
+func (s *Server) userIfMatch(w http.ResponseWriter, r *http.Request, id int) (*time.Time, bool) {
+	if _, anyVersion := parseIfMatch(r); anyVersion {
+		return nil, true
+	}
+	user, err := s.app.GetUser(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return nil, false
+	}
+	return s.ifMatch(w, r, user.UpdatedAt)
+}
@@ -0,0 +1,50 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	first := time.UnixMicro(1690000000000001)
+	second := time.UnixMicro(1690000000000002)
+	parse := func(values ...string) ([]time.Time, bool) {
+		r := httptest.NewRequest(http.MethodPatch, "/", nil)
+		for _, value := range values {
+			r.Header.Add("If-Match", value)
+		}
+		return parseIfMatch(r)
+	}
+
+	versions, anyVersion := parse()
+	require.True(t, anyVersion)
+	require.Empty(t, versions)
+
+	versions, anyVersion = parse(" * ")
+	require.True(t, anyVersion)
+	require.Empty(t, versions)
+
+	versions, anyVersion = parse(etag(first))
+	require.False(t, anyVersion)
+	require.Equal(t, []time.Time{first}, versions)
+
+	versions, anyVersion = parse(etag(first) + " , " + etag(second))
+	require.False(t, anyVersion)
+	require.Equal(t, []time.Time{first, second}, versions)
+
+	versions, anyVersion = parse(etag(first), etag(second))
+	require.False(t, anyVersion)
+	require.Equal(t, []time.Time{first, second}, versions)
+
+	versions, anyVersion = parse(`W/` + etag(first) + `, "other", ` + etag(second))
+	require.False(t, anyVersion)
+	require.Equal(t, []time.Time{second}, versions)
+
+	versions, anyVersion = parse(`W/` + etag(first))
+	require.False(t, anyVersion)
+	require.Empty(t, versions)
+}
@@ -0,0 +1,189 @@
+package rest
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// csvFlushRows is how many rows are buffered before they are sent to the client.
+const csvFlushRows = 100
+
+var (
+	meetingExportHeader = []string{"id", "start_time", "end_time", "duration_minutes", "status",
+		"coach_id", "coach_name", "client_id", "client_name", "capacity", "participants"}
+	userExportHeader = []string{"id", "last_name", "first_name", "phone", "email", "role", "time_zone", "created_at"}
+)
+
+// exportMeetingsHandler streams the meetings of the caller as CSV. It takes the filters of the meeting list.
+func (s *Server) exportMeetingsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filter, err := parseMeetingFilter(r.URL.Query())
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = scopeMeetings(s.getClaims(ctx), &filter); err != nil {
+		s.writeResponse(w, http.StatusForbidden, err)
+		return
+	}
+	filter.Page = models.Page{}
+	loc, err := s.callerLocation(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	stream := newCSVStream(w, "meetings.csv", meetingExportHeader)
+	err = s.app.ExportMeetings(ctx, filter, func(meeting models.MeetingExport) error {
+		return stream.write(meetingRecord(meeting, loc))
+	})
+	s.finishCSV(w, r, stream, err)
+}
+
+// exportUsersHandler streams the users visible to the caller as CSV. It takes the filters of the user list.
+func (s *Server) exportUsersHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	scopeUsers(s.getClaims(ctx), &filter)
+	filter.Page = models.Page{}
+	loc, err := s.callerLocation(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	stream := newCSVStream(w, "users.csv", userExportHeader)
+	err = s.app.ExportUsers(ctx, filter, func(user models.User) error {
+		return stream.write(userRecord(user, loc))
+	})
+	s.finishCSV(w, r, stream, err)
+}
+
+// callerLocation returns the time zone of the caller, times of exports are written in it.
+func (s *Server) callerLocation(ctx context.Context) (*time.Location, error) {
+	user, err := s.app.GetUser(ctx, s.getClaims(ctx).UserID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Location(), nil
+}
+
+// finishCSV ends the stream. Errors before the first row are answered with a problem. Later the status
+// is already sent, so the connection is aborted and the client can't take a truncated file for a complete one.
+func (s *Server) finishCSV(w http.ResponseWriter, r *http.Request, stream *csvStream, err error) {
+	switch {
+	case err != nil && !stream.started:
+		s.writeError(w, r, err)
+		return
+	case err != nil:
+		s.log.Warnf("err during %s %s after %d rows: %v", r.Method, r.URL.Path, stream.rows, err)
+		panic(http.ErrAbortHandler)
+	}
+	if err = stream.start(); err == nil {
+		err = stream.flush()
+	}
+	if err != nil {
+		s.log.Warnf("err during writing to connection: %v", err)
+	}
+}
+
+// csvStream writes a CSV response row by row. The headers and the header row are sent with the first row,
+// so errors which happen before it can still change the response.
+type csvStream struct {
+	w        http.ResponseWriter
+	csv      *csv.Writer
+	filename string
+	header   []string
+	started  bool
+	rows     int
+}
+
+func newCSVStream(w http.ResponseWriter, filename string, header []string) *csvStream {
+	return &csvStream{w: w, csv: csv.NewWriter(w), filename: filename, header: header}
+}
+
+func (c *csvStream) start() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	c.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	c.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", c.filename))
+	return c.csv.Write(c.header)
+}
+
+func (c *csvStream) write(record []string) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	if err := c.csv.Write(record); err != nil {
+		return err
+	}
+	c.rows++
+	if c.rows%csvFlushRows == 0 {
+		return c.flush()
+	}
+	return nil
+}
+
+func (c *csvStream) flush() error {
+	c.csv.Flush()
+	if flusher, ok := c.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return c.csv.Error()
+}
+
+func meetingRecord(meeting models.MeetingExport, loc *time.Location) []string {
+	client := ""
+	if meeting.Client != 0 {
+		client = strconv.Itoa(meeting.Client)
+	}
+	return []string{
+		strconv.Itoa(meeting.ID),
+		csvTime(meeting.StartTime, loc),
+		csvTime(meeting.EndTime, loc),
+		strconv.Itoa(int(meeting.Duration().Minutes())),
+		meeting.Status,
+		strconv.Itoa(meeting.Coach),
+		csvText(meeting.CoachName),
+		client,
+		csvText(meeting.ClientName),
+		strconv.Itoa(meeting.Capacity),
+		strconv.Itoa(meeting.Participants),
+	}
+}
+
+func userRecord(user models.User, loc *time.Location) []string {
+	return []string{
+		strconv.Itoa(user.ID),
+		csvText(user.LastName),
+		csvText(user.FirstName),
+		csvText(user.Phone),
+		csvText(user.Email),
+		user.Role,
+		user.TimeZone,
+		csvTime(user.CreatedAt, loc),
+	}
+}
+
+func csvTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// csvText keeps spreadsheets from running user input as a formula.
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// parsePage reads sort, limit and cursor of list endpoints. Paging is opt-in: without limit and cursor
+// the whole list is returned, a cursor alone pages by models.DefaultPageLimit items.
+func parsePage(query url.Values) (models.Page, error) {
+	page := models.Page{
+		Sort:   models.SortAsc,
+		Cursor: query.Get("cursor"),
+	}
+	if page.Cursor != "" {
+		page.Limit = models.DefaultPageLimit
+	}
+	if sort := query.Get("sort"); sort != "" {
+		if sort != models.SortAsc && sort != models.SortDesc {
+			return models.Page{}, fmt.Errorf("invalid sort %q: expected %s or %s", sort, models.SortAsc, models.SortDesc)
+		}
+		page.Sort = sort
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > models.MaxPageLimit {
+			return models.Page{}, fmt.Errorf("invalid limit %q: expected 1 to %d", value, models.MaxPageLimit)
+		}
+		page.Limit = limit
+	}
+	return page, nil
+}
+
+func parseUserFilter(query url.Values) (models.UserFilter, error) {
+	page, err := parsePage(query)
+	if err != nil {
+		return models.UserFilter{}, err
+	}
+	filter := models.UserFilter{Page: page}
+	if role := query.Get("role"); role != "" {
+		if role != models.RoleCoach && role != models.RoleClient {
+			return models.UserFilter{}, fmt.Errorf("invalid role %q", role)
+		}
+		filter.Role = &role
+	}
+	return filter, nil
+}
+
+func parseMeetingFilter(query url.Values) (models.MeetingFilter, error) {
+	page, err := parsePage(query)
+	if err != nil {
+		return models.MeetingFilter{}, err
+	}
+	filter := models.MeetingFilter{Page: page}
+	if status := query.Get("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+		for _, st := range filter.Statuses {
+			if !models.IsMeetingStatus(st) {
+				return models.MeetingFilter{}, models.ErrInvalidStatus.Errorf("%s", st)
+			}
+		}
+	}
+	if filter.Manager, err = intParam(query, "manager"); err != nil {
+		return models.MeetingFilter{}, err
+	}
+	if filter.Client, err = intParam(query, "client"); err != nil {
+		return models.MeetingFilter{}, err
+	}
+	if filter.From, err = timeParam(query, "from"); err != nil {
+		return models.MeetingFilter{}, err
+	}
+	if filter.To, err = timeParam(query, "to"); err != nil {
+		return models.MeetingFilter{}, err
+	}
+	return filter, nil
+}
+
+func intParam(query url.Values, name string) (*int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	return &result, nil
+}
+
+func timeParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	result, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	return &result, nil
+}
+
+// setNextLink points the Link header to the next page of the list, the lists themselves stay plain arrays.
+func setNextLink(w http.ResponseWriter, r *http.Request, next string) {
+	if next == "" {
+		return
+	}
+	query := r.URL.Query()
+	query.Set("cursor", next)
+	link := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, link.String()))
+}
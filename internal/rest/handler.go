@@ -6,27 +6,74 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/ical"
 	"github.com/pershin-daniil/TimeSlots/pkg/models"
-	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
 )
 
 type App interface {
-	GetUsers(ctx context.Context) ([]models.User, error)
+	GetUsers(ctx context.Context, filter models.UserFilter) (models.UserPage, error)
 	CreateUser(ctx context.Context, user models.UserRequest) (models.User, error)
 	GetUser(ctx context.Context, id int) (models.User, error)
+	InRoster(ctx context.Context, coachID, clientID int) (bool, error)
 	UpdateUser(ctx context.Context, id int, user models.UserRequest) (models.User, error)
-	DeleteUser(ctx context.Context, id int) (models.User, error)
-	GetMeetings(ctx context.Context) ([]models.Meeting, error)
+	DeleteUser(ctx context.Context, id int, ifMatch *time.Time) (models.User, error)
+	GetMeetings(ctx context.Context, filter models.MeetingFilter) (models.MeetingPage, error)
 	CreateMeeting(ctx context.Context, meeting models.MeetingRequest) (models.Meeting, error)
 	GetMeeting(ctx context.Context, id int) (models.Meeting, error)
 	UpdateMeeting(ctx context.Context, id int, meeting models.MeetingRequest) (models.Meeting, error)
-	DeleteMeeting(ctx context.Context, id int) (models.Meeting, error)
-	Login(ctx context.Context, login, password string) (string, error)
+	DeleteMeeting(ctx context.Context, id int, ifMatch *time.Time) (models.Meeting, error)
+	ConfirmMeeting(ctx context.Context, id int) (models.Meeting, error)
+	CancelMeeting(ctx context.Context, id, userID int, reason *string) (models.Meeting, error)
+	CompleteMeeting(ctx context.Context, id int) (models.Meeting, error)
+	MarkNoShow(ctx context.Context, id int) (models.Meeting, error)
+	Login(ctx context.Context, login, password string) (models.TokenResponse, error)
+	RecordLoginFailure(ctx context.Context, failure models.LoginFailure) error
+	Refresh(ctx context.Context, refreshToken string) (models.TokenResponse, error)
+	Logout(ctx context.Context, sessionID string) error
+	ValidateSession(ctx context.Context, claims *models.Claims) error
+	BeginIdempotentRequest(ctx context.Context, key models.IdempotencyKey) (*models.IdempotencyKey, error)
+	FinishIdempotentRequest(ctx context.Context, key models.IdempotencyKey) error
+	GetAvailability(ctx context.Context, coachID int) (models.Availability, error)
+	SetAvailability(ctx context.Context, coachID int, data models.AvailabilityRequest) (models.Availability, error)
+	SaveAvailabilityOverride(ctx context.Context, coachID int, override models.AvailabilityOverride) (models.AvailabilityOverride, error)
+	DeleteAvailabilityOverride(ctx context.Context, coachID, id int) (models.AvailabilityOverride, error)
+	GetSlots(ctx context.Context, coachID int, from, to time.Time, duration time.Duration) ([]models.Slot, error)
+	CreateSeries(ctx context.Context, data models.SeriesRequest) (models.Series, error)
+	GetSeries(ctx context.Context, id int) (models.Series, error)
+	UpdateSeriesMeetings(ctx context.Context, meetingID, userID int, scope string, data models.SeriesRequest) (models.Series, error)
+	DeleteSeriesMeetings(ctx context.Context, meetingID, userID int, scope string) (models.Series, error)
+	GetCancellationPolicy(ctx context.Context, coachID int) (models.CancellationPolicy, error)
+	SetCancellationPolicy(ctx context.Context, coachID int, data models.CancellationPolicyRequest) (models.CancellationPolicy, error)
+	JoinWaitlist(ctx context.Context, coachID, clientID int, data models.WaitlistRequest) (models.WaitlistEntry, error)
+	GetWaitlist(ctx context.Context, clientID int) ([]models.WaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, id, clientID int) (models.WaitlistEntry, error)
+	AcceptWaitlistOffer(ctx context.Context, id, clientID int) (models.Meeting, error)
+	DeclineWaitlistOffer(ctx context.Context, id, clientID int) (models.WaitlistEntry, error)
+	JoinMeeting(ctx context.Context, id, clientID int) (models.Meeting, error)
+	LeaveMeeting(ctx context.Context, id, clientID int) (models.Meeting, error)
+	GrantCredits(ctx context.Context, coachID, clientID int, data models.CreditPackageRequest) (models.CreditPackage, error)
+	GetCreditBalance(ctx context.Context, clientID int, coachID *int) (models.CreditBalance, error)
+	GetCreditLedger(ctx context.Context, clientID int, coachID *int) ([]models.CreditEntry, error)
+	CreateWebhook(ctx context.Context, coachID int, data models.WebhookRequest) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, coachID int) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, coachID, id int) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, coachID, id int, data models.WebhookRequest) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, coachID, id int) (models.Webhook, error)
+	GetWebhookDeliveries(ctx context.Context, coachID, id int) ([]models.WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, coachID, id, deliveryID int) (models.WebhookDelivery, error)
+	CreateCalendarToken(ctx context.Context, userID int) (models.CalendarFeed, error)
+	RevokeCalendarToken(ctx context.Context, userID int) error
+	GetCalendar(ctx context.Context, userID int, token string) (ical.Calendar, error)
+	ImportMeetings(ctx context.Context, coachID int, data io.Reader, dryRun bool) (models.ImportReport, error)
+	ExportMeetings(ctx context.Context, filter models.MeetingFilter, fn func(models.MeetingExport) error) error
+	ExportUsers(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error
 }
 
 func (s *Server) versionHandler(w http.ResponseWriter, _ *http.Request) {
@@ -38,13 +85,22 @@ func (s *Server) versionHandler(w http.ResponseWriter, _ *http.Request) {
 
 func (s *Server) getUsersHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	users, err := s.app.GetUsers(ctx)
+	filter, err := parseUserFilter(r.URL.Query())
 	if err != nil {
-		s.log.Warnf("err during getting users: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
-	s.writeResponse(w, http.StatusOK, users)
+	scopeUsers(s.getClaims(ctx), &filter)
+	page, err := s.app.GetUsers(ctx, filter)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if page.Users == nil {
+		page.Users = []models.User{}
+	}
+	setNextLink(w, r, page.Next)
+	s.writeResponse(w, http.StatusOK, page.Users)
 }
 
 func (s *Server) createUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -54,18 +110,9 @@ func (s *Server) createUserHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
-	if user.Password == nil || *user.Password == "" {
-		s.writeResponse(w, http.StatusUnprocessableEntity, user)
-		return
-	}
 	createdUser, err := s.app.CreateUser(ctx, user)
-	switch {
-	case errors.Is(err, pgstore.ErrUserExists):
-		s.writeResponse(w, http.StatusConflict, err)
-		return
-	case err != nil:
-		s.log.Warnf("err during creating user: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 	s.writeResponse(w, http.StatusCreated, createdUser)
@@ -78,16 +125,30 @@ func (s *Server) getUserHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
+	s.writeUser(w, r, id)
+}
+
+func (s *Server) getMeHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeUser(w, r, s.getClaims(r.Context()).UserID)
+}
+
+func (s *Server) writeUser(w http.ResponseWriter, r *http.Request, id int) {
+	ctx := r.Context()
 	user, err := s.app.GetUser(ctx, id)
-	switch {
-	case errors.Is(err, pgstore.ErrUserNotFound):
-		s.writeResponse(w, http.StatusNotFound, err)
+	if err != nil {
+		s.writeError(w, r, err)
 		return
-	case err != nil:
+	}
+	res, err := s.userResource(ctx, id)
+	if err != nil {
 		s.log.Warnf("err during getting users: %v", err)
 		s.writeResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	if !s.authorize(w, r, res) {
+		return
+	}
+	setETag(w, user.UpdatedAt)
 	s.writeResponse(w, http.StatusOK, user)
 }
 
@@ -98,25 +159,31 @@ func (s *Server) updateUserHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
-	claims := s.getClaims(ctx)
-	if id != claims.UserID && claims.Role != models.RoleCoach {
-		s.writeResponse(w, http.StatusForbidden, nil)
+	res, err := s.userResource(ctx, id)
+	if err != nil {
+		s.log.Warnf("err during updating users: %v", err)
+		s.writeResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !s.authorize(w, r, res) {
+		return
+	}
+	version, ok := s.userIfMatch(w, r, id)
+	if !ok {
+		return
 	}
 	var newData models.UserRequest
 	if err = json.NewDecoder(r.Body).Decode(&newData); err != nil {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
+	newData.IfMatch = version
 	updatedUser, err := s.app.UpdateUser(ctx, id, newData)
-	switch {
-	case errors.Is(err, pgstore.ErrUserNotFound):
-		s.writeResponse(w, http.StatusNotFound, err)
-		return
-	case err != nil:
-		s.log.Warnf("err during updating users: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
+	setETag(w, updatedUser.UpdatedAt)
 	s.writeResponse(w, http.StatusOK, updatedUser)
 }
 
@@ -127,18 +194,16 @@ func (s *Server) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
-	claims := s.getClaims(ctx)
-	if id != claims.UserID {
-		s.writeResponse(w, http.StatusForbidden, nil)
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
 	}
-	deletedUser, err := s.app.DeleteUser(ctx, id)
-	switch {
-	case errors.Is(err, pgstore.ErrUserNotFound):
-		s.writeResponse(w, http.StatusNotFound, err)
+	version, ok := s.userIfMatch(w, r, id)
+	if !ok {
 		return
-	case err != nil:
-		s.log.Warnf("err during deleting users: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+	}
+	deletedUser, err := s.app.DeleteUser(ctx, id, version)
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 	s.writeResponse(w, http.StatusOK, deletedUser)
@@ -151,14 +216,19 @@ func (s *Server) createMeetingHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
-	claims := s.getClaims(ctx)
-	if *meeting.Manager != claims.UserID && claims.Role != models.RoleCoach {
-		s.writeResponse(w, http.StatusForbidden, nil)
+	var res resource
+	if meeting.Manager != nil {
+		res.Manager = *meeting.Manager
+	}
+	if meeting.Client != nil {
+		res.Clients = []int{*meeting.Client}
+	}
+	if !s.authorize(w, r, res) {
+		return
 	}
 	createdMeeting, err := s.app.CreateMeeting(ctx, meeting)
 	if err != nil {
-		s.log.Warnf("err during creating meeeting: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+		s.writeError(w, r, err)
 		return
 	}
 	s.writeResponse(w, http.StatusCreated, createdMeeting)
@@ -166,13 +236,38 @@ func (s *Server) createMeetingHandler(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) getMeetingsHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	meetings, err := s.app.GetMeetings(ctx)
+	filter, err := parseMeetingFilter(r.URL.Query())
 	if err != nil {
-		s.log.Warnf("err during getting meetings: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	claims := s.getClaims(ctx)
+	// mine=true sets manager or client to the caller, which scopeMeetings does for every list,
+	// so mine=false can't be served.
+	if mine := r.URL.Query().Get("mine"); mine != "" {
+		var isMine bool
+		if isMine, err = strconv.ParseBool(mine); err == nil && !isMine {
+			err = errNotMine
+		}
+		if err != nil {
+			s.writeResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err = scopeMeetings(claims, &filter); err != nil {
+		s.writeResponse(w, http.StatusForbidden, err)
+		return
+	}
+	page, err := s.app.GetMeetings(ctx, filter)
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
-	s.writeResponse(w, http.StatusOK, meetings)
+	if page.Meetings == nil {
+		page.Meetings = []models.Meeting{}
+	}
+	setNextLink(w, r, page.Next)
+	s.writeResponse(w, http.StatusOK, page.Meetings)
 }
 
 func (s *Server) getMeetingHandler(w http.ResponseWriter, r *http.Request) {
@@ -182,15 +277,11 @@ func (s *Server) getMeetingHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
-	meeting, err := s.app.GetMeeting(ctx, id)
-	switch {
-	case errors.Is(err, pgstore.ErrMeetingNotFound):
-		s.writeResponse(w, http.StatusNotFound, err)
-	case err != nil:
-		s.log.Warnf("err during getting meeting: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+	meeting, ok := s.loadMeeting(w, r, id)
+	if !ok || !s.authorize(w, r, meetingResource(meeting)) {
 		return
 	}
+	setETag(w, meeting.UpdatedAt)
 	s.writeResponse(w, http.StatusOK, meeting)
 }
 
@@ -201,24 +292,42 @@ func (s *Server) updateMeetingHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
+	meeting, ok := s.loadMeeting(w, r, id)
+	if !ok || !s.authorize(w, r, meetingResource(meeting)) {
+		return
+	}
+	version, ok := s.ifMatch(w, r, meeting.UpdatedAt)
+	if !ok {
+		return
+	}
+	switch scope := r.URL.Query().Get("scope"); scope {
+	case "", models.ScopeThis:
+	case models.ScopeFollowing, models.ScopeAll:
+		if version != nil {
+			s.writeResponse(w, http.StatusBadRequest, errIfMatchWithScope)
+			return
+		}
+		s.updateSeriesMeetings(w, r, meeting, scope)
+		return
+	default:
+		s.writeResponse(w, http.StatusBadRequest, ErrInvalidScope)
+		return
+	}
 	var newData models.MeetingRequest
 	if err = json.NewDecoder(r.Body).Decode(&newData); err != nil {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
-	claims := s.getClaims(ctx)
-	if claims.Role != models.RoleCoach {
-		s.writeResponse(w, http.StatusForbidden, nil)
+	if !s.authorize(w, r, changedMeetingResource(meeting, newData.Manager, newData.Client)) {
+		return
 	}
+	newData.IfMatch = version
 	updatedMeeting, err := s.app.UpdateMeeting(ctx, id, newData)
-	switch {
-	case errors.Is(err, pgstore.ErrMeetingNotFound):
-		s.writeResponse(w, http.StatusNotFound, err)
-	case err != nil:
-		s.log.Warnf("err during updating meeting: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
+	setETag(w, updatedMeeting.UpdatedAt)
 	s.writeResponse(w, http.StatusOK, updatedMeeting)
 }
 
@@ -229,18 +338,45 @@ func (s *Server) deleteMeetingHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeResponse(w, http.StatusBadRequest, err)
 		return
 	}
-	deletedMeeting, err := s.app.DeleteMeeting(ctx, id)
-	switch {
-	case errors.Is(err, pgstore.ErrMeetingNotFound):
-		s.writeResponse(w, http.StatusNotFound, err)
-	case err != nil:
-		s.log.Warnf("err during deleting meeting: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+	meeting, ok := s.loadMeeting(w, r, id)
+	if !ok || !s.authorize(w, r, meetingResource(meeting)) {
+		return
+	}
+	version, ok := s.ifMatch(w, r, meeting.UpdatedAt)
+	if !ok {
+		return
+	}
+	switch scope := r.URL.Query().Get("scope"); scope {
+	case "", models.ScopeThis:
+	case models.ScopeFollowing, models.ScopeAll:
+		if version != nil {
+			s.writeResponse(w, http.StatusBadRequest, errIfMatchWithScope)
+			return
+		}
+		s.deleteSeriesMeetings(w, r, id, scope)
+		return
+	default:
+		s.writeResponse(w, http.StatusBadRequest, ErrInvalidScope)
+		return
+	}
+	deletedMeeting, err := s.app.DeleteMeeting(ctx, id, version)
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 	s.writeResponse(w, http.StatusOK, deletedMeeting)
 }
 
+// loadMeeting gets the meeting a request acts on. It answers with the error and returns false when that fails.
+func (s *Server) loadMeeting(w http.ResponseWriter, r *http.Request, id int) (models.Meeting, bool) {
+	meeting, err := s.app.GetMeeting(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return models.Meeting{}, false
+	}
+	return meeting, true
+}
+
 func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 	auth := r.Header.Get("Authorization")
 	if auth == "" {
@@ -262,33 +398,40 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 		s.writeResponse(w, http.StatusUnauthorized, errors.New("invalid basic auth"))
 		return
 	}
-	token, err := s.app.Login(r.Context(), creds[0], creds[1])
-	switch {
-	case errors.Is(err, pgstore.ErrUserNotFound):
-		s.writeResponse(w, http.StatusNotFound, err)
-	case errors.Is(err, models.ErrInvalidCredentials):
-		s.writeResponse(w, http.StatusUnauthorized, err)
-	case err != nil:
-		s.log.Warnf("err during login: %v", err)
-		s.writeResponse(w, http.StatusInternalServerError, err)
+	ctx := r.Context()
+	ip, phone := clientIP(r), phoneKey(creds[0])
+	if err = s.limiter.Allow(ctx, ip, phone); err != nil {
+		if s.refuseLogin(w, r, err) {
+			return
+		}
+		s.log.Warnf("err checking login limits: %v", err)
+	}
+	tokens, err := s.app.Login(ctx, creds[0], creds[1])
+	if errors.Is(err, models.ErrInvalidCredentials) {
+		s.loginFailed(ctx, r, ip, phone, creds[0])
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err = s.limiter.Succeed(ctx, phone); err != nil {
+		s.log.Warnf("err resetting login failures: %v", err)
 	}
-	s.writeResponse(w, http.StatusOK, models.TokenResponse{Token: token})
+	s.writeResponse(w, http.StatusOK, tokens)
 }
 
 func (s *Server) writeResponse(w http.ResponseWriter, status int, data interface{}) {
-	w.WriteHeader(status)
-	w.Header().Set("Content-Type", "application/json")
 	if x, ok := data.(error); ok {
-		if err := json.NewEncoder(w).Encode(ErrorResponse{Error: x.Error()}); err != nil {
+		w.Header().Set("Content-Type", problemContentType)
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(newProblem(status, x)); err != nil {
 			s.log.Warnf("err during encoding error: %v", err)
 		}
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		s.log.Warnf("err during encoding response: %v", err)
 	}
 }
-
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
@@ -2,61 +2,102 @@ package rest
 
 import (
 	"context"
-	"crypto/rsa"
-	"crypto/x509"
-	_ "embed"
-	"encoding/pem"
 	"errors"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pershin-daniil/TimeSlots/pkg/ratelimit"
+	"github.com/pershin-daniil/TimeSlots/pkg/signing"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/sirupsen/logrus"
 )
 
 type Server struct {
-	log       *logrus.Entry
-	address   string
-	version   string
-	app       App
-	server    *http.Server
-	publicKey *rsa.PublicKey
+	log     *logrus.Entry
+	address string
+	version string
+	app     App
+	server  *http.Server
+	keys    *signing.Keys
+	limiter *ratelimit.Limiter
 }
 
-//go:embed private_rsa.pub
-var publicSigningKey []byte
-
-func New(log *logrus.Logger, app App, address, version string) *Server {
+func New(log *logrus.Logger, app App, keys *signing.Keys, limiter *ratelimit.Limiter, address, version string) *Server {
 	s := Server{
-		log:       log.WithField("module", "rest"),
-		address:   address,
-		version:   version,
-		app:       app,
-		publicKey: mustGetPublicKey(publicSigningKey),
+		log:     log.WithField("module", "rest"),
+		address: address,
+		version: version,
+		app:     app,
+		keys:    keys,
+		limiter: limiter,
 	}
 	r := chi.NewRouter()
 	r.Use(middleware.Recoverer)
 	r.Get("/version", s.versionHandler)
 	r.Get("/metrics", promhttp.Handler().ServeHTTP)
+	r.Get("/.well-known/jwks.json", s.jwksHandler)
 	r.Route("/api", func(r chi.Router) {
-		r.Use(middleware.RequestLogger(&middleware.DefaultLogFormatter{Logger: s.log, NoColor: true}))
+		r.Use(middleware.RequestLogger(redactedLogFormatter{&middleware.DefaultLogFormatter{Logger: s.log, NoColor: true}}))
+		r.Get("/openapi.yaml", s.specHandler)
+		r.Get("/docs", s.docsHandler)
+		r.Get("/docs/swagger-ui/*", s.swaggerUIHandler)
 		r.Route("/v1", func(r chi.Router) {
 			r.Post("/login", s.loginHandler)
-			r.Post("/users", s.createUserHandler)
+			r.Post("/auth/refresh", s.refreshHandler)
+			r.With(s.idempotent).Post("/users", s.createUserHandler)
+			r.Get("/users/{id}/calendar.ics", s.calendarHandler)
 			r.Group(func(r chi.Router) {
-				r.Use(s.jwtAuth)
+				r.Use(s.jwtAuth, s.requirePolicy)
+				r.Post("/auth/logout", s.logoutHandler)
 				r.Get("/users", s.getUsersHandler)
+				r.Get("/users/me", s.getMeHandler)
+				r.Get("/users/export.csv", s.exportUsersHandler)
 				r.Get("/users/{id}", s.getUserHandler)
 				r.Patch("/users/{id}", s.updateUserHandler)
 				r.Delete("/users/{id}", s.deleteUserHandler)
-				r.Post("/meetings", s.createMeetingHandler)
+				r.With(s.idempotent).Post("/users/{id}/credits", s.grantCreditsHandler)
+				r.Get("/users/{id}/credits", s.getCreditsHandler)
+				r.Get("/users/{id}/credits/ledger", s.getCreditLedgerHandler)
+				r.Post("/users/{id}/calendar-token", s.createCalendarTokenHandler)
+				r.Delete("/users/{id}/calendar-token", s.revokeCalendarTokenHandler)
+				r.With(s.idempotent).Post("/meetings", s.createMeetingHandler)
 				r.Get("/meetings", s.getMeetingsHandler)
+				r.Get("/meetings/export.csv", s.exportMeetingsHandler)
 				r.Get("/meetings/{id}", s.getMeetingHandler)
 				r.Patch("/meetings/{id}", s.updateMeetingHandler)
 				r.Delete("/meetings/{id}", s.deleteMeetingHandler)
+				r.Post("/meetings/{id}/confirm", s.meetingStatusHandler(s.app.ConfirmMeeting))
+				r.Post("/meetings/{id}/cancel", s.cancelMeetingHandler)
+				r.Post("/meetings/{id}/complete", s.meetingStatusHandler(s.app.CompleteMeeting))
+				r.Post("/meetings/{id}/no-show", s.meetingStatusHandler(s.app.MarkNoShow))
+				r.Post("/meetings/{id}/participants", s.joinMeetingHandler)
+				r.Delete("/meetings/{id}/participants", s.leaveMeetingHandler)
+				r.With(s.idempotent).Post("/series", s.createSeriesHandler)
+				r.Get("/series/{id}", s.getSeriesHandler)
+				r.Get("/coaches/{id}/availability", s.getAvailabilityHandler)
+				r.Put("/coaches/{id}/availability", s.setAvailabilityHandler)
+				r.Post("/coaches/{id}/availability/overrides", s.saveAvailabilityOverrideHandler)
+				r.Delete("/coaches/{id}/availability/overrides/{overrideID}", s.deleteAvailabilityOverrideHandler)
+				r.Get("/coaches/{id}/cancellation-policy", s.getCancellationPolicyHandler)
+				r.Put("/coaches/{id}/cancellation-policy", s.setCancellationPolicyHandler)
+				r.Get("/coaches/{id}/slots", s.getSlotsHandler)
+				r.Post("/coaches/{id}/meetings/import", s.importMeetingsHandler)
+				r.Post("/coaches/{id}/waitlist", s.joinWaitlistHandler)
+				r.Get("/waitlist", s.getWaitlistHandler)
+				r.Delete("/waitlist/{id}", s.leaveWaitlistHandler)
+				r.Post("/waitlist/{id}/accept", s.acceptWaitlistOfferHandler)
+				r.Post("/waitlist/{id}/decline", s.declineWaitlistOfferHandler)
+				r.Get("/webhooks/events", s.getWebhookEventsHandler)
+				r.Get("/coaches/{id}/webhooks", s.getWebhooksHandler)
+				r.Post("/coaches/{id}/webhooks", s.createWebhookHandler)
+				r.Get("/coaches/{id}/webhooks/{webhookID}", s.getWebhookHandler)
+				r.Patch("/coaches/{id}/webhooks/{webhookID}", s.updateWebhookHandler)
+				r.Delete("/coaches/{id}/webhooks/{webhookID}", s.deleteWebhookHandler)
+				r.Get("/coaches/{id}/webhooks/{webhookID}/deliveries", s.getWebhookDeliveriesHandler)
+				r.Post("/coaches/{id}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", s.redeliverWebhookHandler)
 			})
 		})
 	})
@@ -84,18 +125,3 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 	return nil
 }
-
-func mustGetPublicKey(keyBytes []byte) *rsa.PublicKey {
-	if len(keyBytes) == 0 {
-		panic("file public.pub is missing or invalid")
-	}
-	block, _ := pem.Decode(keyBytes)
-	if block == nil {
-		panic("unable to decode public key to blocks")
-	}
-	key, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		panic(err)
-	}
-	return key.(*rsa.PublicKey)
-}
@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+	// finishTimeout bounds saving the response, which outlives the request when the client has gone away.
+	finishTimeout = 5 * time.Second
+)
+
+// idempotent makes retries of a request with the same Idempotency-Key header safe: the response of the first
+// request is saved and replayed. Reusing a key for a different request body is rejected. Keys of unauthenticated
+// callers are scoped by their address, so their responses are not replayed to other clients.
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.Header.Get(idempotencyKeyHeader)
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		if len(value) > maxIdempotencyKeyLen {
+			s.writeResponse(w, http.StatusBadRequest, errors.New("idempotency key is too long"))
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.writeResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+		key := models.IdempotencyKey{
+			Key:         value,
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			RequestHash: hex.EncodeToString(hash[:]),
+		}
+		if claims := s.getClaims(ctx); claims != nil {
+			key.UserID = claims.UserID
+		} else {
+			key.Client = clientIP(r)
+		}
+		saved, err := s.app.BeginIdempotentRequest(ctx, key)
+		switch {
+		case err != nil:
+			s.writeError(w, r, err)
+			return
+		case saved != nil:
+			contentType := "application/json"
+			if *saved.Status >= http.StatusBadRequest {
+				contentType = problemContentType
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(*saved.Status)
+			if _, err = w.Write(saved.Response); err != nil {
+				s.log.Warnf("err during replaying response: %v", err)
+			}
+			return
+		}
+		var response bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&response)
+		// Deferred to release the key even if the handler panics.
+		defer func() {
+			if status := ww.Status(); status != 0 {
+				key.Status = &status
+				key.Response = response.Bytes()
+			}
+			// The request context is cancelled if the client disconnected, which is when the key matters most.
+			ctx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+			defer cancel()
+			if err := s.app.FinishIdempotentRequest(ctx, key); err != nil {
+				s.log.Warnf("err during finishing idempotent request: %v", err)
+			}
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
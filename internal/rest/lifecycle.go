@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// meetingStatusHandler serves transitions which don't need a request body.
+func (s *Server) meetingStatusHandler(transition func(ctx context.Context, id int) (models.Meeting, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+		if err != nil {
+			s.writeResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		meeting, ok := s.loadMeeting(w, r, id)
+		if !ok || !s.authorize(w, r, meetingResource(meeting)) {
+			return
+		}
+		meeting, err = transition(ctx, id)
+		s.writeTransitionResponse(w, r, meeting, err)
+	}
+}
+
+func (s *Server) cancelMeetingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	var data models.CancelRequest
+	if err = json.NewDecoder(r.Body).Decode(&data); err != nil && !errors.Is(err, io.EOF) {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	meeting, ok := s.loadMeeting(w, r, id)
+	if !ok || !s.authorize(w, r, meetingResource(meeting)) {
+		return
+	}
+	claims := s.getClaims(ctx)
+	cancelledMeeting, err := s.app.CancelMeeting(ctx, id, claims.UserID, data.Reason)
+	s.writeTransitionResponse(w, r, cancelledMeeting, err)
+}
+
+func (s *Server) writeTransitionResponse(w http.ResponseWriter, r *http.Request, meeting models.Meeting, err error) {
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, meeting)
+}
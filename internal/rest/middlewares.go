@@ -2,14 +2,15 @@ package rest
 
 import (
 	"context"
-	"crypto/rsa"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/signing"
 )
 
 type ctxClaimsType string
@@ -34,11 +35,21 @@ func (s *Server) jwtAuth(next http.Handler) http.Handler {
 			s.writeResponse(w, http.StatusUnauthorized, ErrUnauthorised)
 			return
 		}
-		claims, err := parseToken(headerParts[1], s.publicKey)
+		claims, err := parseToken(headerParts[1], s.keys)
 		if err != nil {
 			s.writeResponse(w, http.StatusUnauthorized, ErrUnauthorised)
 			return
 		}
+		err = s.app.ValidateSession(r.Context(), claims)
+		switch {
+		case errors.Is(err, models.ErrSessionRevoked):
+			s.writeResponse(w, http.StatusUnauthorized, ErrUnauthorised)
+			return
+		case err != nil:
+			s.log.Warnf("err during validating session: %v", err)
+			s.writeResponse(w, http.StatusInternalServerError, err)
+			return
+		}
 		r = r.WithContext(context.WithValue(r.Context(), ctxClaimsStr, claims))
 		next.ServeHTTP(w, r)
 	})
@@ -52,13 +63,8 @@ func (s *Server) getClaims(ctx context.Context) *models.Claims {
 	return claims
 }
 
-func parseToken(accessToken string, key *rsa.PublicKey) (*models.Claims, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &models.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("invalid signing method: %v", token.Header["alg"])
-		}
-		return key, nil
-	})
+func parseToken(accessToken string, keys *signing.Keys) (*models.Claims, error) {
+	token, err := jwt.ParseWithClaims(accessToken, &models.Claims{}, keys.Keyfunc)
 	if err != nil {
 		return nil, fmt.Errorf("err parsing token: %w", err)
 	}
@@ -66,5 +72,27 @@ func parseToken(accessToken string, key *rsa.PublicKey) (*models.Claims, error)
 	if !ok {
 		return nil, fmt.Errorf("invalid claims")
 	}
+	// Tokens issued before sessions were introduced never expire.
+	if claims.ExpiresAt == nil || claims.SessionID == "" {
+		return nil, fmt.Errorf("token without expiry or session")
+	}
 	return claims, nil
 }
+
+// redactedLogFormatter logs requests with the calendar token in the query hidden. The token opens the calendar
+// feed without a login, so it must not end up in the access log.
+type redactedLogFormatter struct {
+	middleware.LogFormatter
+}
+
+func (f redactedLogFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	query := r.URL.Query()
+	if query.Get("token") == "" {
+		return f.LogFormatter.NewLogEntry(r)
+	}
+	query.Set("token", "REDACTED")
+	logged := r.Clone(r.Context())
+	logged.URL.RawQuery = query.Encode()
+	logged.RequestURI = logged.URL.RequestURI()
+	return f.LogFormatter.NewLogEntry(logged)
+}
@@ -0,0 +1,31 @@
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactedLogFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+	formatter := redactedLogFormatter{&middleware.DefaultLogFormatter{Logger: log, NoColor: true}}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/users/7/calendar.ics?token=secret&x=1", nil)
+	formatter.NewLogEntry(r).Write(http.StatusOK, 0, nil, time.Millisecond, nil)
+	require.NotContains(t, buf.String(), "secret")
+	require.Contains(t, buf.String(), "/api/v1/users/7/calendar.ics?token=REDACTED&x=1")
+	require.Equal(t, "secret", r.URL.Query().Get("token"))
+
+	buf.Reset()
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/meetings?limit=2", nil)
+	formatter.NewLogEntry(r).Write(http.StatusOK, 0, nil, time.Millisecond, nil)
+	require.Contains(t, buf.String(), "/api/v1/meetings?limit=2")
+}
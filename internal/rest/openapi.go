@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+
+	"github.com/pershin-daniil/TimeSlots/docs"
+)
+
+const apiPrefix = "/api/v1/"
+
+func (s *Server) specHandler(w http.ResponseWriter, _ *http.Request) {
+	s.writeRaw(w, "application/yaml", docs.Spec)
+}
+
+func (s *Server) docsHandler(w http.ResponseWriter, _ *http.Request) {
+	s.writeRaw(w, "text/html; charset=utf-8", docs.Page)
+}
+
+// swaggerUIHandler serves the vendored Swagger UI assets of the docs page.
+func (s *Server) swaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+	http.StripPrefix("/api/docs/", http.FileServer(http.FS(docs.SwaggerUI))).ServeHTTP(w, r)
+}
+
+func (s *Server) writeRaw(w http.ResponseWriter, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(data); err != nil {
+		s.log.Warnf("err during writing to connection: %v", err)
+	}
+}
+
+func loadSpec(ctx context.Context) (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+	spec, err := loader.LoadFromData(docs.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("err loading spec: %w", err)
+	}
+	if err = spec.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	return spec, nil
+}
+
+// ValidateContract makes the server check the requests and responses of the API against docs/api.yaml
+// and pass the violations to report. It is meant for tests and has to be called before Run.
+// Invalid requests are only reported when they succeed, rejecting them is the expected behaviour.
+func (s *Server) ValidateContract(report func(error)) error {
+	validate, err := newContractValidator(context.Background(), report)
+	if err != nil {
+		return err
+	}
+	s.server.Handler = validate(s.server.Handler)
+	return nil
+}
+
+// registerDecoders teaches the validator the content types of the API it doesn't know.
+var registerDecoders sync.Once
+
+func newContractValidator(ctx context.Context, report func(error)) (func(http.Handler) http.Handler, error) {
+	registerDecoders.Do(func() {
+		openapi3filter.RegisterBodyDecoder("text/calendar", openapi3filter.FileBodyDecoder)
+		openapi3filter.RegisterBodyDecoder("text/csv", openapi3filter.FileBodyDecoder)
+	})
+	spec, err := loadSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	router, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("err creating spec router: %w", err)
+	}
+	options := &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				report(fmt.Errorf("%s %s: err reading body: %w", r.Method, r.URL.Path, err))
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			route, params, err := router.FindRoute(r)
+			if err != nil {
+				report(fmt.Errorf("%s %s: %w", r.Method, r.URL.Path, err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			input := &openapi3filter.RequestValidationInput{Request: r, PathParams: params, Route: route, Options: options}
+			if err = openapi3filter.ValidateRequest(r.Context(), input); err != nil && rec.status < http.StatusBadRequest {
+				report(fmt.Errorf("%s %s: request: %w", r.Method, r.URL.Path, err))
+			}
+			if err = validateResponse(r.Context(), input, rec); err != nil {
+				report(fmt.Errorf("%s %s: response %d: %w", r.Method, r.URL.Path, rec.status, err))
+			}
+		})
+	}, nil
+}
+
+func validateResponse(ctx context.Context, input *openapi3filter.RequestValidationInput, rec *recordingWriter) error {
+	return openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.status,
+		Header:                 rec.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+		Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+	})
+}
+
+// recordingWriter keeps a copy of the response for validation.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecCoversRoutes(t *testing.T) {
+	spec, err := loadSpec(context.Background())
+	require.NoError(t, err)
+	s := New(logrus.New(), struct{ App }{}, nil, nil, ":0", "test")
+	routes := make(map[policyKey]bool)
+	err = chi.Walk(s.server.Handler.(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, apiPrefix) {
+			return nil
+		}
+		path := strings.TrimPrefix(route, strings.TrimSuffix(apiPrefix, "/"))
+		routes[policyKey{method, path}] = true
+		if item := spec.Paths.Find(path); item == nil || item.GetOperation(method) == nil {
+			t.Errorf("%s %s is not in the spec", method, route)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	for path, item := range spec.Paths {
+		for method := range item.Operations() {
+			if !routes[policyKey{method, path}] {
+				t.Errorf("%s %s is in the spec but has no route", method, path)
+			}
+		}
+	}
+}
+
+func TestContractValidator(t *testing.T) {
+	const (
+		user    = `{"id":1,"lastName":"Doe","firstName":"John","phone":"+79991234567","email":"","role":"client","timeZone":"UTC","createdAt":"2023-03-14T14:18:27Z","updatedAt":"2023-03-14T14:18:27Z"}`
+		problem = `{"type":"about:blank","title":"Not Found","status":404,"code":"USER_NOT_FOUND","detail":"user not found"}`
+	)
+	for _, tc := range []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		status      int
+		contentType string
+		response    string
+		violation   string
+	}{
+		{"valid response", http.MethodGet, "/api/v1/users/1", "", http.StatusOK, "application/json", user, ""},
+		{"problem", http.MethodGet, "/api/v1/users/1", "", http.StatusNotFound, problemContentType, problem, ""},
+		{"missing field", http.MethodGet, "/api/v1/users/1", "", http.StatusOK, "application/json", strings.Replace(user, `"role":"client",`, "", 1), "response"},
+		{"unknown field", http.MethodGet, "/api/v1/users/1", "", http.StatusOK, "application/json", strings.Replace(user, `"id":1`, `"id":1,"deleted":false`, 1), "response"},
+		{"invalid request accepted", http.MethodPost, "/api/v1/meetings", `{"manager":"one"}`, http.StatusCreated, "application/json", "{}", "request"},
+		{"invalid request rejected", http.MethodPost, "/api/v1/meetings", `{"manager":"one"}`, http.StatusBadRequest, problemContentType, `{"type":"about:blank","title":"Bad Request","status":400,"code":"BAD_REQUEST"}`, ""},
+		{"calendar", http.MethodGet, "/api/v1/users/1/calendar.ics?token=secret", "", http.StatusOK, "text/calendar; charset=utf-8", "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", ""},
+		{"csv", http.MethodGet, "/api/v1/meetings/export.csv?from=2023-07-01T00:00:00Z", "", http.StatusOK, "text/csv; charset=utf-8", "id,start_time\r\n", ""},
+		{"unknown route", http.MethodGet, "/api/v1/unknown", "", http.StatusNotFound, "text/plain", "404 page not found", "no matching operation"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var violations []string
+			validate, err := newContractValidator(context.Background(), func(err error) {
+				violations = append(violations, err.Error())
+			})
+			require.NoError(t, err)
+			handler := validate(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+			require.Equal(t, tc.response, w.Body.String())
+			if tc.violation == "" {
+				require.Empty(t, violations)
+				return
+			}
+			require.NotEmpty(t, violations)
+			require.Contains(t, violations[0], tc.violation)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *Server) joinMeetingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	claims := s.getClaims(ctx)
+	meeting, err := s.app.JoinMeeting(ctx, id, claims.UserID)
+	s.writeParticipantsResponse(w, r, meeting, err)
+}
+
+func (s *Server) leaveMeetingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	claims := s.getClaims(ctx)
+	meeting, err := s.app.LeaveMeeting(ctx, id, claims.UserID)
+	s.writeParticipantsResponse(w, r, meeting, err)
+}
+
+func (s *Server) writeParticipantsResponse(w http.ResponseWriter, r *http.Request, meeting models.Meeting, err error) {
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, meeting)
+}
@@ -0,0 +1,205 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// resource describes who owns the resource a request acts on. Handlers fill in what they know.
+type resource struct {
+	// Owner is the user the resource belongs to: the user itself, a coach's settings or a client's credits.
+	Owner int
+	// Roster is set when Owner is on the roster of the calling coach.
+	Roster bool
+	// Manager and Clients are set for meetings and series.
+	Manager int
+	Clients []int
+	// Group is set for group sessions, whose clients are the participants.
+	Group bool
+}
+
+// rule decides whether the caller may act on the resource.
+type rule func(claims *models.Claims, res resource) bool
+
+func authenticated(*models.Claims, resource) bool { return true }
+
+func coach(claims *models.Claims, _ resource) bool { return claims.Role == models.RoleCoach }
+
+func self(claims *models.Claims, res resource) bool { return claims.UserID == res.Owner }
+
+// ownCoach lets coaches manage their own settings.
+func ownCoach(claims *models.Claims, res resource) bool {
+	return coach(claims, res) && self(claims, res)
+}
+
+// rosterCoach lets coaches act on the clients on their roster.
+func rosterCoach(claims *models.Claims, res resource) bool {
+	return coach(claims, res) && res.Roster
+}
+
+func selfOrRosterCoach(claims *models.Claims, res resource) bool {
+	return self(claims, res) || rosterCoach(claims, res)
+}
+
+func managingCoach(claims *models.Claims, res resource) bool {
+	return coach(claims, res) && claims.UserID == res.Manager
+}
+
+// booking lets coaches book meetings they manage and clients book meetings for themselves.
+func booking(claims *models.Claims, res resource) bool {
+	return managingCoach(claims, res) || len(res.Clients) == 1 && res.Clients[0] == claims.UserID
+}
+
+// member lets the manager and the clients of a meeting act on it.
+func member(claims *models.Claims, res resource) bool {
+	if claims.UserID == res.Manager {
+		return true
+	}
+	for _, client := range res.Clients {
+		if client == claims.UserID {
+			return true
+		}
+	}
+	return false
+}
+
+// canceller lets the manager cancel a meeting and clients their one-to-one meetings.
+// Participants of a group session leave it instead of cancelling it for everyone.
+func canceller(claims *models.Claims, res resource) bool {
+	return claims.UserID == res.Manager || !res.Group && member(claims, res)
+}
+
+type policyKey struct {
+	method string
+	route  string
+}
+
+// publicRoutes are served without a token. The calendar feed is authenticated by the calendar token in its URL.
+var publicRoutes = map[policyKey]bool{
+	{http.MethodGet, "/version"}:                        true,
+	{http.MethodGet, "/metrics"}:                        true,
+	{http.MethodGet, "/.well-known/jwks.json"}:          true,
+	{http.MethodGet, "/api/openapi.yaml"}:               true,
+	{http.MethodGet, "/api/docs"}:                       true,
+	{http.MethodGet, "/api/docs/swagger-ui/*"}:          true,
+	{http.MethodPost, "/api/v1/login"}:                  true,
+	{http.MethodPost, "/api/v1/auth/refresh"}:           true,
+	{http.MethodPost, "/api/v1/users"}:                  true,
+	{http.MethodGet, "/api/v1/users/{id}/calendar.ics"}: true,
+}
+
+// policies are the rules of authenticated routes. A route without a rule is denied.
+// Waitlist entries and participants act on the caller, the service checks the entries belong to them.
+var policies = map[policyKey]rule{
+	{http.MethodPost, "/api/v1/auth/logout"}:                                                         authenticated,
+	{http.MethodGet, "/api/v1/users"}:                                                                authenticated,
+	{http.MethodGet, "/api/v1/users/me"}:                                                             authenticated,
+	{http.MethodGet, "/api/v1/users/export.csv"}:                                                     authenticated,
+	{http.MethodGet, "/api/v1/users/{id}"}:                                                           selfOrRosterCoach,
+	{http.MethodPatch, "/api/v1/users/{id}"}:                                                         selfOrRosterCoach,
+	{http.MethodDelete, "/api/v1/users/{id}"}:                                                        self,
+	{http.MethodPost, "/api/v1/users/{id}/credits"}:                                                  rosterCoach,
+	{http.MethodGet, "/api/v1/users/{id}/credits"}:                                                   selfOrRosterCoach,
+	{http.MethodGet, "/api/v1/users/{id}/credits/ledger"}:                                            selfOrRosterCoach,
+	{http.MethodPost, "/api/v1/users/{id}/calendar-token"}:                                           self,
+	{http.MethodDelete, "/api/v1/users/{id}/calendar-token"}:                                         self,
+	{http.MethodPost, "/api/v1/meetings"}:                                                            booking,
+	{http.MethodGet, "/api/v1/meetings"}:                                                             authenticated,
+	{http.MethodGet, "/api/v1/meetings/export.csv"}:                                                  authenticated,
+	{http.MethodGet, "/api/v1/meetings/{id}"}:                                                        member,
+	{http.MethodPatch, "/api/v1/meetings/{id}"}:                                                      managingCoach,
+	{http.MethodDelete, "/api/v1/meetings/{id}"}:                                                     managingCoach,
+	{http.MethodPost, "/api/v1/meetings/{id}/confirm"}:                                               managingCoach,
+	{http.MethodPost, "/api/v1/meetings/{id}/cancel"}:                                                canceller,
+	{http.MethodPost, "/api/v1/meetings/{id}/complete"}:                                              managingCoach,
+	{http.MethodPost, "/api/v1/meetings/{id}/no-show"}:                                               managingCoach,
+	{http.MethodPost, "/api/v1/meetings/{id}/participants"}:                                          authenticated,
+	{http.MethodDelete, "/api/v1/meetings/{id}/participants"}:                                        authenticated,
+	{http.MethodPost, "/api/v1/series"}:                                                              booking,
+	{http.MethodGet, "/api/v1/series/{id}"}:                                                          member,
+	{http.MethodGet, "/api/v1/coaches/{id}/availability"}:                                            authenticated,
+	{http.MethodPut, "/api/v1/coaches/{id}/availability"}:                                            ownCoach,
+	{http.MethodPost, "/api/v1/coaches/{id}/availability/overrides"}:                                 ownCoach,
+	{http.MethodDelete, "/api/v1/coaches/{id}/availability/overrides/{overrideID}"}:                  ownCoach,
+	{http.MethodGet, "/api/v1/coaches/{id}/cancellation-policy"}:                                     authenticated,
+	{http.MethodPut, "/api/v1/coaches/{id}/cancellation-policy"}:                                     ownCoach,
+	{http.MethodGet, "/api/v1/coaches/{id}/slots"}:                                                   authenticated,
+	{http.MethodPost, "/api/v1/coaches/{id}/meetings/import"}:                                        ownCoach,
+	{http.MethodPost, "/api/v1/coaches/{id}/waitlist"}:                                               authenticated,
+	{http.MethodGet, "/api/v1/waitlist"}:                                                             authenticated,
+	{http.MethodDelete, "/api/v1/waitlist/{id}"}:                                                     authenticated,
+	{http.MethodPost, "/api/v1/waitlist/{id}/accept"}:                                                authenticated,
+	{http.MethodPost, "/api/v1/waitlist/{id}/decline"}:                                               authenticated,
+	{http.MethodGet, "/api/v1/webhooks/events"}:                                                      coach,
+	{http.MethodGet, "/api/v1/coaches/{id}/webhooks"}:                                                ownCoach,
+	{http.MethodPost, "/api/v1/coaches/{id}/webhooks"}:                                               ownCoach,
+	{http.MethodGet, "/api/v1/coaches/{id}/webhooks/{webhookID}"}:                                    ownCoach,
+	{http.MethodPatch, "/api/v1/coaches/{id}/webhooks/{webhookID}"}:                                  ownCoach,
+	{http.MethodDelete, "/api/v1/coaches/{id}/webhooks/{webhookID}"}:                                 ownCoach,
+	{http.MethodGet, "/api/v1/coaches/{id}/webhooks/{webhookID}/deliveries"}:                         ownCoach,
+	{http.MethodPost, "/api/v1/coaches/{id}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver"}: ownCoach,
+}
+
+// allowed evaluates the rule of the route for the caller.
+func allowed(method, route string, claims *models.Claims, res resource) bool {
+	allow, ok := policies[policyKey{method, route}]
+	return ok && claims != nil && allow(claims, res)
+}
+
+// requirePolicy denies authenticated routes which have no rule. Handlers of routes whose rule depends
+// on the resource call authorize once they have loaded it.
+func (s *Server) requirePolicy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := policies[policyKey{r.Method, chi.RouteContext(r.Context()).RoutePattern()}]; !ok {
+			s.writeError(w, r, models.ErrForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize checks the caller may act on the resource and answers 403 when it may not.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, res resource) bool {
+	ctx := r.Context()
+	if allowed(r.Method, chi.RouteContext(ctx).RoutePattern(), s.getClaims(ctx), res) {
+		return true
+	}
+	s.writeError(w, r, models.ErrForbidden)
+	return false
+}
+
+// userResource describes the user with id. The roster is only looked up for coaches acting on other users.
+func (s *Server) userResource(ctx context.Context, id int) (resource, error) {
+	res := resource{Owner: id}
+	claims := s.getClaims(ctx)
+	if claims == nil || claims.Role != models.RoleCoach || claims.UserID == id {
+		return res, nil
+	}
+	var err error
+	res.Roster, err = s.app.InRoster(ctx, claims.UserID, id)
+	return res, err
+}
+
+func meetingResource(meeting models.Meeting) resource {
+	res := resource{Manager: meeting.Manager, Group: meeting.Capacity > 1}
+	if meeting.Client != 0 {
+		res.Clients = append(res.Clients, meeting.Client)
+	}
+	res.Clients = append(res.Clients, meeting.Participants...)
+	return res
+}
+
+// changedMeetingResource describes the meeting as a change would leave it, so the caller must be allowed
+// to act on the new manager and client as well.
+func changedMeetingResource(meeting models.Meeting, manager, client *int) resource {
+	if manager != nil {
+		meeting.Manager = *manager
+	}
+	if client != nil {
+		meeting.Client = *client
+	}
+	return meetingResource(meeting)
+}
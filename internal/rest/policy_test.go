@@ -0,0 +1,154 @@
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+const (
+	coachID = iota + 1
+	otherCoachID
+	clientID
+	otherClientID
+)
+
+var (
+	coachClaims       = &models.Claims{UserID: coachID, Role: models.RoleCoach}
+	otherCoachClaims  = &models.Claims{UserID: otherCoachID, Role: models.RoleCoach}
+	clientClaims      = &models.Claims{UserID: clientID, Role: models.RoleClient}
+	otherClientClaims = &models.Claims{UserID: otherClientID, Role: models.RoleClient}
+	callers           = []*models.Claims{coachClaims, otherCoachClaims, clientClaims, otherClientClaims}
+	everyone          = callers
+)
+
+var (
+	clientResource = resource{Owner: clientID}
+	coachResource  = resource{Owner: coachID}
+	meetingRes     = resource{Manager: coachID, Clients: []int{clientID}}
+	groupRes       = resource{Manager: coachID, Clients: []int{clientID, otherClientID}, Group: true}
+)
+
+// policyCases lists who may act on a resource of the client and the coach. The client is on the roster of the coach.
+var policyCases = []struct {
+	method  string
+	route   string
+	res     resource
+	allowed []*models.Claims
+}{
+	{http.MethodPost, "/api/v1/auth/logout", resource{}, everyone},
+	{http.MethodGet, "/api/v1/users", resource{}, everyone},
+	{http.MethodGet, "/api/v1/users/me", resource{}, everyone},
+	{http.MethodGet, "/api/v1/users/export.csv", resource{}, everyone},
+	{http.MethodGet, "/api/v1/users/{id}", clientResource, []*models.Claims{coachClaims, clientClaims}},
+	{http.MethodPatch, "/api/v1/users/{id}", clientResource, []*models.Claims{coachClaims, clientClaims}},
+	{http.MethodDelete, "/api/v1/users/{id}", clientResource, []*models.Claims{clientClaims}},
+	{http.MethodPost, "/api/v1/users/{id}/credits", clientResource, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/users/{id}/credits", resource{Owner: otherClientID}, nil},
+	{http.MethodGet, "/api/v1/users/{id}/credits", clientResource, []*models.Claims{coachClaims, clientClaims}},
+	{http.MethodGet, "/api/v1/users/{id}/credits/ledger", clientResource, []*models.Claims{coachClaims, clientClaims}},
+	{http.MethodPost, "/api/v1/users/{id}/calendar-token", clientResource, []*models.Claims{clientClaims}},
+	{http.MethodDelete, "/api/v1/users/{id}/calendar-token", clientResource, []*models.Claims{clientClaims}},
+	{http.MethodPost, "/api/v1/meetings", meetingRes, []*models.Claims{coachClaims, clientClaims}},
+	{http.MethodPost, "/api/v1/meetings", resource{Manager: coachID}, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/meetings", resource{Manager: clientID}, nil},
+	{http.MethodGet, "/api/v1/meetings", resource{}, everyone},
+	{http.MethodGet, "/api/v1/meetings/export.csv", resource{}, everyone},
+	{http.MethodGet, "/api/v1/meetings/{id}", meetingRes, []*models.Claims{coachClaims, clientClaims}},
+	{http.MethodPatch, "/api/v1/meetings/{id}", meetingRes, []*models.Claims{coachClaims}},
+	{http.MethodDelete, "/api/v1/meetings/{id}", meetingRes, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/meetings/{id}/confirm", meetingRes, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/meetings/{id}/cancel", meetingRes, []*models.Claims{coachClaims, clientClaims}},
+	{http.MethodPost, "/api/v1/meetings/{id}/cancel", groupRes, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/meetings/{id}/complete", meetingRes, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/meetings/{id}/no-show", meetingRes, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/meetings/{id}/participants", resource{}, everyone},
+	{http.MethodDelete, "/api/v1/meetings/{id}/participants", resource{}, everyone},
+	{http.MethodPost, "/api/v1/series", meetingRes, []*models.Claims{coachClaims, clientClaims}},
+	{http.MethodPost, "/api/v1/series", resource{Manager: otherCoachID, Clients: []int{otherClientID}}, []*models.Claims{otherCoachClaims, otherClientClaims}},
+	{http.MethodGet, "/api/v1/series/{id}", meetingRes, []*models.Claims{coachClaims, clientClaims}},
+	{http.MethodGet, "/api/v1/coaches/{id}/availability", coachResource, everyone},
+	{http.MethodPut, "/api/v1/coaches/{id}/availability", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/coaches/{id}/availability/overrides", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodDelete, "/api/v1/coaches/{id}/availability/overrides/{overrideID}", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodGet, "/api/v1/coaches/{id}/cancellation-policy", coachResource, everyone},
+	{http.MethodPut, "/api/v1/coaches/{id}/cancellation-policy", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodGet, "/api/v1/coaches/{id}/slots", coachResource, everyone},
+	{http.MethodPost, "/api/v1/coaches/{id}/meetings/import", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/coaches/{id}/waitlist", coachResource, everyone},
+	{http.MethodGet, "/api/v1/waitlist", resource{}, everyone},
+	{http.MethodDelete, "/api/v1/waitlist/{id}", resource{}, everyone},
+	{http.MethodPost, "/api/v1/waitlist/{id}/accept", resource{}, everyone},
+	{http.MethodPost, "/api/v1/waitlist/{id}/decline", resource{}, everyone},
+	{http.MethodGet, "/api/v1/webhooks/events", resource{}, []*models.Claims{coachClaims, otherCoachClaims}},
+	{http.MethodGet, "/api/v1/coaches/{id}/webhooks", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/coaches/{id}/webhooks", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodGet, "/api/v1/coaches/{id}/webhooks/{webhookID}", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodPatch, "/api/v1/coaches/{id}/webhooks/{webhookID}", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodDelete, "/api/v1/coaches/{id}/webhooks/{webhookID}", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodGet, "/api/v1/coaches/{id}/webhooks/{webhookID}/deliveries", coachResource, []*models.Claims{coachClaims}},
+	{http.MethodPost, "/api/v1/coaches/{id}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", coachResource, []*models.Claims{coachClaims}},
+}
+
+func TestPolicies(t *testing.T) {
+	for _, tc := range policyCases {
+		for _, caller := range callers {
+			res := tc.res
+			res.Roster = caller == coachClaims && res.Owner == clientID
+			want := false
+			for _, claims := range tc.allowed {
+				want = want || claims == caller
+			}
+			if got := allowed(tc.method, tc.route, caller, res); got != want {
+				t.Errorf("%s %s by %s %d: allowed = %v, want %v", tc.method, tc.route, caller.Role, caller.UserID, got, want)
+			}
+		}
+		if allowed(tc.method, tc.route, nil, tc.res) {
+			t.Errorf("%s %s: allowed without claims", tc.method, tc.route)
+		}
+	}
+}
+
+func TestChangedMeetingResource(t *testing.T) {
+	meeting := models.Meeting{ID: 1, Manager: coachID, Client: clientID, Capacity: 1}
+	route := "/api/v1/meetings/{id}"
+	otherCoach, otherClient := otherCoachID, otherClientID
+	if !allowed(http.MethodPatch, route, coachClaims, changedMeetingResource(meeting, nil, &otherClient)) {
+		t.Error("managing coach can't change the client")
+	}
+	if allowed(http.MethodPatch, route, coachClaims, changedMeetingResource(meeting, &otherCoach, nil)) {
+		t.Error("managing coach can move the meeting to another coach")
+	}
+}
+
+func TestPoliciesCoverRoutes(t *testing.T) {
+	s := New(logrus.New(), struct{ App }{}, nil, nil, ":0", "test")
+	routes := make(map[policyKey]bool)
+	err := chi.Walk(s.server.Handler.(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := policyKey{method, route}
+		routes[key] = true
+		if _, ok := policies[key]; !ok && !publicRoutes[key] {
+			t.Errorf("%s %s has no policy", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tested := make(map[policyKey]bool)
+	for _, tc := range policyCases {
+		tested[policyKey{tc.method, tc.route}] = true
+	}
+	for key := range policies {
+		if !routes[key] {
+			t.Errorf("%s %s has a policy but no route", key.method, key.route)
+		}
+		if !tested[key] {
+			t.Errorf("%s %s has no test case", key.method, key.route)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Code is stable, the codes are listed in docs/errors.md.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Code     string              `json:"code"`
+	Detail   string              `json:"detail,omitempty"`
+	Errors   []models.FieldError `json:"errors,omitempty"`
+	Meetings []models.Meeting    `json:"meetings,omitempty"`
+}
+
+var statusByCode = map[string]int{
+	models.CodeValidationFailed:           http.StatusUnprocessableEntity,
+	models.CodeInvalidCredentials:         http.StatusUnauthorized,
+	models.CodeTooManyAttempts:            http.StatusTooManyRequests,
+	models.CodeLoginLocked:                http.StatusTooManyRequests,
+	models.CodeInvalidRefreshToken:        http.StatusUnauthorized,
+	models.CodeSessionRevoked:             http.StatusUnauthorized,
+	models.CodeSessionNotFound:            http.StatusUnauthorized,
+	models.CodeForbidden:                  http.StatusForbidden,
+	models.CodePreconditionFailed:         http.StatusPreconditionFailed,
+	models.CodeIdempotencyKeyReused:       http.StatusUnprocessableEntity,
+	models.CodeRequestInProgress:          http.StatusConflict,
+	models.CodeInvalidCursor:              http.StatusBadRequest,
+	models.CodeUserNotFound:               http.StatusNotFound,
+	models.CodeUserExists:                 http.StatusConflict,
+	models.CodeInvalidTimeZone:            http.StatusUnprocessableEntity,
+	models.CodeMeetingNotFound:            http.StatusNotFound,
+	models.CodeMeetingConflict:            http.StatusConflict,
+	models.CodeInvalidStatus:              http.StatusBadRequest,
+	models.CodeInvalidTransition:          http.StatusConflict,
+	models.CodeInvalidCapacity:            http.StatusBadRequest,
+	models.CodeNotGroupSession:            http.StatusConflict,
+	models.CodeMeetingNotActive:           http.StatusConflict,
+	models.CodeMeetingFull:                http.StatusConflict,
+	models.CodeAlreadyParticipant:         http.StatusConflict,
+	models.CodeNotParticipant:             http.StatusNotFound,
+	models.CodeAvailabilityNotFound:       http.StatusNotFound,
+	models.CodeOverrideNotFound:           http.StatusNotFound,
+	models.CodeInvalidAvailability:        http.StatusUnprocessableEntity,
+	models.CodeOutsideAvailability:        http.StatusUnprocessableEntity,
+	models.CodeInvalidSlotRange:           http.StatusUnprocessableEntity,
+	models.CodeSeriesNotFound:             http.StatusNotFound,
+	models.CodeInvalidSeries:              http.StatusUnprocessableEntity,
+	models.CodeNotInSeries:                http.StatusUnprocessableEntity,
+	models.CodeCancellationPolicyNotFound: http.StatusNotFound,
+	models.CodeInvalidCancellationPolicy:  http.StatusUnprocessableEntity,
+	models.CodeLateCancellation:           http.StatusUnprocessableEntity,
+	models.CodeWaitlistEntryNotFound:      http.StatusNotFound,
+	models.CodeInvalidWaitlistSlot:        http.StatusBadRequest,
+	models.CodeSlotAvailable:              http.StatusConflict,
+	models.CodeAlreadyWaitlisted:          http.StatusConflict,
+	models.CodeWaitlistEntryClosed:        http.StatusConflict,
+	models.CodeSlotHeld:                   http.StatusConflict,
+	models.CodeInvalidCreditPackage:       http.StatusUnprocessableEntity,
+	models.CodeInsufficientCredits:        http.StatusPaymentRequired,
+	models.CodeWebhookNotFound:            http.StatusNotFound,
+	models.CodeWebhookDeliveryNotFound:    http.StatusNotFound,
+	models.CodeInvalidCalendarToken:       http.StatusUnauthorized,
+	models.CodeInvalidCalendar:            http.StatusUnprocessableEntity,
+	models.CodeAlreadyImported:            http.StatusConflict,
+}
+
+// newProblem describes err to the client. Only domain errors and errors of client requests are shown,
+// details of server errors stay in the logs.
+func newProblem(status int, err error) Problem {
+	title := http.StatusText(status)
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  title,
+		Status: status,
+		Code:   strings.ToUpper(strings.ReplaceAll(title, " ", "_")),
+	}
+	if status >= http.StatusInternalServerError {
+		return problem
+	}
+	var validationErr *models.ValidationError
+	var domainErr *models.Error
+	var conflictErr *pgstore.MeetingConflictError
+	switch {
+	case errors.As(err, &validationErr):
+		problem.Code = models.CodeValidationFailed
+		problem.Detail = validationErr.Error()
+		problem.Errors = validationErr.Fields
+	case errors.As(err, &domainErr):
+		problem.Code = domainErr.Code
+		problem.Detail = domainErr.Error()
+	default:
+		problem.Detail = err.Error()
+	}
+	if errors.As(err, &conflictErr) {
+		problem.Meetings = conflictErr.Meetings
+	}
+	return problem
+}
+
+// writeError answers with the status of the domain error err. Other errors are logged and answered with 500.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	var domainErr *models.Error
+	switch {
+	case errors.Is(err, models.ErrValidationFailed):
+		status = http.StatusUnprocessableEntity
+	case errors.As(err, &domainErr):
+		if code, ok := statusByCode[domainErr.Code]; ok {
+			status = code
+		}
+	}
+	if status == http.StatusInternalServerError {
+		s.log.Warnf("err during %s %s: %v", r.Method, r.URL.Path, err)
+	}
+	s.writeResponse(w, status, err)
+}
@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+)
+
+func TestNewProblem(t *testing.T) {
+	wrapped := fmt.Errorf("err getting user (id 3) from store: %w", pgstore.ErrUserNotFound)
+	problem := newProblem(http.StatusNotFound, wrapped)
+	require.Equal(t, models.CodeUserNotFound, problem.Code)
+	require.Equal(t, "user not found", problem.Detail)
+	require.Equal(t, "Not Found", problem.Title)
+
+	problem = newProblem(http.StatusInternalServerError, errors.New(`pq: relation "users" does not exist`))
+	require.Equal(t, "INTERNAL_SERVER_ERROR", problem.Code)
+	require.Empty(t, problem.Detail)
+
+	problem = newProblem(http.StatusBadRequest, errors.New("invalid scope"))
+	require.Equal(t, "BAD_REQUEST", problem.Code)
+	require.Equal(t, "invalid scope", problem.Detail)
+
+	conflict := &pgstore.MeetingConflictError{Meetings: []models.Meeting{{ID: 7}}}
+	problem = newProblem(http.StatusConflict, fmt.Errorf("err creating meeting: %w", conflict))
+	require.Equal(t, models.CodeMeetingConflict, problem.Code)
+	require.Len(t, problem.Meetings, 1)
+
+	field := models.FieldError{Field: "email", Message: "invalid email"}
+	problem = newProblem(http.StatusUnprocessableEntity, models.NewValidationError(field))
+	require.Equal(t, models.CodeValidationFailed, problem.Code)
+	require.Equal(t, []models.FieldError{field}, problem.Errors)
+}
+
+func TestStatusByCode(t *testing.T) {
+	s := New(logrus.New(), struct{ App }{}, nil, nil, ":0", "test")
+	for _, tc := range []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("wrapped: %w", pgstore.ErrMeetingNotFound), http.StatusNotFound},
+		{models.ErrInsufficientCredits.Errorf("coach %d", 1), http.StatusPaymentRequired},
+		{models.NewValidationError(), http.StatusUnprocessableEntity},
+		{&pgstore.MeetingConflictError{}, http.StatusConflict},
+		{models.ErrForbidden, http.StatusForbidden},
+	} {
+		w := httptest.NewRecorder()
+		s.writeError(w, &http.Request{}, tc.err)
+		require.Equal(t, tc.status, w.Code, tc.err.Error())
+		require.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"errors"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+var (
+	errOutOfScope = errors.New("resource is out of the caller scope")
+	errNotMine    = errors.New("mine=false is not supported, only the meetings of the caller are listed")
+)
+
+// scopeUsers restricts the user list to the users visible to the caller. Clients see only
+// themselves and coaches see their roster.
+func scopeUsers(claims *models.Claims, filter *models.UserFilter) {
+	if claims.Role == models.RoleCoach {
+		filter.Coach = &claims.UserID
+		return
+	}
+	filter.ID = &claims.UserID
+}
+
+// scopeMeetings restricts the meeting list to the meetings of the caller. Coaches see the
+// meetings they manage and clients see the meetings they take part in.
+func scopeMeetings(claims *models.Claims, filter *models.MeetingFilter) error {
+	own := &filter.Client
+	if claims.Role == models.RoleCoach {
+		own = &filter.Manager
+	}
+	if *own != nil && **own != claims.UserID {
+		return errOutOfScope
+	}
+	*own = &claims.UserID
+	return nil
+}
@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+var ErrInvalidScope = errors.New("invalid scope")
+
+func (s *Server) createSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var data models.SeriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	var res resource
+	if data.Manager != nil {
+		res.Manager = *data.Manager
+	}
+	if data.Client != nil {
+		res.Clients = []int{*data.Client}
+	}
+	if !s.authorize(w, r, res) {
+		return
+	}
+	createdSeries, err := s.app.CreateSeries(ctx, data)
+	s.writeSeriesResponse(w, r, http.StatusCreated, createdSeries, err)
+}
+
+func (s *Server) getSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	series, err := s.app.GetSeries(ctx, id)
+	if err == nil && !s.authorize(w, r, resource{Manager: series.Manager, Clients: []int{series.Client}}) {
+		return
+	}
+	s.writeSeriesResponse(w, r, http.StatusOK, series, err)
+}
+
+func (s *Server) updateSeriesMeetings(w http.ResponseWriter, r *http.Request, meeting models.Meeting, scope string) {
+	ctx := r.Context()
+	var data models.SeriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, changedMeetingResource(meeting, data.Manager, data.Client)) {
+		return
+	}
+	series, err := s.app.UpdateSeriesMeetings(ctx, meeting.ID, s.getClaims(ctx).UserID, scope, data)
+	s.writeSeriesResponse(w, r, http.StatusOK, series, err)
+}
+
+func (s *Server) deleteSeriesMeetings(w http.ResponseWriter, r *http.Request, id int, scope string) {
+	ctx := r.Context()
+	series, err := s.app.DeleteSeriesMeetings(ctx, id, s.getClaims(ctx).UserID, scope)
+	s.writeSeriesResponse(w, r, http.StatusOK, series, err)
+}
+
+func (s *Server) writeSeriesResponse(w http.ResponseWriter, r *http.Request, status int, series models.Series, err error) {
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, status, series)
+}
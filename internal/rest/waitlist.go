@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *Server) joinWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	var data models.WaitlistRequest
+	if err = json.NewDecoder(r.Body).Decode(&data); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	claims := s.getClaims(ctx)
+	entry, err := s.app.JoinWaitlist(ctx, id, claims.UserID, data)
+	s.writeWaitlistResponse(w, r, http.StatusCreated, entry, err)
+}
+
+func (s *Server) getWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims := s.getClaims(ctx)
+	entries, err := s.app.GetWaitlist(ctx, claims.UserID)
+	if err != nil {
+		s.log.Warnf("err during getting waitlist: %v", err)
+		s.writeResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, entries)
+}
+
+func (s *Server) leaveWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	claims := s.getClaims(ctx)
+	entry, err := s.app.LeaveWaitlist(ctx, id, claims.UserID)
+	s.writeWaitlistResponse(w, r, http.StatusOK, entry, err)
+}
+
+func (s *Server) acceptWaitlistOfferHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	claims := s.getClaims(ctx)
+	meeting, err := s.app.AcceptWaitlistOffer(ctx, id, claims.UserID)
+	s.writeWaitlistResponse(w, r, http.StatusCreated, meeting, err)
+}
+
+func (s *Server) declineWaitlistOfferHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	claims := s.getClaims(ctx)
+	entry, err := s.app.DeclineWaitlistOffer(ctx, id, claims.UserID)
+	s.writeWaitlistResponse(w, r, http.StatusOK, entry, err)
+}
+
+func (s *Server) writeWaitlistResponse(w http.ResponseWriter, r *http.Request, status int, data interface{}, err error) {
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, status, data)
+}
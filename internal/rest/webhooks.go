@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *Server) getWebhookEventsHandler(w http.ResponseWriter, _ *http.Request) {
+	s.writeResponse(w, http.StatusOK, models.WebhookEvents)
+}
+
+func (s *Server) getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
+	}
+	webhooks, err := s.app.GetWebhooks(ctx, id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, webhooks)
+}
+
+func (s *Server) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.authorize(w, r, resource{Owner: id}) {
+		return
+	}
+	var data models.WebhookRequest
+	if err = json.NewDecoder(r.Body).Decode(&data); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	webhook, err := s.app.CreateWebhook(ctx, id, data)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusCreated, webhook)
+}
+
+func (s *Server) getWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, webhookID, ok := s.webhookParams(w, r)
+	if !ok {
+		return
+	}
+	webhook, err := s.app.GetWebhook(ctx, id, webhookID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, webhook)
+}
+
+func (s *Server) updateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, webhookID, ok := s.webhookParams(w, r)
+	if !ok {
+		return
+	}
+	var data models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	webhook, err := s.app.UpdateWebhook(ctx, id, webhookID, data)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, webhook)
+}
+
+func (s *Server) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, webhookID, ok := s.webhookParams(w, r)
+	if !ok {
+		return
+	}
+	webhook, err := s.app.DeleteWebhook(ctx, id, webhookID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, webhook)
+}
+
+func (s *Server) getWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, webhookID, ok := s.webhookParams(w, r)
+	if !ok {
+		return
+	}
+	deliveries, err := s.app.GetWebhookDeliveries(ctx, id, webhookID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusOK, deliveries)
+}
+
+func (s *Server) redeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, webhookID, ok := s.webhookParams(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "deliveryID"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	delivery, err := s.app.RedeliverWebhookDelivery(ctx, id, webhookID, deliveryID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeResponse(w, http.StatusCreated, delivery)
+}
+
+// webhookParams reads the coach and the webhook of the route and checks the caller is the coach.
+func (s *Server) webhookParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "id"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return 0, 0, false
+	}
+	webhookID, err := strconv.Atoi(chi.URLParamFromCtx(ctx, "webhookID"))
+	if err != nil {
+		s.writeResponse(w, http.StatusBadRequest, err)
+		return 0, 0, false
+	}
+	return id, webhookID, s.authorize(w, r, resource{Owner: id})
+}
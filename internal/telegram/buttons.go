@@ -36,3 +36,6 @@ var (
 	notificationBtn  = settings.Data("Напоминание", "notify")
 	cancelMeetingBtn = settings.Data("Отмена тренировки", "cancel")
 )
+
+// cancelOccurrenceBtn is a template of buttons which carry the id of the meeting to cancel.
+var cancelOccurrenceBtn = tele.Btn{Unique: "cancel_meeting"}
@@ -1,6 +1,8 @@
 package telegram
 
 const (
-	cmdStart = "/start"
-	cmdInfo  = "/info"
+	cmdStart   = "/start"
+	cmdInfo    = "/info"
+	cmdAccept  = "/accept"
+	cmdDecline = "/decline"
 )
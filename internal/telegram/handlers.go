@@ -1,17 +1,28 @@
 package telegram
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
 
 	tele "gopkg.in/telebot.v3"
 )
 
 var coachCode = "TRAIN"
 
+const (
+	slotsPeriod  = 7 * 24 * time.Hour
+	slotDuration = time.Hour
+	timeLayout   = "02.01 15:04"
+)
+
 func (t *Telegram) initHandlers() {
 	t.bot.Handle(cmdStart, t.startHandler)
 	t.bot.Handle(&registrationBtn, t.registrationHandler)
@@ -19,6 +30,9 @@ func (t *Telegram) initHandlers() {
 	t.bot.Handle(&myMeetingBtn, t.meetingsHandler)
 	t.bot.Handle(&notificationBtn, t.notifyHandler)
 	t.bot.Handle(&cancelMeetingBtn, t.cancelMeetingHandler)
+	t.bot.Handle(&cancelOccurrenceBtn, t.cancelOccurrenceHandler)
+	t.bot.Handle(cmdAccept, t.acceptOfferHandler)
+	t.bot.Handle(cmdDecline, t.declineOfferHandler)
 	t.bot.Handle(tele.OnText, t.textHandler)
 }
 
@@ -63,11 +77,19 @@ func (t *Telegram) registrationHandler(ctx tele.Context) error {
 
 func (t *Telegram) scheduleHandler(ctx tele.Context) error {
 	// TODO: кейс записи на тренировку
-	events := t.cal.Events()
+	from := time.Now()
+	slots, err := t.app.GetSlots(context.Background(), t.coachID, from, from.Add(slotsPeriod), slotDuration)
+	if err != nil {
+		return fmt.Errorf("tg get slots faild: %w", err)
+	}
+	loc := t.location(ctx)
 	var msg strings.Builder
 	msg.WriteString("Здесь доступное расписание тренера, на которое можно записаться.\n\n")
-	for _, event := range events {
-		msg.WriteString(fmt.Sprintf("%s\n", event))
+	if len(slots) == 0 {
+		msg.WriteString("Свободных окон нет.\n")
+	}
+	for _, slot := range slots {
+		msg.WriteString(fmt.Sprintf("%s\n", slot.StartTime.In(loc).Format(timeLayout)))
 	}
 	return ctx.Edit(msg.String(), showMeetings)
 }
@@ -85,7 +107,107 @@ func (t *Telegram) notifyHandler(ctx tele.Context) error {
 }
 
 func (t *Telegram) cancelMeetingHandler(ctx tele.Context) error {
-	// TODO: canceling meeting
-	msg := "Отмена занятия"
+	userID := int(ctx.Sender().ID)
+	from := time.Now()
+	page, err := t.app.GetMeetings(context.Background(), models.MeetingFilter{
+		Statuses: []string{models.StatusRequested, models.StatusConfirmed},
+		Client:   &userID,
+		From:     &from,
+	})
+	if err != nil {
+		return fmt.Errorf("tg get meetings faild: %w", err)
+	}
+	meetings := page.Meetings
+	if len(meetings) == 0 {
+		return ctx.Edit("Нет тренировок, которые можно отменить.", showMeetings)
+	}
+	loc := t.location(ctx)
+	markup := &tele.ReplyMarkup{}
+	rows := make([]tele.Row, 0, len(meetings))
+	for _, meeting := range meetings {
+		rows = append(rows, markup.Row(markup.Data(meeting.StartTime.In(loc).Format(timeLayout), cancelOccurrenceBtn.Unique, strconv.Itoa(meeting.ID))))
+	}
+	markup.Inline(rows...)
+	return ctx.Edit("Какую тренировку отменить?", markup)
+}
+
+func (t *Telegram) cancelOccurrenceHandler(ctx tele.Context) error {
+	id, err := strconv.Atoi(ctx.Data())
+	if err != nil {
+		return fmt.Errorf("tg parse meeting id faild: %w", err)
+	}
+	userID := int(ctx.Sender().ID)
+	meeting, err := t.app.GetMeeting(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("tg get meeting faild: %w", err)
+	}
+	if meeting.Client != userID {
+		return ctx.Edit("Эта тренировка не ваша.", showMeetings)
+	}
+	meeting, err = t.app.CancelMeeting(context.Background(), id, userID, nil)
+	switch {
+	case errors.Is(err, models.ErrLateCancellation):
+		return ctx.Edit("Бесплатная отмена уже недоступна, обратитесь к тренеру.", showMeetings)
+	case errors.Is(err, models.ErrInvalidTransition):
+		return ctx.Edit("Эту тренировку уже нельзя отменить.", showMeetings)
+	case err != nil:
+		return fmt.Errorf("tg cancel meeting faild: %w", err)
+	}
+	msg := "Тренировка отменена."
+	if meeting.LateCancellation != nil && *meeting.LateCancellation {
+		msg = "Тренировка отменена поздно и будет засчитана как проведённая."
+	}
 	return ctx.Edit(msg, showMeetings)
 }
+
+func (t *Telegram) acceptOfferHandler(ctx tele.Context) error {
+	id, err := offerID(ctx)
+	if err != nil {
+		return ctx.Send("Укажите номер предложения, например /accept 1")
+	}
+	meeting, err := t.app.AcceptWaitlistOffer(context.Background(), id, int(ctx.Sender().ID))
+	switch {
+	case errors.Is(err, pgstore.ErrWaitlistEntryNotFound):
+		return ctx.Send("Предложение не найдено.")
+	case errors.Is(err, models.ErrWaitlistEntryClosed):
+		return ctx.Send("Предложение больше не действует.")
+	case errors.Is(err, pgstore.ErrMeetingConflict):
+		return ctx.Send("В это время у вас уже есть тренировка.")
+	case err != nil:
+		return fmt.Errorf("tg accept waitlist offer faild: %w", err)
+	}
+	return ctx.Send(fmt.Sprintf("Вы записаны на %s.", meeting.StartTime.In(t.location(ctx)).Format(timeLayout)))
+}
+
+func (t *Telegram) declineOfferHandler(ctx tele.Context) error {
+	id, err := offerID(ctx)
+	if err != nil {
+		return ctx.Send("Укажите номер предложения, например /decline 1")
+	}
+	_, err = t.app.DeclineWaitlistOffer(context.Background(), id, int(ctx.Sender().ID))
+	switch {
+	case errors.Is(err, pgstore.ErrWaitlistEntryNotFound):
+		return ctx.Send("Предложение не найдено.")
+	case errors.Is(err, models.ErrWaitlistEntryClosed):
+		return ctx.Send("Предложение больше не действует.")
+	case err != nil:
+		return fmt.Errorf("tg decline waitlist offer faild: %w", err)
+	}
+	return ctx.Send("Вы отказались от предложения.")
+}
+
+// location returns the time zone of the sender falling back to UTC for unknown users.
+func (t *Telegram) location(ctx tele.Context) *time.Location {
+	user, err := t.app.GetUser(context.Background(), int(ctx.Sender().ID))
+	if err != nil {
+		return time.UTC
+	}
+	return user.Location()
+}
+
+func offerID(ctx tele.Context) (int, error) {
+	if len(ctx.Args()) != 1 {
+		return 0, errors.New("offer id is required")
+	}
+	return strconv.Atoi(ctx.Args()[0])
+}
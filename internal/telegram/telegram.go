@@ -3,7 +3,6 @@ package telegram
 import (
 	"context"
 	"fmt"
-	"github.com/pershin-daniil/TimeSlots/internal/calendar"
 	"time"
 
 	"github.com/pershin-daniil/TimeSlots/pkg/models"
@@ -13,26 +12,29 @@ import (
 )
 
 type Telegram struct {
-	log *logrus.Entry
-	bot *tele.Bot
-	app App
-	cal Calendar
+	log     *logrus.Entry
+	bot     *tele.Bot
+	app     App
+	coachID int
 }
 
 type App interface {
 	CreateUser(ctx context.Context, user models.UserRequest) (models.User, error)
+	GetUser(ctx context.Context, id int) (models.User, error)
+	GetSlots(ctx context.Context, coachID int, from, to time.Time, duration time.Duration) ([]models.Slot, error)
+	GetMeetings(ctx context.Context, filter models.MeetingFilter) (models.MeetingPage, error)
+	GetMeeting(ctx context.Context, id int) (models.Meeting, error)
+	CancelMeeting(ctx context.Context, id, userID int, reason *string) (models.Meeting, error)
+	AcceptWaitlistOffer(ctx context.Context, id, clientID int) (models.Meeting, error)
+	DeclineWaitlistOffer(ctx context.Context, id, clientID int) (models.WaitlistEntry, error)
 }
 
-type Calendar interface {
-	Events() []models.Event
-}
-
-func New(log *logrus.Logger, bot *tele.Bot, app App, cal *calendar.Calendar) (*Telegram, error) {
+func New(log *logrus.Logger, bot *tele.Bot, app App, coachID int) (*Telegram, error) {
 	t := Telegram{
-		log: log.WithField("module", "telegram"),
-		bot: bot,
-		app: app,
-		cal: cal,
+		log:     log.WithField("module", "telegram"),
+		bot:     bot,
+		app:     app,
+		coachID: coachID,
 	}
 	t.initButtons()
 	t.initHandlers()
@@ -0,0 +1,291 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCalendar = errors.New("invalid calendar")
+
+const (
+	localLayout = "20060102T150405"
+	dateLayout  = "20060102"
+)
+
+// Attendee is an ATTENDEE of an event. Email and Phone come from mailto: and tel: addresses.
+type Attendee struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode reads the events of an RFC 5545 calendar. Floating times, dates and times in time zones unknown
+// to the system are read in loc. Components other than VEVENT, such as VTIMEZONE and VALARM, are skipped.
+func Decode(r io.Reader, loc *time.Location) (Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return Calendar{}, err
+	}
+	var (
+		cal   Calendar
+		stack []string
+		event *Event
+		// ends tells whether DTEND or DURATION of the event was given.
+		ends bool
+	)
+	for i, line := range lines {
+		fail := func(err error) (Calendar, error) {
+			return Calendar{}, fmt.Errorf("%w: line %d: %v", ErrInvalidCalendar, i+1, err)
+		}
+		if line == "" {
+			continue
+		}
+		prop, err := parseProperty(line)
+		if err != nil {
+			return fail(err)
+		}
+		switch prop.name {
+		case "BEGIN":
+			component := strings.ToUpper(prop.value)
+			if len(stack) == 0 && component != "VCALENDAR" {
+				return fail(errors.New("calendar does not start with BEGIN:VCALENDAR"))
+			}
+			stack = append(stack, component)
+			if component == "VEVENT" && len(stack) == 2 {
+				event, ends = &Event{}, false
+			}
+			continue
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1] != strings.ToUpper(prop.value) {
+				return fail(fmt.Errorf("unexpected END:%s", prop.value))
+			}
+			stack = stack[:len(stack)-1]
+			if event != nil && len(stack) == 1 {
+				if event.Start.IsZero() {
+					return fail(fmt.Errorf("event %q has no DTSTART", event.UID))
+				}
+				if !ends {
+					event.End = event.Start
+					if event.AllDay {
+						event.End = event.Start.AddDate(0, 0, 1)
+					}
+				}
+				cal.Events = append(cal.Events, *event)
+				event = nil
+			}
+			continue
+		}
+		switch {
+		case len(stack) == 1 && prop.name == "X-WR-CALNAME":
+			cal.Name = unescape(prop.value)
+		case event != nil && len(stack) == 2:
+			if err = event.set(prop, loc, &ends); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return Calendar{}, fmt.Errorf("%w: %s is not closed", ErrInvalidCalendar, stack[len(stack)-1])
+	}
+	if len(lines) == 0 {
+		return Calendar{}, fmt.Errorf("%w: empty calendar", ErrInvalidCalendar)
+	}
+	return cal, nil
+}
+
+func (e *Event) set(prop property, loc *time.Location, ends *bool) error {
+	var err error
+	switch prop.name {
+	case "UID":
+		e.UID = prop.value
+	case "SUMMARY":
+		e.Summary = unescape(prop.value)
+	case "DESCRIPTION":
+		e.Description = unescape(prop.value)
+	case "STATUS":
+		e.Status = strings.ToUpper(prop.value)
+	case "SEQUENCE":
+		e.Sequence, _ = strconv.Atoi(prop.value)
+	case "DTSTART":
+		e.Start, e.AllDay, err = parseTime(prop, prop.value, loc)
+	case "DTEND":
+		e.End, _, err = parseTime(prop, prop.value, loc)
+		*ends = true
+	case "DURATION":
+		var d time.Duration
+		if d, err = parseDuration(prop.value); err == nil {
+			e.End = e.Start.Add(d)
+			*ends = true
+		}
+	case "RRULE":
+		e.Rule = prop.value
+	case "EXDATE":
+		for _, value := range strings.Split(prop.value, ",") {
+			var t time.Time
+			if t, _, err = parseTime(prop, value, loc); err != nil {
+				break
+			}
+			e.ExDates = append(e.ExDates, t)
+		}
+	case "RECURRENCE-ID":
+		var t time.Time
+		if t, _, err = parseTime(prop, prop.value, loc); err == nil {
+			e.RecurrenceID = &t
+		}
+	case "ATTENDEE":
+		attendee := Attendee{Name: prop.params["CN"]}
+		address := prop.value
+		switch {
+		case len(address) > 7 && strings.EqualFold(address[:7], "mailto:"):
+			attendee.Email = address[7:]
+		case len(address) > 4 && strings.EqualFold(address[:4], "tel:"):
+			attendee.Phone = address[4:]
+		}
+		e.Attendees = append(e.Attendees, attendee)
+	case "CREATED":
+		e.Created, _, err = parseTime(prop, prop.value, loc)
+	case "LAST-MODIFIED":
+		e.LastModified, _, err = parseTime(prop, prop.value, loc)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", prop.name, err)
+	}
+	return nil
+}
+
+// unfold reads the content lines, joining the folded ones.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+	return lines, nil
+}
+
+// parseProperty splits a content line into the name, the parameters and the value. Parameter values
+// may be quoted and then contain ";", ":" and ",".
+func parseProperty(line string) (property, error) {
+	prop := property{params: map[string]string{}}
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return property{}, fmt.Errorf("malformed line %q", line)
+	}
+	prop.name = strings.ToUpper(line[:end])
+	rest := line[end:]
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return property{}, fmt.Errorf("malformed parameter in %q", line)
+		}
+		key := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return property{}, fmt.Errorf("unterminated quote in %q", line)
+			}
+			value, rest = rest[1:closing+1], rest[closing+2:]
+		} else {
+			stop := strings.IndexAny(rest, ";:")
+			if stop < 0 {
+				return property{}, fmt.Errorf("malformed line %q", line)
+			}
+			value, rest = rest[:stop], rest[stop:]
+		}
+		prop.params[key] = value
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return property{}, fmt.Errorf("malformed line %q", line)
+	}
+	prop.value = rest[1:]
+	return prop, nil
+}
+
+// parseTime parses a DATE or a DATE-TIME value and reports whether it is a date.
+func parseTime(prop property, value string, loc *time.Location) (time.Time, bool, error) {
+	if prop.params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(timeLayout, value)
+		return t, false, err
+	}
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if zone, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = zone
+		}
+	}
+	t, err := time.ParseInLocation(localLayout, value, loc)
+	return t, false, err
+}
+
+// parseDuration parses durations such as PT1H30M, P1D or P1W. Negative durations are rejected.
+func parseDuration(value string) (time.Duration, error) {
+	rest := strings.TrimPrefix(value, "+")
+	if !strings.HasPrefix(rest, "P") || len(rest) < 3 {
+		return 0, fmt.Errorf("malformed duration %q", value)
+	}
+	rest = rest[1:]
+	var (
+		total   time.Duration
+		inTime  bool
+		digits  string
+		units   = map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+		clock   = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		anyPart bool
+	)
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits += string(c)
+		case c == 'T' && !inTime && digits == "":
+			inTime = true
+		default:
+			unit, ok := units[c]
+			if inTime {
+				unit, ok = clock[c]
+			}
+			n, err := strconv.Atoi(digits)
+			if !ok || err != nil {
+				return 0, fmt.Errorf("malformed duration %q", value)
+			}
+			total += time.Duration(n) * unit
+			digits, anyPart = "", true
+		}
+	}
+	if digits != "" || !anyPart {
+		return 0, fmt.Errorf("malformed duration %q", value)
+	}
+	return total, nil
+}
+
+var unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescape(text string) string {
+	return unescaper.Replace(text)
+}
@@ -0,0 +1,128 @@
+package ical
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	data := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"X-WR-CALNAME:Old tool",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Berlin",
+		"BEGIN:STANDARD",
+		"DTSTART:19701025T030000",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:weekly-1",
+		"DTSTART;TZID=Europe/Berlin:20230703T100000",
+		"DURATION:PT1H30M",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO;COUNT=4",
+		"EXDATE;TZID=Europe/Berlin:20230710T100000,20230717T100000",
+		`SUMMARY:Training\, legs`,
+		"ATTENDEE;CN=\"Ivanov, Ivan\";ROLE=REQ-PARTICIPANT:mailto:ivan@example.com",
+		"ATTENDEE:tel:+79991234567",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT15M",
+		"DESCRIPTION:Reminder",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:weekly-1",
+		"RECURRENCE-ID;TZID=Europe/Berlin:20230724T100000",
+		"DTSTART:20230724T100000Z",
+		"DTEND:20230724T113000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:floating",
+		"DTSTART:20230705T090000",
+		"DTEND;TZID=Windows/Unknown:20230705T100000",
+		"STATUS:cancelled",
+		"DESCRIPTION:a long description which is folded because it is longer than seve",
+		" nty five octets",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:all-day",
+		"DTSTART;VALUE=DATE:20230706",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	cal, err := Decode(strings.NewReader(data), moscow)
+	require.NoError(t, err)
+	require.Equal(t, "Old tool", cal.Name)
+	require.Len(t, cal.Events, 4)
+
+	weekly := cal.Events[0]
+	require.Equal(t, "weekly-1", weekly.UID)
+	require.True(t, time.Date(2023, 7, 3, 10, 0, 0, 0, berlin).Equal(weekly.Start))
+	require.Equal(t, 90*time.Minute, weekly.End.Sub(weekly.Start))
+	require.Equal(t, "FREQ=WEEKLY;BYDAY=MO;COUNT=4", weekly.Rule)
+	require.Len(t, weekly.ExDates, 2)
+	require.True(t, time.Date(2023, 7, 17, 10, 0, 0, 0, berlin).Equal(weekly.ExDates[1]))
+	require.Equal(t, "Training, legs", weekly.Summary)
+	require.Equal(t, []Attendee{{Name: "Ivanov, Ivan", Email: "ivan@example.com"}, {Phone: "+79991234567"}}, weekly.Attendees)
+	require.Empty(t, weekly.Description)
+
+	override := cal.Events[1]
+	require.NotNil(t, override.RecurrenceID)
+	require.True(t, time.Date(2023, 7, 24, 10, 0, 0, 0, berlin).Equal(*override.RecurrenceID))
+
+	floating := cal.Events[2]
+	require.True(t, time.Date(2023, 7, 5, 9, 0, 0, 0, moscow).Equal(floating.Start))
+	require.Equal(t, time.Hour, floating.End.Sub(floating.Start))
+	require.Equal(t, StatusCancelled, floating.Status)
+	require.Equal(t, "a long description which is folded because it is longer than seventy five octets", floating.Description)
+
+	allDay := cal.Events[3]
+	require.True(t, allDay.AllDay)
+	require.Equal(t, 24*time.Hour, allDay.End.Sub(allDay.Start))
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"empty":          "",
+		"not a calendar": "BEGIN:VCARD\r\nEND:VCARD\r\n",
+		"not closed":     "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:20230705T090000Z\r\n",
+		"no start":       "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		"bad time":       "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:tomorrow\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		"bad duration":   "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:20230705T090000Z\r\nDURATION:1H\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		"malformed line": "BEGIN:VCALENDAR\r\nno colon here\r\nEND:VCALENDAR\r\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(data), time.UTC)
+			require.True(t, errors.Is(err, ErrInvalidCalendar), err)
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"PT15M":     15 * time.Minute,
+		"PT1H30M":   90 * time.Minute,
+		"P1D":       24 * time.Hour,
+		"P1W":       7 * 24 * time.Hour,
+		"P1DT2H":    26 * time.Hour,
+		"+PT45M10S": 45*time.Minute + 10*time.Second,
+	} {
+		got, err := parseDuration(value)
+		require.NoError(t, err, value)
+		require.Equal(t, want, got, value)
+	}
+	for _, value := range []string{"PT", "P", "1H", "PT1X", "-PT1H", "PT1H30"} {
+		_, err := parseDuration(value)
+		require.Error(t, err, value)
+	}
+}
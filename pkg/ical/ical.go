@@ -0,0 +1,126 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+const (
+	prodID     = "-//TimeSlots//TimeSlots//EN"
+	timeLayout = "20060102T150405Z"
+	// maxLineLength is the limit of a content line in octets, longer lines are folded.
+	maxLineLength = 75
+)
+
+// Calendar is a VCALENDAR of events.
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Event is a VEVENT. Sequence must grow whenever the time or the status of the event changes,
+// clients ignore updates of an event with a sequence they have already seen.
+type Event struct {
+	UID          string
+	Sequence     int
+	Status       string
+	Summary      string
+	Description  string
+	Start        time.Time
+	End          time.Time
+	Created      time.Time
+	LastModified time.Time
+	// The fields below are only read by Decode. AllDay is set when the event starts on a date.
+	AllDay bool
+	// Rule is the RRULE of a recurring event, ExDates are its excluded occurrences.
+	Rule    string
+	ExDates []time.Time
+	// RecurrenceID is set when the event replaces the occurrence of a recurring event starting then.
+	RecurrenceID *time.Time
+	Attendees    []Attendee
+}
+
+// Encode writes the calendar in RFC 5545 format.
+func (c Calendar) Encode(w io.Writer) error {
+	e := encoder{w: bufio.NewWriter(w)}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", prodID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		e.line("X-WR-CALNAME", escape(c.Name))
+	}
+	for _, event := range c.Events {
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", event.UID)
+		e.line("DTSTAMP", formatTime(event.LastModified))
+		e.line("DTSTART", formatTime(event.Start))
+		e.line("DTEND", formatTime(event.End))
+		e.line("SEQUENCE", strconv.Itoa(event.Sequence))
+		if event.Status != "" {
+			e.line("STATUS", event.Status)
+		}
+		if event.Summary != "" {
+			e.line("SUMMARY", escape(event.Summary))
+		}
+		if event.Description != "" {
+			e.line("DESCRIPTION", escape(event.Description))
+		}
+		e.line("CREATED", formatTime(event.Created))
+		e.line("LAST-MODIFIED", formatTime(event.LastModified))
+		e.line("END", "VEVENT")
+	}
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a content line folded to maxLineLength octets without splitting characters.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	line := name + ":" + value
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > maxLineLength {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+	_, e.err = e.w.WriteString(b.String())
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes a TEXT value.
+func escape(text string) string {
+	return escaper.Replace(text)
+}
@@ -0,0 +1,65 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	start := time.Date(2023, 7, 3, 10, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	updated := time.Date(2023, 7, 1, 9, 30, 0, 0, time.UTC)
+	cal := Calendar{
+		Name: "Trainings",
+		Events: []Event{{
+			UID:          "meeting-1@timeslots",
+			Sequence:     2,
+			Status:       StatusCancelled,
+			Summary:      "Training with Ivanov, Ivan; room 2",
+			Description:  "line one\nline two",
+			Start:        start,
+			End:          start.Add(time.Hour),
+			Created:      updated.Add(-time.Hour),
+			LastModified: updated,
+		}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, cal.Encode(&buf))
+	require.Equal(t, strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//TimeSlots//TimeSlots//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Trainings",
+		"BEGIN:VEVENT",
+		"UID:meeting-1@timeslots",
+		"DTSTAMP:20230701T093000Z",
+		"DTSTART:20230703T070000Z",
+		"DTEND:20230703T080000Z",
+		"SEQUENCE:2",
+		"STATUS:CANCELLED",
+		`SUMMARY:Training with Ivanov\, Ivan\; room 2`,
+		`DESCRIPTION:line one\nline two`,
+		"CREATED:20230701T083000Z",
+		"LAST-MODIFIED:20230701T093000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n"), buf.String())
+}
+
+func TestFolding(t *testing.T) {
+	var buf bytes.Buffer
+	cal := Calendar{Name: strings.Repeat("Тренировка ", 10)}
+	require.NoError(t, cal.Encode(&buf))
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(line), maxLineLength)
+		require.True(t, utf8.ValidString(line), line)
+	}
+	require.Contains(t, strings.ReplaceAll(buf.String(), "\r\n ", ""), "X-WR-CALNAME:"+cal.Name)
+}
@@ -16,4 +16,10 @@ var (
 		Subsystem: "pg",
 		Name:      "pg_duration",
 	}, []string{"method"})
+	// LoginRefused counts logins refused by the rate limits ("rate_limited") and lockouts ("locked").
+	LoginRefused = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "timeslots",
+		Subsystem: "auth",
+		Name:      "login_refused_count",
+	}, []string{"reason"})
 )
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+var (
+	ErrInvalidRefreshToken = NewError(CodeInvalidRefreshToken, "invalid refresh token")
+	ErrSessionRevoked      = NewError(CodeSessionRevoked, "session revoked")
+	ErrTooManyAttempts     = NewError(CodeTooManyAttempts, "too many login attempts")
+	ErrLoginLocked         = NewError(CodeLoginLocked, "login locked after failed attempts")
+)
+
+// LoginFailure is the audit record of a failed login. UserID is set when the phone belongs to a user,
+// LockedUntil when the failure locked the phone out.
+type LoginFailure struct {
+	ID          int64      `db:"id"`
+	Phone       string     `db:"phone"`
+	UserID      *int       `db:"user_id"`
+	IP          string     `db:"ip"`
+	UserAgent   string     `db:"user_agent"`
+	LockedUntil *time.Time `db:"locked_until"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+// Session is a login of a user. Every access and refresh token belongs to a session and logging out revokes all of them.
+type Session struct {
+	ID        string     `json:"id" db:"id"`
+	UserID    int        `json:"userID" db:"user_id"`
+	RevokedAt *time.Time `json:"revokedAt" db:"revoked_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// RefreshToken is stored by the hash of the token only. A refresh token can be used once, refreshing rotates it.
+type RefreshToken struct {
+	Hash      string     `db:"token_hash"`
+	SessionID string     `db:"session_id"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RotatedAt *time.Time `db:"rotated_at"`
+}
+
+type RefreshRequest struct {
+	RefreshToken *string `json:"refreshToken"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+var (
+	ErrInvalidAvailability = NewError(CodeInvalidAvailability, "invalid availability")
+	ErrOutsideAvailability = NewError(CodeOutsideAvailability, "meeting is outside of coach availability")
+	ErrInvalidSlotRange    = NewError(CodeInvalidSlotRange, "invalid slot range")
+)
+
+// WeeklyPeriod is a recurring period of a day of the week. Weekday follows time.Weekday,
+// StartTime and EndTime are wall clock times formatted as 15:04 in the availability time zone.
+type WeeklyPeriod struct {
+	Weekday   int    `json:"weekday" db:"weekday"`
+	StartTime string `json:"startTime" db:"start_time"`
+	EndTime   string `json:"endTime" db:"end_time"`
+}
+
+// AvailabilityOverride replaces the weekly schedule for a single date. The coach is either
+// unavailable for the whole day or available between StartTime and EndTime only.
+type AvailabilityOverride struct {
+	ID        int     `json:"id" db:"id"`
+	Date      string  `json:"date" db:"date"`
+	Available bool    `json:"available" db:"available"`
+	StartTime *string `json:"startTime" db:"start_time"`
+	EndTime   *string `json:"endTime" db:"end_time"`
+}
+
+type AvailabilityRequest struct {
+	TimeZone *string `json:"timeZone"`
+	// CalendarID is the external calendar of the coach, its busy events are not offered as slots.
+	// An empty string removes it.
+	CalendarID   *string        `json:"calendarID"`
+	WorkingHours []WeeklyPeriod `json:"workingHours"`
+	Breaks       []WeeklyPeriod `json:"breaks"`
+}
+
+type Availability struct {
+	CoachID      int                    `json:"coachID" db:"coach"`
+	TimeZone     string                 `json:"timeZone" db:"time_zone"`
+	CalendarID   string                 `json:"calendarID,omitempty" db:"calendar_id"`
+	WorkingHours []WeeklyPeriod         `json:"workingHours"`
+	Breaks       []WeeklyPeriod         `json:"breaks"`
+	Overrides    []AvailabilityOverride `json:"overrides"`
+}
+
+type Slot struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
@@ -1,5 +1,9 @@
 package models
 
+import "time"
+
+var ErrInvalidCalendarToken = NewError(CodeInvalidCalendarToken, "invalid calendar token")
+
 type Event struct {
 	ID          string
 	Title       string
@@ -10,3 +14,16 @@ type Event struct {
 	Updated     string
 	Status      string
 }
+
+// CalendarFeed is the iCalendar subscription of a user. The token authenticates the feed URL, it is
+// only shown when it is issued and issuing a new one revokes the old one.
+type CalendarFeed struct {
+	URL       string    `json:"url"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
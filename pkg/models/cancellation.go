@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+var (
+	ErrInvalidCancellationPolicy = NewError(CodeInvalidCancellationPolicy, "invalid cancellation policy")
+	ErrLateCancellation          = NewError(CodeLateCancellation, "free cancellation period is over")
+)
+
+// CancellationPolicy of a coach. A client cancellation made less than FreeCancellationMinutes
+// before the start of the meeting is late and the meeting counts as attended.
+type CancellationPolicy struct {
+	CoachID                 int       `json:"coachID" db:"coach"`
+	FreeCancellationMinutes int       `json:"freeCancellationMinutes" db:"free_cancellation_minutes"`
+	AllowLateCancellation   bool      `json:"allowLateCancellation" db:"allow_late_cancellation"`
+	UpdatedAt               time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type CancellationPolicyRequest struct {
+	FreeCancellationMinutes *int  `json:"freeCancellationMinutes"`
+	AllowLateCancellation   *bool `json:"allowLateCancellation"`
+}
+
+// IsLate reports whether cancelling a meeting which starts at start is late at the moment now.
+func (p CancellationPolicy) IsLate(start, now time.Time) bool {
+	return now.After(start.Add(-time.Duration(p.FreeCancellationMinutes) * time.Minute))
+}
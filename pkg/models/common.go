@@ -1,13 +1,15 @@
 package models
 
 import (
-	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var ErrInvalidCredentials = errors.New("invalid credentials")
+var (
+	ErrInvalidCredentials = NewError(CodeInvalidCredentials, "invalid credentials")
+	ErrPreconditionFailed = NewError(CodePreconditionFailed, "resource was modified")
+)
 
 const (
 	RoleCoach  = `coach`
@@ -20,15 +22,20 @@ type UserNotify struct {
 	Notified  bool      `json:"notified" db:"notified"`
 	LastName  string    `json:"lastName" db:"last_name"`
 	FirstName string    `json:"firstName" db:"first_name"`
+	TimeZone  string    `json:"timeZone" db:"time_zone"`
 	StartAt   time.Time `json:"startAt" db:"start_at"`
 }
 
 type Claims struct {
 	jwt.RegisteredClaims
-	UserID int    `json:"userID"`
-	Role   string `json:"role"`
+	UserID    int    `json:"userID"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
 }
 
+// TokenResponse holds a short-lived access token and the refresh token to get the next one.
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
 }
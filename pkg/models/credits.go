@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	CreditGrant   = `grant`
+	CreditBooking = `booking`
+	CreditRefund  = `refund`
+)
+
+var (
+	ErrInvalidCreditPackage = NewError(CodeInvalidCreditPackage, "invalid credit package")
+	ErrInsufficientCredits  = NewError(CodeInsufficientCredits, "no credits left")
+)
+
+type CreditPackageRequest struct {
+	Sessions  *int       `json:"sessions"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type CreditPackage struct {
+	ID        int       `json:"id" db:"id"`
+	ClientID  int       `json:"clientID" db:"client"`
+	CoachID   int       `json:"coachID" db:"coach"`
+	Sessions  int       `json:"sessions" db:"sessions"`
+	Remaining int       `json:"remaining" db:"remaining"`
+	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// CreditBalance sums the remaining sessions of the active packages of the client.
+type CreditBalance struct {
+	ClientID int             `json:"clientID"`
+	Balance  int             `json:"balance"`
+	Packages []CreditPackage `json:"packages"`
+}
+
+// CreditEntry is a change of the credits of the client. Delta is positive for grants
+// and refunds and negative for bookings.
+type CreditEntry struct {
+	ID        int       `json:"id" db:"id"`
+	ClientID  int       `json:"clientID" db:"client"`
+	CoachID   int       `json:"coachID" db:"coach"`
+	PackageID int       `json:"packageID" db:"package_id"`
+	MeetingID *int      `json:"meetingID,omitempty" db:"meeting_id"`
+	Delta     int       `json:"delta" db:"delta"`
+	Reason    string    `json:"reason" db:"reason"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error codes are part of the API, clients switch on them. A released code is never renamed.
+const (
+	CodeValidationFailed           = "VALIDATION_FAILED"
+	CodeInvalidCredentials         = "INVALID_CREDENTIALS"
+	CodeTooManyAttempts            = "TOO_MANY_ATTEMPTS"
+	CodeLoginLocked                = "LOGIN_LOCKED"
+	CodeInvalidRefreshToken        = "INVALID_REFRESH_TOKEN"
+	CodeSessionRevoked             = "SESSION_REVOKED"
+	CodeForbidden                  = "FORBIDDEN"
+	CodePreconditionFailed         = "PRECONDITION_FAILED"
+	CodeIdempotencyKeyReused       = "IDEMPOTENCY_KEY_REUSED"
+	CodeRequestInProgress          = "REQUEST_IN_PROGRESS"
+	CodeInvalidCursor              = "INVALID_CURSOR"
+	CodeUserNotFound               = "USER_NOT_FOUND"
+	CodeUserExists                 = "USER_EXISTS"
+	CodeInvalidTimeZone            = "INVALID_TIME_ZONE"
+	CodeMeetingNotFound            = "MEETING_NOT_FOUND"
+	CodeMeetingConflict            = "MEETING_CONFLICT"
+	CodeInvalidStatus              = "INVALID_STATUS"
+	CodeInvalidTransition          = "INVALID_TRANSITION"
+	CodeInvalidCapacity            = "INVALID_CAPACITY"
+	CodeNotGroupSession            = "NOT_GROUP_SESSION"
+	CodeMeetingNotActive           = "MEETING_NOT_ACTIVE"
+	CodeMeetingFull                = "MEETING_FULL"
+	CodeAlreadyParticipant         = "ALREADY_PARTICIPANT"
+	CodeNotParticipant             = "NOT_PARTICIPANT"
+	CodeAvailabilityNotFound       = "AVAILABILITY_NOT_FOUND"
+	CodeOverrideNotFound           = "OVERRIDE_NOT_FOUND"
+	CodeInvalidAvailability        = "INVALID_AVAILABILITY"
+	CodeOutsideAvailability        = "OUTSIDE_AVAILABILITY"
+	CodeInvalidSlotRange           = "INVALID_SLOT_RANGE"
+	CodeSeriesNotFound             = "SERIES_NOT_FOUND"
+	CodeInvalidSeries              = "INVALID_SERIES"
+	CodeNotInSeries                = "NOT_IN_SERIES"
+	CodeCancellationPolicyNotFound = "CANCELLATION_POLICY_NOT_FOUND"
+	CodeInvalidCancellationPolicy  = "INVALID_CANCELLATION_POLICY"
+	CodeLateCancellation           = "LATE_CANCELLATION"
+	CodeWaitlistEntryNotFound      = "WAITLIST_ENTRY_NOT_FOUND"
+	CodeInvalidWaitlistSlot        = "INVALID_WAITLIST_SLOT"
+	CodeSlotAvailable              = "SLOT_AVAILABLE"
+	CodeAlreadyWaitlisted          = "ALREADY_WAITLISTED"
+	CodeWaitlistEntryClosed        = "WAITLIST_ENTRY_CLOSED"
+	CodeSlotHeld                   = "SLOT_HELD"
+	CodeInvalidCreditPackage       = "INVALID_CREDIT_PACKAGE"
+	CodeInsufficientCredits        = "INSUFFICIENT_CREDITS"
+	CodeSessionNotFound            = "SESSION_NOT_FOUND"
+	CodeWebhookNotFound            = "WEBHOOK_NOT_FOUND"
+	CodeWebhookDeliveryNotFound    = "WEBHOOK_DELIVERY_NOT_FOUND"
+	CodeInvalidCalendarToken       = "INVALID_CALENDAR_TOKEN"
+	CodeInvalidCalendar            = "INVALID_CALENDAR"
+	CodeAlreadyImported            = "ALREADY_IMPORTED"
+)
+
+var (
+	ErrValidationFailed = NewError(CodeValidationFailed, "validation failed")
+	ErrForbidden        = NewError(CodeForbidden, "access denied")
+	ErrUserNotFound     = NewError(CodeUserNotFound, "user not found")
+	ErrUserExists       = NewError(CodeUserExists, "user already exists")
+	ErrMeetingNotFound  = NewError(CodeMeetingNotFound, "meeting not found")
+	ErrMeetingConflict  = NewError(CodeMeetingConflict, "meeting conflicts with existing meetings")
+	ErrSessionNotFound  = NewError(CodeSessionNotFound, "session not found")
+)
+
+// Error is a domain error with a stable code. Its message is safe to show to clients, unlike the context
+// it gets wrapped in on the way up.
+type Error struct {
+	Code    string
+	Message string
+	Detail  string
+}
+
+func NewError(code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return e.Message
+	}
+	return e.Message + ": " + e.Detail
+}
+
+// Is matches errors with the same code, so errors with details still match their sentinel.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Errorf returns a copy of the error with details.
+func (e *Error) Errorf(format string, args ...interface{}) error {
+	return &Error{Code: e.Code, Message: e.Message, Detail: fmt.Sprintf(format, args...)}
+}
+
+// FieldError describes what is wrong with a field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports all invalid fields of a request at once. It matches ErrValidationFailed.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return ErrValidationFailed.Message + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidationFailed
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// MeetingExport is a meeting with the names of its coach and client, as exported for accounting.
+// Client is zero for group sessions, Participants counts their joined clients.
+type MeetingExport struct {
+	ID           int       `db:"id"`
+	StartTime    time.Time `db:"start_at"`
+	EndTime      time.Time `db:"end_at"`
+	Status       string    `db:"status"`
+	Coach        int       `db:"manager"`
+	CoachName    string    `db:"coach_name"`
+	Client       int       `db:"client"`
+	ClientName   string    `db:"client_name"`
+	Capacity     int       `db:"capacity"`
+	Participants int       `db:"participants"`
+}
+
+func (m MeetingExport) Duration() time.Duration {
+	return m.EndTime.Sub(m.StartTime)
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKeyTTL is how long a response is kept for replaying.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+var (
+	ErrIdempotencyKeyReused = NewError(CodeIdempotencyKeyReused, "idempotency key was used with a different request")
+	ErrRequestInProgress    = NewError(CodeRequestInProgress, "request with the same idempotency key is in progress")
+)
+
+// IdempotencyKey is a request made with an Idempotency-Key header. Keys are scoped by the caller and the endpoint.
+// Unauthenticated requests have UserID 0 and are told apart by the address in Client. Status and Response are empty
+// while the request is in progress.
+type IdempotencyKey struct {
+	Key         string    `db:"key"`
+	UserID      int       `db:"user_id"`
+	Client      string    `db:"client"`
+	Method      string    `db:"method"`
+	Path        string    `db:"path"`
+	RequestHash string    `db:"request_hash"`
+	Status      *int      `db:"status"`
+	Response    []byte    `db:"response"`
+	CreatedAt   time.Time `db:"created_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Results of importing a calendar event occurrence.
+const (
+	ImportCreated  = `created`
+	ImportSkipped  = `skipped`
+	ImportConflict = `conflict`
+)
+
+var (
+	ErrInvalidCalendar = NewError(CodeInvalidCalendar, "invalid calendar")
+	ErrAlreadyImported = NewError(CodeAlreadyImported, "meeting already imported")
+)
+
+// ImportItem is the outcome of importing an occurrence of a calendar event. Client is the user matched
+// by the attendees of the event. MeetingID is the created meeting or the one imported before.
+type ImportItem struct {
+	UID       string    `json:"uid"`
+	Summary   string    `json:"summary,omitempty"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Client    *int      `json:"client,omitempty"`
+	Result    string    `json:"result"`
+	Reason    string    `json:"reason,omitempty"`
+	MeetingID *int      `json:"meetingID,omitempty"`
+}
+
+// ImportReport lists what importing a calendar created or would create with DryRun.
+type ImportReport struct {
+	DryRun    bool         `json:"dryRun"`
+	Created   int          `json:"created"`
+	Skipped   int          `json:"skipped"`
+	Conflicts int          `json:"conflicts"`
+	Items     []ImportItem `json:"items"`
+}
+
+func (r *ImportReport) Add(item ImportItem) {
+	switch item.Result {
+	case ImportCreated:
+		r.Created++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportConflict:
+		r.Conflicts++
+	}
+	r.Items = append(r.Items, item)
+}
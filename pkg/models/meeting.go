@@ -1,6 +1,58 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	StatusRequested = `requested`
+	StatusConfirmed = `confirmed`
+	StatusCancelled = `cancelled`
+	StatusCompleted = `completed`
+	StatusNoShow    = `no_show`
+)
+
+// Meetings shorter or longer than this are rejected.
+const (
+	MinMeetingDuration = 15 * time.Minute
+	MaxMeetingDuration = 12 * time.Hour
+)
+
+var (
+	ErrInvalidStatus      = NewError(CodeInvalidStatus, "invalid meeting status")
+	ErrInvalidTransition  = NewError(CodeInvalidTransition, "invalid meeting status transition")
+	ErrInvalidCapacity    = NewError(CodeInvalidCapacity, "invalid meeting capacity")
+	ErrNotGroupSession    = NewError(CodeNotGroupSession, "meeting is not a group session")
+	ErrMeetingNotActive   = NewError(CodeMeetingNotActive, "meeting is not open for booking")
+	ErrMeetingFull        = NewError(CodeMeetingFull, "meeting is full")
+	ErrAlreadyParticipant = NewError(CodeAlreadyParticipant, "already a participant of the meeting")
+	ErrNotParticipant     = NewError(CodeNotParticipant, "not a participant of the meeting")
+)
+
+var meetingTransitions = map[string][]string{
+	StatusRequested: {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusCancelled, StatusCompleted, StatusNoShow},
+}
+
+// IsMeetingStatus reports whether status is a known meeting status.
+func IsMeetingStatus(status string) bool {
+	switch status {
+	case StatusRequested, StatusConfirmed, StatusCancelled, StatusCompleted, StatusNoShow:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether a meeting in status from may be moved to status to.
+func CanTransition(from, to string) bool {
+	for _, status := range meetingTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
 
 type MeetingRequest struct {
 	ID        *int       `json:"id" db:"id"`
@@ -9,15 +61,82 @@ type MeetingRequest struct {
 	EndTime   *time.Time `json:"endTime" db:"end_at"`
 	Client    *int       `json:"client" db:"client"`
 	Notified  *bool      `json:"notified" db:"notified"`
+	Status    *string    `json:"status" db:"status"`
+	// Capacity above one makes a group session. Its clients join it one by one instead of Client.
+	Capacity *int `json:"capacity" db:"capacity"`
+	// TimeZone allows StartTime and EndTime to be sent as local times without an offset.
+	TimeZone *string `json:"timeZone" db:"-"`
+	// IfMatch makes the update fail with ErrPreconditionFailed unless the meeting was last updated at this moment.
+	IfMatch *time.Time `json:"-" db:"-"`
+	// ExternalID is the calendar event occurrence an imported meeting comes from.
+	ExternalID *string `json:"-" db:"external_id"`
+}
+
+func (m *MeetingRequest) UnmarshalJSON(data []byte) error {
+	type meetingRequest MeetingRequest
+	var request struct {
+		meetingRequest
+		StartTime *string `json:"startTime"`
+		EndTime   *string `json:"endTime"`
+	}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+	*m = MeetingRequest(request.meetingRequest)
+	var err error
+	if m.StartTime, err = parseTime(request.StartTime, m.TimeZone); err != nil {
+		return err
+	}
+	m.EndTime, err = parseTime(request.EndTime, m.TimeZone)
+	return err
 }
 
 type Meeting struct {
-	ID        int       `json:"id" db:"id"`
-	Manager   int       `json:"manager" db:"manager"`
-	StartTime time.Time `json:"startTime" db:"start_at"`
-	EndTime   time.Time `json:"endTime" db:"end_at"`
-	Client    int       `json:"client" db:"client"`
-	Notified  bool      `json:"notified" db:"notified"`
+	ID           int        `json:"id" db:"id"`
+	Manager      int        `json:"manager" db:"manager"`
+	StartTime    time.Time  `json:"startTime" db:"start_at"`
+	EndTime      time.Time  `json:"endTime" db:"end_at"`
+	Client       int        `json:"client,omitempty" db:"client"`
+	Notified     bool       `json:"notified" db:"notified"`
+	Status       string     `json:"status" db:"status"`
+	CancelReason *string    `json:"cancelReason,omitempty" db:"cancel_reason"`
+	CancelledAt  *time.Time `json:"cancelledAt,omitempty" db:"cancelled_at"`
+	CancelledBy  *int       `json:"cancelledBy,omitempty" db:"cancelled_by"`
+	// LateCancellation is set for cancelled meetings. Late cancelled meetings count as attended.
+	LateCancellation *bool `json:"lateCancellation,omitempty" db:"late_cancellation"`
+	SeriesID         *int  `json:"seriesID,omitempty" db:"series_id"`
+	Capacity         int   `json:"capacity" db:"capacity"`
+	// Participants are clients of a group session.
+	Participants []int `json:"participants,omitempty" db:"-"`
+	// OriginalStartTime is the start of the occurrence according to the series rule.
+	OriginalStartTime *time.Time `json:"-" db:"original_start_at"`
+	// Sequence counts changes of the time and the status of the meeting, calendar feeds publish it.
+	Sequence  int       `json:"-" db:"sequence"`
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
 }
+
+// MeetingFilter selects meetings by status, participants and start_at in [From, To).
+type MeetingFilter struct {
+	Page
+	Statuses []string
+	Manager  *int
+	Client   *int
+	From     *time.Time
+	To       *time.Time
+}
+
+// StatusChange moves a meeting from status From to status To. The cancellation
+// fields are stored only when the meeting is cancelled.
+type StatusChange struct {
+	From             string
+	To               string
+	Reason           *string
+	CancelledBy      *int
+	LateCancellation bool
+	IfMatch          *time.Time
+}
+
+type CancelRequest struct {
+	Reason *string `json:"reason"`
+}
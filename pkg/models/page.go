@@ -0,0 +1,40 @@
+package models
+
+// Sort orders of list endpoints.
+const (
+	SortAsc  = `asc`
+	SortDesc = `desc`
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+var ErrInvalidCursor = NewError(CodeInvalidCursor, "invalid cursor")
+
+// Page limits a list to Limit items following Cursor, the Next token of the previous page.
+// A zero Limit returns all the items.
+type Page struct {
+	Sort   string
+	Limit  int
+	Cursor string
+}
+
+type UserFilter struct {
+	Page
+	ID   *int
+	Role *string
+	// Coach limits the users to the roster of the coach.
+	Coach *int
+}
+
+type UserPage struct {
+	Users []User `json:"users"`
+	Next  string `json:"next,omitempty"`
+}
+
+type MeetingPage struct {
+	Meetings []Meeting `json:"meetings"`
+	Next     string    `json:"next,omitempty"`
+}
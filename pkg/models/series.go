@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Scopes of a change applied to a meeting which belongs to a series.
+const (
+	ScopeThis      = "this"
+	ScopeFollowing = "following"
+	ScopeAll       = "all"
+)
+
+var (
+	ErrInvalidSeries = NewError(CodeInvalidSeries, "invalid series")
+	ErrNotInSeries   = NewError(CodeNotInSeries, "meeting does not belong to a series")
+)
+
+// SeriesRequest describes a recurring meeting. StartTime and EndTime are the first occurrence,
+// Rule is an RRULE such as FREQ=WEEKLY;BYDAY=MO,TH;COUNT=10 expanded in TimeZone.
+type SeriesRequest struct {
+	Manager   *int       `json:"manager"`
+	Client    *int       `json:"client"`
+	StartTime *time.Time `json:"startTime"`
+	EndTime   *time.Time `json:"endTime"`
+	TimeZone  *string    `json:"timeZone"`
+	Rule      *string    `json:"rule"`
+}
+
+func (r *SeriesRequest) UnmarshalJSON(data []byte) error {
+	type seriesRequest SeriesRequest
+	var request struct {
+		seriesRequest
+		StartTime *string `json:"startTime"`
+		EndTime   *string `json:"endTime"`
+	}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+	*r = SeriesRequest(request.seriesRequest)
+	var err error
+	if r.StartTime, err = parseTime(request.StartTime, r.TimeZone); err != nil {
+		return err
+	}
+	r.EndTime, err = parseTime(request.EndTime, r.TimeZone)
+	return err
+}
+
+type Series struct {
+	ID        int       `json:"id" db:"id"`
+	Manager   int       `json:"manager" db:"manager"`
+	Client    int       `json:"client" db:"client"`
+	StartTime time.Time `json:"startTime" db:"start_at"`
+	EndTime   time.Time `json:"endTime" db:"end_at"`
+	TimeZone  string    `json:"timeZone" db:"time_zone"`
+	Rule      string    `json:"rule" db:"rule"`
+	Meetings  []Meeting `json:"meetings" db:"-"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// localTimeLayout is a wall clock time without an offset. It is read in the time zone sent along with it.
+const localTimeLayout = "2006-01-02T15:04:05"
+
+// parseTime parses value in RFC 3339 or, when zone is set, as a local time in zone.
+func parseTime(value, zone *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, *value); err == nil {
+		return &t, nil
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("parsing time %q: a local time requires timeZone", *value)
+	}
+	loc, err := time.LoadLocation(*zone)
+	if err != nil {
+		return nil, ErrInvalidTimeZone.Errorf("unknown time zone %q", *zone)
+	}
+	t, err := time.ParseInLocation(localTimeLayout, *value, loc)
+	if err != nil {
+		return nil, fmt.Errorf("parsing time %q: %w", *value, err)
+	}
+	return &t, nil
+}
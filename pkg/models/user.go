@@ -4,6 +4,8 @@ import (
 	"time"
 )
 
+var ErrInvalidTimeZone = NewError(CodeInvalidTimeZone, "invalid time zone")
+
 type UserRequest struct {
 	ID           *int    `json:"id" db:"id"`
 	LastName     *string `json:"lastName" db:"last_name"`
@@ -13,6 +15,10 @@ type UserRequest struct {
 	PasswordHash *string `json:"-" db:"password_hash"`
 	Role         *string `json:"-" db:"role"`
 	Password     *string `json:"password" db:"-"`
+	// TimeZone is an IANA time zone such as Europe/Moscow. User-facing times are rendered in it.
+	TimeZone *string `json:"timeZone" db:"time_zone"`
+	// IfMatch makes the update fail with ErrPreconditionFailed unless the user was last updated at this moment.
+	IfMatch *time.Time `json:"-" db:"-"`
 }
 
 type User struct {
@@ -23,7 +29,22 @@ type User struct {
 	Email        string    `json:"email" db:"email"`
 	PasswordHash string    `json:"-" db:"password_hash"`
 	Role         string    `json:"role" db:"role"`
+	TimeZone     string    `json:"timeZone" db:"time_zone"`
 	Deleted      bool      `json:"-" db:"deleted"`
 	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
 }
+
+// Location returns the time zone of the user falling back to UTC when it is unknown.
+func (u User) Location() *time.Location {
+	return Location(u.TimeZone)
+}
+
+// Location loads the IANA time zone falling back to UTC when it is unknown.
+func Location(zone string) *time.Location {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	WaitlistWaiting  = `waiting`
+	WaitlistOffered  = `offered`
+	WaitlistAccepted = `accepted`
+	WaitlistDeclined = `declined`
+	WaitlistExpired  = `expired`
+	WaitlistLeft     = `left`
+)
+
+var (
+	ErrInvalidWaitlistSlot = NewError(CodeInvalidWaitlistSlot, "invalid waitlist slot")
+	ErrSlotAvailable       = NewError(CodeSlotAvailable, "slot is available, book it instead")
+	ErrAlreadyWaitlisted   = NewError(CodeAlreadyWaitlisted, "already on the waitlist for this slot")
+	ErrWaitlistEntryClosed = NewError(CodeWaitlistEntryClosed, "waitlist entry is no longer active")
+	ErrSlotHeld            = NewError(CodeSlotHeld, "slot is held for a waitlisted client")
+)
+
+type WaitlistRequest struct {
+	StartTime *time.Time `json:"startTime"`
+	EndTime   *time.Time `json:"endTime"`
+}
+
+// WaitlistEntry is a place of the client in the queue for a taken slot of the coach. When the slot
+// becomes free, the first waiting client gets an offer which is held for them until HoldUntil.
+type WaitlistEntry struct {
+	ID        int        `json:"id" db:"id"`
+	CoachID   int        `json:"coachID" db:"coach"`
+	ClientID  int        `json:"clientID" db:"client"`
+	StartTime time.Time  `json:"startTime" db:"start_at"`
+	EndTime   time.Time  `json:"endTime" db:"end_at"`
+	Status    string     `json:"status" db:"status"`
+	OfferedAt *time.Time `json:"offeredAt,omitempty" db:"offered_at"`
+	HoldUntil *time.Time `json:"holdUntil,omitempty" db:"hold_until"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+}
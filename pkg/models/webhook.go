@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventMeetingCreated   = `meeting.created`
+	EventMeetingUpdated   = `meeting.updated`
+	EventMeetingCancelled = `meeting.cancelled`
+	EventUserCreated      = `user.created`
+)
+
+const (
+	DeliveryPending   = `pending`
+	DeliveryDelivered = `delivered`
+	DeliveryFailed    = `failed`
+)
+
+var (
+	ErrWebhookNotFound         = NewError(CodeWebhookNotFound, "webhook not found")
+	ErrWebhookDeliveryNotFound = NewError(CodeWebhookDeliveryNotFound, "webhook delivery not found")
+)
+
+// WebhookEventType is an entry of the event catalogue.
+type WebhookEventType struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// WebhookEvents is the catalogue of events webhooks subscribe to.
+var WebhookEvents = []WebhookEventType{
+	{EventMeetingCreated, "A meeting was booked. Series report one event per meeting."},
+	{EventMeetingUpdated, "A meeting was moved, confirmed, completed, missed or its participants changed."},
+	{EventMeetingCancelled, "A meeting was cancelled."},
+	{EventUserCreated, "A user signed up."},
+}
+
+func IsWebhookEvent(event string) bool {
+	for _, known := range WebhookEvents {
+		if known.Name == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookRequest struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// Webhook is a subscription of the coach to events. Meeting events are sent to the webhooks of the coach
+// managing the meeting, user events to every webhook. Secret is only shown when the webhook is created.
+type Webhook struct {
+	ID        int       `json:"id" db:"id"`
+	CoachID   int       `json:"coachID" db:"coach"`
+	URL       string    `json:"url" db:"url"`
+	Events    []string  `json:"events" db:"-"`
+	Active    bool      `json:"active" db:"active"`
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// WebhookEvent is the payload of a delivery. Data is the meeting or the user the event is about.
+type WebhookEvent struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDelivery is an event queued for a webhook and the outcome of its last attempt.
+// Failed attempts are retried with backoff until the delivery succeeds or is given up.
+type WebhookDelivery struct {
+	ID             int             `json:"id" db:"id"`
+	WebhookID      int             `json:"webhookID" db:"webhook_id"`
+	Event          string          `json:"event" db:"event"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty" db:"next_attempt_at"`
+	ResponseStatus *int            `json:"responseStatus,omitempty" db:"response_status"`
+	LastError      *string         `json:"lastError,omitempty" db:"last_error"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty" db:"delivered_at"`
+	RedeliveryOf   *int            `json:"redeliveryOf,omitempty" db:"redelivery_of"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updatedAt" db:"updated_at"`
+}
+
+// PendingDelivery is a delivery claimed for sending together with where to send it.
+type PendingDelivery struct {
+	WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// DeliveryAttempt is the outcome of sending a delivery. NextAttemptAt is set when it is retried.
+type DeliveryAttempt struct {
+	Status         string
+	ResponseStatus *int
+	Error          *string
+	NextAttemptAt  *time.Time
+}
@@ -27,7 +27,19 @@ func (n *Notifier) NotifyTelegram(_ context.Context, msg string, data models.Use
 	if err != nil {
 		return fmt.Errorf("notify telegram faild: %w", err)
 	}
-	if _, err = n.bot.Send(chat, fmt.Sprintf("%v %v", msg, data)); err != nil {
+	if _, err = n.bot.Send(chat, msg); err != nil {
+		return fmt.Errorf("notify telegram faild: %w", err)
+	}
+	return nil
+}
+
+// SendMessage sends msg to the Telegram chat of the user.
+func (n *Notifier) SendMessage(_ context.Context, userID int, msg string) error {
+	chat, err := n.bot.ChatByID(int64(userID))
+	if err != nil {
+		return fmt.Errorf("notify telegram faild: %w", err)
+	}
+	if _, err = n.bot.Send(chat, msg); err != nil {
 		return fmt.Errorf("notify telegram faild: %w", err)
 	}
 	return nil
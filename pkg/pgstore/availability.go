@@ -0,0 +1,195 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *Store) GetAvailability(ctx context.Context, coach int) (models.Availability, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetAvailability").Observe(time.Since(started).Seconds())
+	}()
+
+	var availability models.Availability
+	var err error
+	for i := 0; i < retries; i++ {
+		availability, err = s.getAvailability(ctx, s.db, coach)
+		switch {
+		case errors.Is(err, ErrAvailabilityNotFound):
+			return models.Availability{}, err
+		case err != nil:
+			continue
+		}
+		return availability, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetAvailability").Inc()
+
+	return models.Availability{}, fmt.Errorf("get availability of coach %d faild: %w", coach, err)
+}
+
+func (s *Store) getAvailability(ctx context.Context, q sqlx.QueryerContext, coach int) (models.Availability, error) {
+	availability := models.Availability{
+		WorkingHours: []models.WeeklyPeriod{},
+		Breaks:       []models.WeeklyPeriod{},
+		Overrides:    []models.AvailabilityOverride{},
+	}
+	err := sqlx.GetContext(ctx, q, &availability, `
+SELECT coach, time_zone, calendar_id FROM availability
+WHERE coach = $1;`, coach)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return models.Availability{}, ErrAvailabilityNotFound
+	case err != nil:
+		return models.Availability{}, err
+	}
+	if err = sqlx.SelectContext(ctx, q, &availability.WorkingHours, `
+SELECT weekday, to_char(start_time, 'HH24:MI') AS start_time, to_char(end_time, 'HH24:MI') AS end_time FROM working_hours
+WHERE coach = $1
+ORDER BY weekday, start_time;`, coach); err != nil {
+		return models.Availability{}, err
+	}
+	if err = sqlx.SelectContext(ctx, q, &availability.Breaks, `
+SELECT weekday, to_char(start_time, 'HH24:MI') AS start_time, to_char(end_time, 'HH24:MI') AS end_time FROM availability_breaks
+WHERE coach = $1
+ORDER BY weekday, start_time;`, coach); err != nil {
+		return models.Availability{}, err
+	}
+	if err = sqlx.SelectContext(ctx, q, &availability.Overrides, `
+SELECT id, to_char(date, 'YYYY-MM-DD') AS date, available,
+       to_char(start_time, 'HH24:MI') AS start_time, to_char(end_time, 'HH24:MI') AS end_time
+FROM availability_overrides
+WHERE coach = $1
+ORDER BY date;`, coach); err != nil {
+		return models.Availability{}, err
+	}
+	return availability, nil
+}
+
+// SetAvailability replaces the weekly working hours and breaks of the coach.
+func (s *Store) SetAvailability(ctx context.Context, coach int, data models.AvailabilityRequest) (models.Availability, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("SetAvailability").Observe(time.Since(started).Seconds())
+	}()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Availability{}, fmt.Errorf("open transaction faild: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.log.Warnf("rollback transaction faild: %v", err)
+		}
+	}()
+
+	availability, err := s.setAvailability(ctx, tx, coach, data)
+	if err != nil {
+		metrics.PgErrCount.WithLabelValues("SetAvailability").Inc()
+		return models.Availability{}, fmt.Errorf("set availability of coach %d faild: %w", coach, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return models.Availability{}, fmt.Errorf("commit transaction faild: %w", err)
+	}
+	return availability, nil
+}
+
+func (s *Store) setAvailability(ctx context.Context, tx *sqlx.Tx, coach int, data models.AvailabilityRequest) (models.Availability, error) {
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO availability (coach, time_zone, calendar_id)
+VALUES ($1, COALESCE($2, 'UTC'), COALESCE($3, ''))
+ON CONFLICT (coach) DO UPDATE SET time_zone = COALESCE($2, availability.time_zone),
+                                  calendar_id = COALESCE($3, availability.calendar_id), updated_at = NOW();`,
+		coach, data.TimeZone, data.CalendarID); err != nil {
+		return models.Availability{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM working_hours WHERE coach = $1;`, coach); err != nil {
+		return models.Availability{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM availability_breaks WHERE coach = $1;`, coach); err != nil {
+		return models.Availability{}, err
+	}
+	for _, period := range data.WorkingHours {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO working_hours (coach, weekday, start_time, end_time)
+VALUES ($1, $2, $3, $4);`, coach, period.Weekday, period.StartTime, period.EndTime); err != nil {
+			return models.Availability{}, err
+		}
+	}
+	for _, period := range data.Breaks {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO availability_breaks (coach, weekday, start_time, end_time)
+VALUES ($1, $2, $3, $4);`, coach, period.Weekday, period.StartTime, period.EndTime); err != nil {
+			return models.Availability{}, err
+		}
+	}
+	return s.getAvailability(ctx, tx, coach)
+}
+
+// SaveAvailabilityOverride creates the override for its date or replaces the existing one.
+func (s *Store) SaveAvailabilityOverride(ctx context.Context, coach int, override models.AvailabilityOverride) (models.AvailabilityOverride, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("SaveAvailabilityOverride").Observe(time.Since(started).Seconds())
+	}()
+
+	var savedOverride models.AvailabilityOverride
+	query := `
+INSERT INTO availability_overrides (coach, date, available, start_time, end_time)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (coach, date) DO UPDATE
+SET available = excluded.available, start_time = excluded.start_time, end_time = excluded.end_time
+RETURNING id, to_char(date, 'YYYY-MM-DD') AS date, available,
+          to_char(start_time, 'HH24:MI') AS start_time, to_char(end_time, 'HH24:MI') AS end_time;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if _, err = s.db.ExecContext(ctx, `
+INSERT INTO availability (coach) VALUES ($1)
+ON CONFLICT (coach) DO NOTHING;`, coach); err != nil {
+			continue
+		}
+		if err = s.db.GetContext(ctx, &savedOverride, query,
+			coach, override.Date, override.Available, override.StartTime, override.EndTime); err != nil {
+			continue
+		}
+		return savedOverride, nil
+	}
+	metrics.PgErrCount.WithLabelValues("SaveAvailabilityOverride").Inc()
+
+	return models.AvailabilityOverride{}, fmt.Errorf("save availability override of coach %d faild: %w", coach, err)
+}
+
+func (s *Store) DeleteAvailabilityOverride(ctx context.Context, coach, id int) (models.AvailabilityOverride, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("DeleteAvailabilityOverride").Observe(time.Since(started).Seconds())
+	}()
+
+	var deletedOverride models.AvailabilityOverride
+	query := `
+DELETE FROM availability_overrides
+WHERE id = $1 AND coach = $2
+RETURNING id, to_char(date, 'YYYY-MM-DD') AS date, available,
+          to_char(start_time, 'HH24:MI') AS start_time, to_char(end_time, 'HH24:MI') AS end_time;`
+	var err error
+	for i := 0; i < retries; i++ {
+		err = s.db.GetContext(ctx, &deletedOverride, query, id, coach)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return models.AvailabilityOverride{}, ErrOverrideNotFound
+		case err != nil:
+			continue
+		}
+		return deletedOverride, nil
+	}
+	metrics.PgErrCount.WithLabelValues("DeleteAvailabilityOverride").Inc()
+
+	return models.AvailabilityOverride{}, fmt.Errorf("delete availability override %d faild: %w", id, err)
+}
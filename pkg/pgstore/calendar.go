@@ -0,0 +1,139 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// SetCalendarToken stores the hash of the calendar token of the user, replacing the previous token.
+func (s *Store) SetCalendarToken(ctx context.Context, userID int, hash string) (time.Time, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("SetCalendarToken").Observe(time.Since(started).Seconds())
+	}()
+
+	var createdAt time.Time
+	query := `
+INSERT INTO calendar_tokens (user_id, token_hash)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = NOW()
+RETURNING created_at;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.GetContext(ctx, &createdAt, query, userID, hash); err != nil {
+			continue
+		}
+		return createdAt, nil
+	}
+	metrics.PgErrCount.WithLabelValues("SetCalendarToken").Inc()
+
+	return time.Time{}, fmt.Errorf("set calendar token of user %d faild: %w", userID, err)
+}
+
+func (s *Store) GetCalendarToken(ctx context.Context, userID int) (string, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetCalendarToken").Observe(time.Since(started).Seconds())
+	}()
+
+	var hash string
+	query := `
+SELECT token_hash FROM calendar_tokens
+WHERE user_id = $1;`
+	var err error
+	for i := 0; i < retries; i++ {
+		err = s.db.GetContext(ctx, &hash, query, userID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return "", ErrCalendarTokenNotFound
+		case err != nil:
+			continue
+		}
+		return hash, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetCalendarToken").Inc()
+
+	return "", fmt.Errorf("get calendar token of user %d faild: %w", userID, err)
+}
+
+func (s *Store) DeleteCalendarToken(ctx context.Context, userID int) error {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("DeleteCalendarToken").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+DELETE FROM calendar_tokens
+WHERE user_id = $1;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if _, err = s.db.ExecContext(ctx, query, userID); err != nil {
+			continue
+		}
+		return nil
+	}
+	metrics.PgErrCount.WithLabelValues("DeleteCalendarToken").Inc()
+
+	return fmt.Errorf("delete calendar token of user %d faild: %w", userID, err)
+}
+
+// GetUserMeetings returns meetings starting from from which the user manages or takes part in, ordered by start_at.
+func (s *Store) GetUserMeetings(ctx context.Context, userID int, from time.Time) ([]models.Meeting, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetUserMeetings").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+SELECT ` + meetingColumns + ` FROM meetings
+WHERE start_at >= $2
+AND (manager = $1 OR id IN (SELECT meeting_id FROM meeting_participants WHERE status = 'joined' AND client = $1))
+ORDER BY start_at, id;`
+	var err error
+	for i := 0; i < retries; i++ {
+		var meetings []models.Meeting
+		if err = s.db.SelectContext(ctx, &meetings, query, userID, from); err != nil {
+			continue
+		}
+		return meetings, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetUserMeetings").Inc()
+
+	return nil, fmt.Errorf("get meetings of user %d faild: %w", userID, err)
+}
+
+// GetImportedMeetings returns the ids of the meetings of the manager imported from the external ids.
+func (s *Store) GetImportedMeetings(ctx context.Context, manager int, externalIDs []string) (map[string]int, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetImportedMeetings").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+SELECT external_id, id FROM meetings
+WHERE manager = $1 AND external_id = ANY($2);`
+	var err error
+	for i := 0; i < retries; i++ {
+		var rows []struct {
+			ExternalID string `db:"external_id"`
+			ID         int    `db:"id"`
+		}
+		if err = s.db.SelectContext(ctx, &rows, query, manager, externalIDs); err != nil {
+			continue
+		}
+		imported := make(map[string]int, len(rows))
+		for _, row := range rows {
+			imported[row.ExternalID] = row.ID
+		}
+		return imported, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetImportedMeetings").Inc()
+
+	return nil, fmt.Errorf("get imported meetings of manager %d faild: %w", manager, err)
+}
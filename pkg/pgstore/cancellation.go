@@ -0,0 +1,66 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *Store) GetCancellationPolicy(ctx context.Context, coach int) (models.CancellationPolicy, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetCancellationPolicy").Observe(time.Since(started).Seconds())
+	}()
+
+	var policy models.CancellationPolicy
+	query := `
+SELECT coach, free_cancellation_minutes, allow_late_cancellation, updated_at FROM cancellation_policies
+WHERE coach = $1;`
+	var err error
+	for i := 0; i < retries; i++ {
+		err = s.db.GetContext(ctx, &policy, query, coach)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return models.CancellationPolicy{}, ErrCancellationPolicyNotFound
+		case err != nil:
+			continue
+		}
+		return policy, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetCancellationPolicy").Inc()
+
+	return models.CancellationPolicy{}, fmt.Errorf("get cancellation policy of coach %d faild: %w", coach, err)
+}
+
+func (s *Store) SetCancellationPolicy(ctx context.Context, policy models.CancellationPolicy) (models.CancellationPolicy, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("SetCancellationPolicy").Observe(time.Since(started).Seconds())
+	}()
+
+	var savedPolicy models.CancellationPolicy
+	query := `
+INSERT INTO cancellation_policies (coach, free_cancellation_minutes, allow_late_cancellation)
+VALUES ($1, $2, $3)
+ON CONFLICT (coach) DO UPDATE
+SET free_cancellation_minutes = excluded.free_cancellation_minutes,
+    allow_late_cancellation = excluded.allow_late_cancellation,
+    updated_at = NOW()
+RETURNING coach, free_cancellation_minutes, allow_late_cancellation, updated_at;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.GetContext(ctx, &savedPolicy, query,
+			policy.CoachID, policy.FreeCancellationMinutes, policy.AllowLateCancellation); err != nil {
+			continue
+		}
+		return savedPolicy, nil
+	}
+	metrics.PgErrCount.WithLabelValues("SetCancellationPolicy").Inc()
+
+	return models.CancellationPolicy{}, fmt.Errorf("set cancellation policy of coach %d faild: %w", policy.CoachID, err)
+}
@@ -0,0 +1,146 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+const creditPackageColumns = `id, client, coach, sessions, remaining, expires_at, created_at`
+
+// GrantCredits adds the package to the credits of the client.
+func (s *Store) GrantCredits(ctx context.Context, pkg models.CreditPackage) (models.CreditPackage, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GrantCredits").Observe(time.Since(started).Seconds())
+	}()
+
+	var newPackage models.CreditPackage
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &newPackage, `
+INSERT INTO credit_packages (client, coach, sessions, remaining, expires_at)
+VALUES ($1, $2, $3, $3, $4)
+RETURNING `+creditPackageColumns+`;`, pkg.ClientID, pkg.CoachID, pkg.Sessions, pkg.ExpiresAt); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+INSERT INTO credit_ledger (client, coach, package_id, delta, reason)
+VALUES ($1, $2, $3, $4, 'grant');`, newPackage.ClientID, newPackage.CoachID, newPackage.ID, newPackage.Sessions)
+		return err
+	})
+	if err != nil {
+		metrics.PgErrCount.WithLabelValues("GrantCredits").Inc()
+		return models.CreditPackage{}, fmt.Errorf("grant credits to client %d faild: %w", pkg.ClientID, err)
+	}
+	return newPackage, nil
+}
+
+// GetCreditPackages returns the packages of the client which are not expired.
+// Packages of all coaches are returned when coach is nil.
+func (s *Store) GetCreditPackages(ctx context.Context, client int, coach *int) ([]models.CreditPackage, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetCreditPackages").Observe(time.Since(started).Seconds())
+	}()
+
+	packages := []models.CreditPackage{}
+	query := `
+SELECT ` + creditPackageColumns + ` FROM credit_packages
+WHERE client = $1 AND ($2::int IS NULL OR coach = $2) AND expires_at > NOW()
+ORDER BY expires_at, id;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.SelectContext(ctx, &packages, query, client, coach); err != nil {
+			continue
+		}
+		return packages, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetCreditPackages").Inc()
+
+	return nil, fmt.Errorf("get credit packages of client %d faild: %w", client, err)
+}
+
+func (s *Store) GetCreditLedger(ctx context.Context, client int, coach *int) ([]models.CreditEntry, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetCreditLedger").Observe(time.Since(started).Seconds())
+	}()
+
+	entries := []models.CreditEntry{}
+	query := `
+SELECT id, client, coach, package_id, meeting_id, delta, reason, created_at FROM credit_ledger
+WHERE client = $1 AND ($2::int IS NULL OR coach = $2)
+ORDER BY created_at, id;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.SelectContext(ctx, &entries, query, client, coach); err != nil {
+			continue
+		}
+		return entries, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetCreditLedger").Inc()
+
+	return nil, fmt.Errorf("get credit ledger of client %d faild: %w", client, err)
+}
+
+// consumeCredit spends a credit of the client on the meeting, taking it from the package which expires first.
+// Clients who never had a package with the coach pay per session, so nothing is consumed for them.
+func consumeCredit(ctx context.Context, tx *sqlx.Tx, meeting models.Meeting) error {
+	var packageID int
+	err := tx.GetContext(ctx, &packageID, `
+UPDATE credit_packages
+SET remaining = remaining - 1
+WHERE id = (
+    SELECT id FROM credit_packages
+    WHERE client = $1 AND coach = $2 AND expires_at > NOW() AND remaining > 0
+    ORDER BY expires_at, id
+    LIMIT 1
+    FOR UPDATE
+)
+RETURNING id;`, meeting.Client, meeting.Manager)
+	if errors.Is(err, sql.ErrNoRows) {
+		var hasPackages bool
+		if err = tx.GetContext(ctx, &hasPackages, `
+SELECT EXISTS (SELECT 1 FROM credit_packages WHERE client = $1 AND coach = $2);`, meeting.Client, meeting.Manager); err != nil {
+			return err
+		}
+		if hasPackages {
+			return models.ErrInsufficientCredits
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO credit_ledger (client, coach, package_id, meeting_id, delta, reason)
+VALUES ($1, $2, $3, $4, -1, 'booking');`, meeting.Client, meeting.Manager, packageID, meeting.ID)
+	return err
+}
+
+// refundCredits returns the credits still spent on the meetings to their packages, only the credits of
+// the client when it is set. A meeting may have been booked and refunded several times, so the credits
+// are counted from the ledger.
+func refundCredits(ctx context.Context, tx *sqlx.Tx, ids []int, client *int) error {
+	_, err := tx.ExecContext(ctx, `
+WITH spent AS (
+    SELECT client, coach, package_id, meeting_id, -SUM(delta) AS credits FROM credit_ledger
+    WHERE meeting_id = ANY($1) AND ($2::int IS NULL OR client = $2)
+    GROUP BY client, coach, package_id, meeting_id
+    HAVING SUM(delta) < 0
+), refunded AS (
+    UPDATE credit_packages p
+    SET remaining = p.remaining + t.credits
+    FROM (SELECT package_id, SUM(credits) AS credits FROM spent GROUP BY package_id) t
+    WHERE p.id = t.package_id
+)
+INSERT INTO credit_ledger (client, coach, package_id, meeting_id, delta, reason)
+SELECT client, coach, package_id, meeting_id, credits, 'refund' FROM spent;`, ids, client)
+	return err
+}
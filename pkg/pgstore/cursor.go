@@ -0,0 +1,41 @@
+package pgstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// cursor is the position of the last item of a page. StartAt is only used for meetings.
+type cursor struct {
+	StartAt time.Time `json:"s,omitempty"`
+	ID      int       `json:"i"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor{}, models.ErrInvalidCursor
+	}
+	if err = json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return cursor{}, models.ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// pageOrder returns the ORDER BY direction of the page and the comparison operator
+// which selects the rows following its cursor.
+func pageOrder(page models.Page) (string, string) {
+	if page.Sort == models.SortDesc {
+		return `DESC`, `<`
+	}
+	return `ASC`, `>`
+}
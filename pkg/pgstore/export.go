@@ -0,0 +1,102 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// ExportMeetings calls fn for every meeting matching the filter, ordered by start, while reading the rows,
+// so the meetings are never held in memory together. The page of the filter is ignored.
+func (s *Store) ExportMeetings(ctx context.Context, filter models.MeetingFilter, fn func(models.MeetingExport) error) error {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("ExportMeetings").Observe(time.Since(started).Seconds())
+	}()
+
+	var query strings.Builder
+	query.WriteString(`
+SELECT m.id, m.start_at, m.end_at, m.status, m.manager, m.capacity,
+       concat_ws(' ', coach.first_name, coach.last_name) AS coach_name,
+       COALESCE(m.client, 0) AS client,
+       COALESCE(concat_ws(' ', client.first_name, client.last_name), '') AS client_name,
+       (SELECT count(*) FROM meeting_participants p WHERE p.meeting_id = m.id AND p.status = 'joined') AS participants
+FROM meetings m
+JOIN users coach ON coach.id = m.manager
+LEFT JOIN users client ON client.id = m.client
+WHERE `)
+	args := meetingConditions(&query, filter)
+	query.WriteString(` ORDER BY m.start_at, m.id;`)
+	err := s.export(ctx, "ExportMeetings", query.String(), args, func(rows *sqlx.Rows) error {
+		var meeting models.MeetingExport
+		if err := rows.StructScan(&meeting); err != nil {
+			return err
+		}
+		return fn(meeting)
+	})
+	if err != nil {
+		return fmt.Errorf("export meetings faild: %w", err)
+	}
+	return nil
+}
+
+// ExportUsers calls fn for every user matching the filter, ordered by id, while reading the rows.
+// The page of the filter is ignored.
+func (s *Store) ExportUsers(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("ExportUsers").Observe(time.Since(started).Seconds())
+	}()
+
+	var query strings.Builder
+	query.WriteString(`SELECT ` + userColumns + ` FROM users WHERE `)
+	args := userConditions(&query, filter)
+	query.WriteString(` ORDER BY id;`)
+	err := s.export(ctx, "ExportUsers", query.String(), args, func(rows *sqlx.Rows) error {
+		var user models.User
+		if err := rows.StructScan(&user); err != nil {
+			return err
+		}
+		return fn(user)
+	})
+	if err != nil {
+		return fmt.Errorf("export users faild: %w", err)
+	}
+	return nil
+}
+
+// export runs the query and calls row for each of its rows. Only running the query is retried,
+// rows already passed on can't be taken back.
+func (s *Store) export(ctx context.Context, name, query string, args []interface{}, row func(*sqlx.Rows) error) error {
+	var (
+		rows *sqlx.Rows
+		err  error
+	)
+	for i := 0; i < retries; i++ {
+		if rows, err = s.db.QueryxContext(ctx, query, args...); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		metrics.PgErrCount.WithLabelValues(name).Inc()
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	for rows.Next() {
+		if err = row(rows); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		metrics.PgErrCount.WithLabelValues(name).Inc()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+const idempotencyKeyColumns = `key, user_id, client, method, path, request_hash, status, response, created_at`
+
+// ReserveIdempotencyKey saves the key of a request which is starting. If the key is already known it returns
+// the saved one and false.
+func (s *Store) ReserveIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (models.IdempotencyKey, bool, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("ReserveIdempotencyKey").Observe(time.Since(started).Seconds())
+	}()
+
+	insertQuery := `
+INSERT INTO idempotency_keys (key, user_id, client, method, path, request_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT DO NOTHING;`
+	selectQuery := `
+SELECT ` + idempotencyKeyColumns + ` FROM idempotency_keys
+WHERE key = $1 AND user_id = $2 AND client = $3 AND method = $4 AND path = $5;`
+	var (
+		res sql.Result
+		err error
+	)
+	for i := 0; i < retries; i++ {
+		var saved models.IdempotencyKey
+		res, err = s.db.ExecContext(ctx, insertQuery, key.Key, key.UserID, key.Client, key.Method, key.Path, key.RequestHash)
+		if err != nil {
+			continue
+		}
+		if inserted, _ := res.RowsAffected(); inserted == 1 {
+			return key, true, nil
+		}
+		if err = s.db.GetContext(ctx, &saved, selectQuery, key.Key, key.UserID, key.Client, key.Method, key.Path); err != nil {
+			continue
+		}
+		return saved, false, nil
+	}
+	metrics.PgErrCount.WithLabelValues("ReserveIdempotencyKey").Inc()
+
+	return models.IdempotencyKey{}, false, fmt.Errorf("reserve idempotency key faild: %w", err)
+}
+
+// CompleteIdempotencyKey saves the response of the request for replaying.
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, key models.IdempotencyKey) error {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("CompleteIdempotencyKey").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+UPDATE idempotency_keys SET status = $6, response = $7
+WHERE key = $1 AND user_id = $2 AND client = $3 AND method = $4 AND path = $5;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if _, err = s.db.ExecContext(ctx, query,
+			key.Key, key.UserID, key.Client, key.Method, key.Path, key.Status, key.Response); err != nil {
+			continue
+		}
+		return nil
+	}
+	metrics.PgErrCount.WithLabelValues("CompleteIdempotencyKey").Inc()
+
+	return fmt.Errorf("complete idempotency key faild: %w", err)
+}
+
+// ReleaseIdempotencyKey forgets the key so the request can be retried.
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key models.IdempotencyKey) error {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("ReleaseIdempotencyKey").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+DELETE FROM idempotency_keys
+WHERE key = $1 AND user_id = $2 AND client = $3 AND method = $4 AND path = $5;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if _, err = s.db.ExecContext(ctx, query, key.Key, key.UserID, key.Client, key.Method, key.Path); err != nil {
+			continue
+		}
+		return nil
+	}
+	metrics.PgErrCount.WithLabelValues("ReleaseIdempotencyKey").Inc()
+
+	return fmt.Errorf("release idempotency key faild: %w", err)
+}
+
+// DeleteIdempotencyKeys deletes the keys created before the moment and returns how many were deleted.
+func (s *Store) DeleteIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("DeleteIdempotencyKeys").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+DELETE FROM idempotency_keys
+WHERE created_at < $1;`
+	var (
+		res sql.Result
+		err error
+	)
+	for i := 0; i < retries; i++ {
+		res, err = s.db.ExecContext(ctx, query, before)
+		if err != nil {
+			continue
+		}
+		deleted, _ := res.RowsAffected()
+		return deleted, nil
+	}
+	metrics.PgErrCount.WithLabelValues("DeleteIdempotencyKeys").Inc()
+
+	return 0, fmt.Errorf("delete idempotency keys faild: %w", err)
+}
@@ -0,0 +1,22 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE meetings
+    ADD CONSTRAINT meetings_period_check CHECK (start_at < end_at);
+
+ALTER TABLE meetings
+    ADD CONSTRAINT meetings_manager_overlap
+        EXCLUDE USING gist (manager WITH =, tstzrange(start_at, end_at) WITH &&);
+
+ALTER TABLE meetings
+    ADD CONSTRAINT meetings_client_overlap
+        EXCLUDE USING gist (client WITH =, tstzrange(start_at, end_at) WITH &&);
+
+-- +migrate Down
+
+ALTER TABLE meetings DROP CONSTRAINT meetings_client_overlap;
+ALTER TABLE meetings DROP CONSTRAINT meetings_manager_overlap;
+ALTER TABLE meetings DROP CONSTRAINT meetings_period_check;
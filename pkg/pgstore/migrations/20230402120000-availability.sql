@@ -0,0 +1,55 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE TABLE availability
+(
+    coach      int PRIMARY KEY REFERENCES users (id),
+    time_zone  varchar     NOT NULL DEFAULT 'UTC',
+    updated_at timestamptz NOT NULL DEFAULT NOW(),
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE working_hours
+(
+    id         serial PRIMARY KEY,
+    coach      int      NOT NULL REFERENCES availability (coach) ON DELETE CASCADE,
+    weekday    smallint NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_time time     NOT NULL,
+    end_time   time     NOT NULL,
+    CHECK (start_time < end_time)
+);
+
+CREATE INDEX working_hours_coach_idx ON working_hours (coach);
+
+CREATE TABLE availability_breaks
+(
+    id         serial PRIMARY KEY,
+    coach      int      NOT NULL REFERENCES availability (coach) ON DELETE CASCADE,
+    weekday    smallint NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_time time     NOT NULL,
+    end_time   time     NOT NULL,
+    CHECK (start_time < end_time)
+);
+
+CREATE INDEX availability_breaks_coach_idx ON availability_breaks (coach);
+
+CREATE TABLE availability_overrides
+(
+    id         serial PRIMARY KEY,
+    coach      int         NOT NULL REFERENCES availability (coach) ON DELETE CASCADE,
+    date       date        NOT NULL,
+    available  bool        NOT NULL,
+    start_time time,
+    end_time   time,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    UNIQUE (coach, date),
+    CHECK (NOT available OR (start_time IS NOT NULL AND end_time IS NOT NULL AND start_time < end_time))
+);
+
+-- +migrate Down
+
+DROP TABLE availability_overrides;
+DROP TABLE availability_breaks;
+DROP TABLE working_hours;
+DROP TABLE availability;
@@ -0,0 +1,40 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE TABLE meeting_series
+(
+    id         serial PRIMARY KEY,
+    manager    int         NOT NULL REFERENCES users (id),
+    client     int         NOT NULL REFERENCES users (id),
+    start_at   timestamptz NOT NULL,
+    end_at     timestamptz NOT NULL,
+    time_zone  varchar     NOT NULL,
+    rule       varchar     NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT NOW(),
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    CHECK (start_at < end_at)
+);
+
+CREATE TABLE meeting_series_exceptions
+(
+    series_id         int         NOT NULL REFERENCES meeting_series (id) ON DELETE CASCADE,
+    original_start_at timestamptz NOT NULL,
+    created_at        timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (series_id, original_start_at)
+);
+
+ALTER TABLE meetings
+    ADD COLUMN series_id         int REFERENCES meeting_series (id),
+    ADD COLUMN original_start_at timestamptz;
+
+CREATE INDEX meetings_series_idx ON meetings (series_id, original_start_at);
+
+-- +migrate Down
+
+DROP INDEX meetings_series_idx;
+ALTER TABLE meetings
+    DROP COLUMN original_start_at,
+    DROP COLUMN series_id;
+DROP TABLE meeting_series_exceptions;
+DROP TABLE meeting_series;
@@ -0,0 +1,90 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+ALTER TABLE meetings
+    ADD COLUMN status        varchar NOT NULL DEFAULT 'confirmed'
+        CHECK (status IN ('requested', 'confirmed', 'cancelled', 'completed', 'no_show')),
+    ADD COLUMN cancel_reason varchar;
+
+CREATE INDEX meetings_status_idx ON meetings (status);
+
+ALTER TABLE meetings DROP CONSTRAINT meetings_manager_overlap;
+ALTER TABLE meetings
+    ADD CONSTRAINT meetings_manager_overlap
+        EXCLUDE USING gist (manager WITH =, tstzrange(start_at, end_at) WITH &&)
+        WHERE (status IN ('requested', 'confirmed'));
+
+ALTER TABLE meetings DROP CONSTRAINT meetings_client_overlap;
+ALTER TABLE meetings
+    ADD CONSTRAINT meetings_client_overlap
+        EXCLUDE USING gist (client WITH =, tstzrange(start_at, end_at) WITH &&)
+        WHERE (status IN ('requested', 'confirmed'));
+
+ALTER TABLE meetings_history
+    ADD COLUMN status        varchar,
+    ADD COLUMN cancel_reason varchar,
+    ADD COLUMN operation     varchar;
+
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION meetings_history()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO meetings_history (meetings_id, manager, start_at, end_at, client, notified, status, cancel_reason,
+                                      operation, event_time, created_at)
+        VALUES (OLD.id, OLD.manager, OLD.start_at, OLD.end_at, OLD.client, OLD.notified, OLD.status, OLD.cancel_reason,
+                TG_OP, NOW(), OLD.created_at);
+    ELSE
+        INSERT INTO meetings_history (meetings_id, manager, start_at, end_at, client, notified, status, cancel_reason,
+                                      operation, event_time, created_at)
+        VALUES (NEW.id, NEW.manager, NEW.start_at, NEW.end_at, NEW.client, NEW.notified, NEW.status, NEW.cancel_reason,
+                TG_OP, NOW(), NEW.created_at);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+DROP TRIGGER IF EXISTS meetings_history_delete ON meetings;
+CREATE TRIGGER meetings_history_delete
+    AFTER DELETE ON meetings
+    FOR EACH ROW
+EXECUTE PROCEDURE meetings_history();
+
+-- +migrate Down
+
+DROP TRIGGER meetings_history_delete ON meetings;
+
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION meetings_history()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO meetings_history (meetings_id, manager, start_at, end_at, client, notified, event_time, created_at)
+    VALUES (NEW.id, NEW.manager, NEW.start_at, NEW.end_at, NEW.client, NEW.notified, NOW(), NEW.created_at);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+ALTER TABLE meetings_history
+    DROP COLUMN operation,
+    DROP COLUMN cancel_reason,
+    DROP COLUMN status;
+
+ALTER TABLE meetings DROP CONSTRAINT meetings_client_overlap;
+ALTER TABLE meetings
+    ADD CONSTRAINT meetings_client_overlap
+        EXCLUDE USING gist (client WITH =, tstzrange(start_at, end_at) WITH &&);
+
+ALTER TABLE meetings DROP CONSTRAINT meetings_manager_overlap;
+ALTER TABLE meetings
+    ADD CONSTRAINT meetings_manager_overlap
+        EXCLUDE USING gist (manager WITH =, tstzrange(start_at, end_at) WITH &&);
+
+DROP INDEX meetings_status_idx;
+ALTER TABLE meetings
+    DROP COLUMN cancel_reason,
+    DROP COLUMN status;
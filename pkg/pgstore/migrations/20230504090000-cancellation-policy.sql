@@ -0,0 +1,25 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE TABLE cancellation_policies
+(
+    coach                     int PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    free_cancellation_minutes int         NOT NULL DEFAULT 0 CHECK (free_cancellation_minutes >= 0),
+    allow_late_cancellation   boolean     NOT NULL DEFAULT TRUE,
+    updated_at                timestamptz NOT NULL DEFAULT NOW(),
+    created_at                timestamptz NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE meetings
+    ADD COLUMN cancelled_at      timestamptz,
+    ADD COLUMN cancelled_by      int REFERENCES users (id),
+    ADD COLUMN late_cancellation boolean;
+
+-- +migrate Down
+
+ALTER TABLE meetings
+    DROP COLUMN late_cancellation,
+    DROP COLUMN cancelled_by,
+    DROP COLUMN cancelled_at;
+DROP TABLE cancellation_policies;
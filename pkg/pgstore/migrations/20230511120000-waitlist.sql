@@ -0,0 +1,29 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE TABLE waitlist_entries
+(
+    id         serial PRIMARY KEY,
+    coach      int         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    client     int         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    start_at   timestamptz NOT NULL,
+    end_at     timestamptz NOT NULL,
+    status     varchar     NOT NULL DEFAULT 'waiting'
+        CHECK (status IN ('waiting', 'offered', 'accepted', 'declined', 'expired', 'left')),
+    offered_at timestamptz,
+    hold_until timestamptz,
+    updated_at timestamptz NOT NULL DEFAULT NOW(),
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    CHECK (start_at < end_at)
+);
+
+-- A client waits for a slot once, and a slot is offered to one client at a time.
+CREATE UNIQUE INDEX waitlist_entries_client_idx ON waitlist_entries (coach, start_at, end_at, client)
+    WHERE status IN ('waiting', 'offered');
+CREATE UNIQUE INDEX waitlist_entries_offer_idx ON waitlist_entries (coach, start_at, end_at)
+    WHERE status = 'offered';
+
+-- +migrate Down
+
+DROP TABLE waitlist_entries;
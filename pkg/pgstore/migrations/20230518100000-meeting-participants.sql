@@ -0,0 +1,81 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+ALTER TABLE meetings
+    ADD COLUMN capacity int NOT NULL DEFAULT 1 CHECK (capacity >= 1),
+    ALTER COLUMN client DROP NOT NULL;
+
+-- Participants keep a copy of the meeting period and state, so overlapping
+-- meetings of a client are rejected by a constraint like the one of the coach.
+CREATE TABLE meeting_participants
+(
+    meeting_id     int         NOT NULL REFERENCES meetings (id) ON DELETE CASCADE,
+    client         int         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    start_at       timestamptz NOT NULL,
+    end_at         timestamptz NOT NULL,
+    meeting_active boolean     NOT NULL,
+    status         varchar     NOT NULL DEFAULT 'joined' CHECK (status IN ('joined', 'left')),
+    joined_at      timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (meeting_id, client),
+    CONSTRAINT meeting_participants_overlap
+        EXCLUDE USING gist (client WITH =, tstzrange(start_at, end_at) WITH &&)
+        WHERE (status = 'joined' AND meeting_active)
+);
+
+CREATE INDEX meeting_participants_client_idx ON meeting_participants (client);
+
+INSERT INTO meeting_participants (meeting_id, client, start_at, end_at, meeting_active)
+SELECT id, client, start_at, end_at, status IN ('requested', 'confirmed')
+FROM meetings
+WHERE client IS NOT NULL;
+
+ALTER TABLE meetings DROP CONSTRAINT meetings_client_overlap;
+
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION meetings_participants()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        IF OLD.client IS NOT NULL AND NEW.client IS DISTINCT FROM OLD.client THEN
+            DELETE FROM meeting_participants WHERE meeting_id = NEW.id AND client = OLD.client;
+        END IF;
+        UPDATE meeting_participants
+        SET start_at       = NEW.start_at,
+            end_at         = NEW.end_at,
+            meeting_active = NEW.status IN ('requested', 'confirmed')
+        WHERE meeting_id = NEW.id;
+        IF NEW.client IS NOT DISTINCT FROM OLD.client THEN
+            RETURN NULL;
+        END IF;
+    END IF;
+    IF NEW.client IS NOT NULL THEN
+        INSERT INTO meeting_participants (meeting_id, client, start_at, end_at, meeting_active)
+        VALUES (NEW.id, NEW.client, NEW.start_at, NEW.end_at, NEW.status IN ('requested', 'confirmed'))
+        ON CONFLICT (meeting_id, client) DO UPDATE SET status = 'joined';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER meetings_participants
+    AFTER INSERT OR UPDATE OF client, start_at, end_at, status
+    ON meetings
+    FOR EACH ROW
+EXECUTE PROCEDURE meetings_participants();
+
+-- +migrate Down
+
+DROP TRIGGER meetings_participants ON meetings;
+DROP FUNCTION meetings_participants();
+DROP TABLE meeting_participants;
+DELETE FROM meetings WHERE client IS NULL;
+ALTER TABLE meetings
+    ADD CONSTRAINT meetings_client_overlap
+        EXCLUDE USING gist (client WITH =, tstzrange(start_at, end_at) WITH &&)
+        WHERE (status IN ('requested', 'confirmed'));
+ALTER TABLE meetings
+    ALTER COLUMN client SET NOT NULL,
+    DROP COLUMN capacity;
@@ -0,0 +1,36 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE TABLE credit_packages
+(
+    id         serial PRIMARY KEY,
+    client     int         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    coach      int         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    sessions   int         NOT NULL CHECK (sessions > 0),
+    remaining  int         NOT NULL CHECK (remaining >= 0),
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX credit_packages_client_idx ON credit_packages (client, coach, expires_at);
+
+CREATE TABLE credit_ledger
+(
+    id         serial PRIMARY KEY,
+    client     int         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    coach      int         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    package_id int         NOT NULL REFERENCES credit_packages (id) ON DELETE CASCADE,
+    meeting_id int REFERENCES meetings (id) ON DELETE SET NULL,
+    delta      int         NOT NULL,
+    reason     varchar     NOT NULL CHECK (reason IN ('grant', 'booking', 'refund')),
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX credit_ledger_client_idx ON credit_ledger (client, created_at);
+CREATE INDEX credit_ledger_meeting_idx ON credit_ledger (meeting_id);
+
+-- +migrate Down
+
+DROP TABLE credit_ledger;
+DROP TABLE credit_packages;
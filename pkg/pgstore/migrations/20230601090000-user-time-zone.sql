@@ -0,0 +1,11 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+ALTER TABLE users
+    ADD COLUMN time_zone varchar NOT NULL DEFAULT 'UTC';
+
+-- +migrate Down
+
+ALTER TABLE users
+    DROP COLUMN time_zone;
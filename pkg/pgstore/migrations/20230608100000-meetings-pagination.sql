@@ -0,0 +1,9 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE INDEX meetings_start_at_idx ON meetings (start_at, id);
+
+-- +migrate Down
+
+DROP INDEX meetings_start_at_idx;
@@ -0,0 +1,27 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE TABLE auth_sessions
+(
+    id         varchar PRIMARY KEY,
+    user_id    int         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    revoked_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE refresh_tokens
+(
+    token_hash varchar PRIMARY KEY,
+    session_id varchar     NOT NULL REFERENCES auth_sessions (id) ON DELETE CASCADE,
+    expires_at timestamptz NOT NULL,
+    rotated_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX refresh_tokens_session_idx ON refresh_tokens (session_id);
+
+-- +migrate Down
+
+DROP TABLE refresh_tokens;
+DROP TABLE auth_sessions;
@@ -0,0 +1,22 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE TABLE idempotency_keys
+(
+    key          varchar     NOT NULL,
+    user_id      int         NOT NULL,
+    method       varchar     NOT NULL,
+    path         varchar     NOT NULL,
+    request_hash varchar     NOT NULL,
+    status       int,
+    response     bytea,
+    created_at   timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (key, user_id, method, path)
+);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
+
+-- +migrate Down
+
+DROP TABLE idempotency_keys;
@@ -0,0 +1,52 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE TABLE webhooks
+(
+    id         serial PRIMARY KEY,
+    coach      int         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    url        varchar     NOT NULL,
+    secret     varchar     NOT NULL,
+    active     boolean     NOT NULL DEFAULT TRUE,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhooks_coach_idx ON webhooks (coach);
+
+CREATE TABLE webhook_events
+(
+    webhook_id int     NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event      varchar NOT NULL,
+    PRIMARY KEY (webhook_id, event)
+);
+
+CREATE INDEX webhook_events_event_idx ON webhook_events (event);
+
+CREATE TABLE webhook_deliveries
+(
+    id              serial PRIMARY KEY,
+    webhook_id      int         NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event           varchar     NOT NULL,
+    payload         bytea       NOT NULL,
+    status          varchar     NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts        int         NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz DEFAULT NOW(),
+    response_status int,
+    last_error      varchar,
+    delivered_at    timestamptz,
+    redelivery_of   int REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    created_at      timestamptz NOT NULL DEFAULT NOW(),
+    updated_at      timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, id);
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- +migrate Down
+
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_events;
+DROP TABLE webhooks;
@@ -0,0 +1,43 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+CREATE TABLE calendar_tokens
+(
+    user_id    int PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    token_hash varchar     NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+-- Sequence is the SEQUENCE of the meeting in calendar feeds. Calendar apps only take changes of an event
+-- with a greater sequence, so it grows whenever the time or the status of the meeting changes.
+ALTER TABLE meetings
+    ADD COLUMN sequence int NOT NULL DEFAULT 0;
+
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION meetings_sequence()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF NEW.start_at IS DISTINCT FROM OLD.start_at OR NEW.end_at IS DISTINCT FROM OLD.end_at
+        OR NEW.status IS DISTINCT FROM OLD.status THEN
+        NEW.sequence = OLD.sequence + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER meetings_sequence
+    BEFORE UPDATE OF start_at, end_at, status
+    ON meetings
+    FOR EACH ROW
+EXECUTE PROCEDURE meetings_sequence();
+
+-- +migrate Down
+
+DROP TRIGGER meetings_sequence ON meetings;
+DROP FUNCTION meetings_sequence();
+ALTER TABLE meetings
+    DROP COLUMN sequence;
+DROP TABLE calendar_tokens;
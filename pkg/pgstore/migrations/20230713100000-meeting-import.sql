@@ -0,0 +1,16 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+-- External id identifies the calendar event occurrence a meeting was imported from,
+-- so importing the same calendar twice doesn't duplicate meetings.
+ALTER TABLE meetings
+    ADD COLUMN external_id varchar;
+
+CREATE UNIQUE INDEX meetings_external_id_idx ON meetings (manager, external_id) WHERE external_id IS NOT NULL;
+
+-- +migrate Down
+
+DROP INDEX meetings_external_id_idx;
+ALTER TABLE meetings
+    DROP COLUMN external_id;
@@ -0,0 +1,35 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+-- Rate limit counters shared by the replicas of the service, see pkg/ratelimit.
+CREATE TABLE rate_limits
+(
+    key        varchar PRIMARY KEY,
+    count      int         NOT NULL,
+    updated_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX rate_limits_expires_at_idx ON rate_limits (expires_at);
+
+-- Audit of failed logins. user_id is set when the phone belongs to a user, locked_until when the failure
+-- locked the phone out.
+CREATE TABLE login_failures
+(
+    id           bigserial PRIMARY KEY,
+    phone        varchar     NOT NULL,
+    user_id      int REFERENCES users (id) ON DELETE SET NULL,
+    ip           varchar     NOT NULL,
+    user_agent   varchar     NOT NULL DEFAULT '',
+    locked_until timestamptz,
+    created_at   timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX login_failures_phone_idx ON login_failures (phone, created_at);
+CREATE INDEX login_failures_ip_idx ON login_failures (ip, created_at);
+
+-- +migrate Down
+
+DROP TABLE login_failures;
+DROP TABLE rate_limits;
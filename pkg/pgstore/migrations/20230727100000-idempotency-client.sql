@@ -0,0 +1,23 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+-- Client scopes the keys of unauthenticated requests, which all have user_id 0, by the address of the caller.
+ALTER TABLE idempotency_keys
+    ADD COLUMN client varchar NOT NULL DEFAULT '';
+
+ALTER TABLE idempotency_keys
+    DROP CONSTRAINT idempotency_keys_pkey,
+    ADD PRIMARY KEY (key, user_id, client, method, path);
+
+-- +migrate Down
+
+DELETE FROM idempotency_keys
+WHERE client <> '';
+
+ALTER TABLE idempotency_keys
+    DROP CONSTRAINT idempotency_keys_pkey,
+    ADD PRIMARY KEY (key, user_id, method, path);
+
+ALTER TABLE idempotency_keys
+    DROP COLUMN client;
@@ -0,0 +1,12 @@
+-- noinspection SqlNoDataSourceInspectionForFile
+
+-- +migrate Up
+
+-- Events of the external calendar block the slots of the coach who owns it. It is empty when the coach has none.
+ALTER TABLE availability
+    ADD COLUMN calendar_id varchar NOT NULL DEFAULT '';
+
+-- +migrate Down
+
+ALTER TABLE availability
+    DROP COLUMN calendar_id;
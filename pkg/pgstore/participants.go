@@ -0,0 +1,135 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// AddParticipant adds the client to the group session and spends a credit of the client on it.
+// The meeting row is locked, so concurrent joins can't exceed its capacity.
+func (s *Store) AddParticipant(ctx context.Context, id, client int) (models.Meeting, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("AddParticipant").Observe(time.Since(started).Seconds())
+	}()
+
+	var meeting models.Meeting
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &meeting, `
+SELECT `+meetingColumns+` FROM meetings
+WHERE id = $1
+FOR UPDATE;`, id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrMeetingNotFound
+		case err != nil:
+			return err
+		case meeting.Capacity < 2:
+			return models.ErrNotGroupSession
+		case meeting.Status != models.StatusRequested && meeting.Status != models.StatusConfirmed:
+			return models.ErrMeetingNotActive
+		}
+		var joined int
+		if err = tx.GetContext(ctx, &joined, `
+SELECT count(*) FROM meeting_participants
+WHERE meeting_id = $1 AND status = 'joined';`, id); err != nil {
+			return err
+		}
+		if joined >= meeting.Capacity {
+			return models.ErrMeetingFull
+		}
+		result, err := tx.ExecContext(ctx, `
+INSERT INTO meeting_participants (meeting_id, client, start_at, end_at, meeting_active)
+VALUES ($1, $2, $3, $4, TRUE)
+ON CONFLICT (meeting_id, client) DO UPDATE
+SET status = 'joined', joined_at = NOW()
+WHERE meeting_participants.status = 'left';`, id, client, meeting.StartTime, meeting.EndTime)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return models.ErrAlreadyParticipant
+		}
+		booking := meeting
+		booking.Client = client
+		if err = consumeCredit(ctx, tx, booking); err != nil {
+			return err
+		}
+		meeting.Participants, err = getParticipants(ctx, tx, id)
+		return err
+	})
+	switch {
+	case errors.Is(err, ErrMeetingNotFound), errors.Is(err, models.ErrNotGroupSession), errors.Is(err, models.ErrMeetingNotActive),
+		errors.Is(err, models.ErrMeetingFull), errors.Is(err, models.ErrAlreadyParticipant), errors.Is(err, models.ErrInsufficientCredits):
+		return models.Meeting{}, err
+	case isExclusionViolation(err):
+		return models.Meeting{}, s.meetingConflict(ctx, 0, models.MeetingRequest{
+			Client:    &client,
+			StartTime: &meeting.StartTime,
+			EndTime:   &meeting.EndTime,
+		})
+	case err != nil:
+		metrics.PgErrCount.WithLabelValues("AddParticipant").Inc()
+		return models.Meeting{}, fmt.Errorf("add participant %d to meeting %d faild: %w", client, id, err)
+	}
+	return meeting, nil
+}
+
+// RemoveParticipant takes the client out of the group session. With refund set the credits the client
+// spent on the session are returned.
+func (s *Store) RemoveParticipant(ctx context.Context, id, client int, refund bool) (models.Meeting, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("RemoveParticipant").Observe(time.Since(started).Seconds())
+	}()
+
+	var meeting models.Meeting
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+UPDATE meeting_participants
+SET status = 'left'
+WHERE meeting_id = $1 AND client = $2 AND status = 'joined';`, id, client)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return models.ErrNotParticipant
+		}
+		if refund {
+			if err = refundCredits(ctx, tx, []int{id}, &client); err != nil {
+				return err
+			}
+		}
+		if err = tx.GetContext(ctx, &meeting, `
+SELECT `+meetingColumns+` FROM meetings
+WHERE id = $1;`, id); err != nil {
+			return err
+		}
+		meeting.Participants, err = getParticipants(ctx, tx, id)
+		return err
+	})
+	switch {
+	case errors.Is(err, models.ErrNotParticipant):
+		return models.Meeting{}, err
+	case err != nil:
+		metrics.PgErrCount.WithLabelValues("RemoveParticipant").Inc()
+		return models.Meeting{}, fmt.Errorf("remove participant %d from meeting %d faild: %w", client, id, err)
+	}
+	return meeting, nil
+}
+
+func getParticipants(ctx context.Context, q sqlx.QueryerContext, id int) ([]int, error) {
+	participants := []int{}
+	err := sqlx.SelectContext(ctx, q, &participants, `
+SELECT client FROM meeting_participants
+WHERE meeting_id = $1 AND status = 'joined'
+ORDER BY joined_at, client;`, id)
+	return participants, err
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 	"github.com/pershin-daniil/TimeSlots/pkg/models"
 	migrate "github.com/rubenv/sql-migrate"
@@ -20,19 +21,53 @@ import (
 //go:embed migrations
 var migrations embed.FS
 
-const retries = 3
+const (
+	retries = 3
+
+	exclusionViolation = "23P01"
+	uniqueViolation    = "23505"
+
+	userColumns    = `id, last_name, first_name, phone, COALESCE(email, '') AS email, role, time_zone, updated_at, created_at`
+	meetingColumns = `id, manager, start_at, end_at, COALESCE(client, 0) AS client, capacity, notified, status, cancel_reason, cancelled_at, cancelled_by, late_cancellation, series_id, original_start_at, sequence, updated_at, created_at`
+
+	// activeMeeting matches meetings which occupy time of their participants.
+	activeMeeting = `status IN ('requested', 'confirmed')`
+)
 
 type Store struct {
 	log *logrus.Entry
 	db  *sqlx.DB
 }
 
+// Errors of the store are domain errors, the aliases are kept for the callers matching them here.
 var (
-	ErrUserNotFound    = fmt.Errorf("user not found")
-	ErrMeetingNotFound = fmt.Errorf("meeting not found")
-	ErrUserExists      = fmt.Errorf("user already exists")
+	ErrUserNotFound               = models.ErrUserNotFound
+	ErrMeetingNotFound            = models.ErrMeetingNotFound
+	ErrUserExists                 = models.ErrUserExists
+	ErrMeetingConflict            = models.ErrMeetingConflict
+	ErrAvailabilityNotFound       = models.NewError(models.CodeAvailabilityNotFound, "availability not found")
+	ErrOverrideNotFound           = models.NewError(models.CodeOverrideNotFound, "availability override not found")
+	ErrSeriesNotFound             = models.NewError(models.CodeSeriesNotFound, "series not found")
+	ErrCancellationPolicyNotFound = models.NewError(models.CodeCancellationPolicyNotFound, "cancellation policy not found")
+	ErrWaitlistEntryNotFound      = models.NewError(models.CodeWaitlistEntryNotFound, "waitlist entry not found")
+	ErrSessionNotFound            = models.ErrSessionNotFound
+	ErrCalendarTokenNotFound      = models.ErrInvalidCalendarToken
 )
 
+// MeetingConflictError carries the meetings which overlap the rejected one.
+// It matches ErrMeetingConflict with errors.Is.
+type MeetingConflictError struct {
+	Meetings []models.Meeting
+}
+
+func (e *MeetingConflictError) Error() string {
+	return ErrMeetingConflict.Error()
+}
+
+func (e *MeetingConflictError) Unwrap() error {
+	return ErrMeetingConflict
+}
+
 func New(ctx context.Context, log *logrus.Logger, dsn string) (*Store, error) {
 	db, err := sqlx.ConnectContext(ctx, "pgx", dsn)
 	if err != nil {
@@ -72,24 +107,95 @@ func (s *Store) Migrate(direction migrate.MigrationDirection) error {
 	return nil
 }
 
-func (s *Store) GetUsers(ctx context.Context) ([]models.User, error) {
+// GetUsers returns a page of users matching the filter ordered by id.
+func (s *Store) GetUsers(ctx context.Context, filter models.UserFilter) (models.UserPage, error) {
 	started := time.Now()
 	defer func() {
 		metrics.PgDuration.WithLabelValues("GetUsers").Observe(time.Since(started).Seconds())
 	}()
-	var users []models.User
+
+	var query strings.Builder
+	query.WriteString(`SELECT ` + userColumns + ` FROM users WHERE `)
+	args := userConditions(&query, filter)
+	order, cmp := pageOrder(filter.Page)
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return models.UserPage{}, err
+		}
+		args = append(args, c.ID)
+		query.WriteString(` AND id ` + cmp + ` $` + fmt.Sprint(len(args)))
+	}
+	query.WriteString(` ORDER BY id ` + order)
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit+1)
+		query.WriteString(` LIMIT $` + fmt.Sprint(len(args)))
+	}
 	var err error
-	query := `SELECT id, last_name, first_name, phone, COALESCE(email, '') AS email, updated_at, created_at FROM users
-WHERE NOT deleted;`
 	for i := 0; i < retries; i++ {
-		if err = s.db.SelectContext(ctx, &users, query); err != nil {
+		users := []models.User{}
+		if err = s.db.SelectContext(ctx, &users, query.String(), args...); err != nil {
 			continue
 		}
-		return users, nil
+		page := models.UserPage{Users: users}
+		if filter.Limit > 0 && len(users) > filter.Limit {
+			page.Users = users[:filter.Limit]
+			page.Next = encodeCursor(cursor{ID: page.Users[filter.Limit-1].ID})
+		}
+		return page, nil
 	}
 	metrics.PgErrCount.WithLabelValues("GetUsers").Inc()
 
-	return nil, fmt.Errorf("get users failed: %w", err)
+	return models.UserPage{}, fmt.Errorf("get users failed: %w", err)
+}
+
+// userConditions writes the conditions of the filter, except its page, to the query and returns their arguments.
+func userConditions(query *strings.Builder, filter models.UserFilter) []interface{} {
+	var args []interface{}
+	query.WriteString(`NOT deleted`)
+	if filter.ID != nil {
+		args = append(args, *filter.ID)
+		query.WriteString(` AND id = $` + fmt.Sprint(len(args)))
+	}
+	if filter.Role != nil {
+		args = append(args, *filter.Role)
+		query.WriteString(` AND role = $` + fmt.Sprint(len(args)))
+	}
+	if filter.Coach != nil {
+		args = append(args, *filter.Coach)
+		query.WriteString(` AND id IN (` + rosterQuery(len(args)) + `)`)
+	}
+	return args
+}
+
+// rosterQuery selects the clients of the coach given by parameter n: the ones who have meetings with them.
+// Session packages are granted to the roster only, so they don't add anyone to it.
+func rosterQuery(n int) string {
+	return fmt.Sprintf(`
+SELECT p.client FROM meeting_participants p
+JOIN meetings m ON m.id = p.meeting_id
+WHERE m.manager = $%d`, n)
+}
+
+// InRoster reports whether the client is on the roster of the coach.
+func (s *Store) InRoster(ctx context.Context, coach, client int) (bool, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("InRoster").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `SELECT EXISTS (SELECT 1 FROM (` + rosterQuery(1) + `) roster WHERE client = $2);`
+	var inRoster bool
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.GetContext(ctx, &inRoster, query, coach, client); err != nil {
+			continue
+		}
+		return inRoster, nil
+	}
+	metrics.PgErrCount.WithLabelValues("InRoster").Inc()
+
+	return false, fmt.Errorf("check roster of coach %d faild: %w", coach, err)
 }
 
 func (s *Store) CreateUser(ctx context.Context, user models.UserRequest) (models.User, error) {
@@ -117,12 +223,12 @@ func (s *Store) CreateUser(ctx context.Context, user models.UserRequest) (models
 	}
 	var createdUser models.User
 	query := `
-INSERT INTO users (last_name, first_name, phone, email, password_hash)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, last_name, first_name, phone, COALESCE(email, '') AS email, updated_at, created_at, password_hash;`
+INSERT INTO users (last_name, first_name, phone, email, password_hash, time_zone)
+VALUES ($1, $2, $3, $4, $5, COALESCE($6, 'UTC'))
+RETURNING id, last_name, first_name, phone, COALESCE(email, '') AS email, time_zone, updated_at, created_at, password_hash;`
 
 	for i := 0; i < retries; i++ {
-		if err = s.db.GetContext(ctx, &createdUser, query, user.LastName, user.FirstName, user.Phone, user.Email, user.PasswordHash); err != nil {
+		if err = s.db.GetContext(ctx, &createdUser, query, user.LastName, user.FirstName, user.Phone, user.Email, user.PasswordHash, user.TimeZone); err != nil {
 			continue
 		}
 		return createdUser, nil
@@ -166,7 +272,7 @@ func (s *Store) GetUserByPhone(ctx context.Context, phone string) (models.User,
 
 	var user models.User
 	query := `
-SELECT id, last_name, first_name, phone, COALESCE(email, '') AS email, updated_at, created_at, phone, password_hash, role
+SELECT id, last_name, first_name, phone, COALESCE(email, '') AS email, time_zone, updated_at, created_at, phone, password_hash, role
 FROM users
 WHERE phone = $1 AND NOT deleted;`
 	var err error
@@ -185,6 +291,35 @@ WHERE phone = $1 AND NOT deleted;`
 	return models.User{}, fmt.Errorf("get user by phone (%s) faild: %w", phone, err)
 }
 
+// FindClientsByContact returns the clients on the roster of the coach with one of the emails, compared
+// case-insensitively, or one of the phones.
+func (s *Store) FindClientsByContact(ctx context.Context, coach int, emails, phones []string) ([]models.User, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("FindClientsByContact").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+SELECT id, last_name, first_name, phone, COALESCE(email, '') AS email, time_zone, updated_at, created_at FROM users
+WHERE (lower(email) = ANY($1) OR phone = ANY($2)) AND NOT deleted AND id IN (` + rosterQuery(3) + `)
+ORDER BY id;`
+	lowered := make([]string, 0, len(emails))
+	for _, email := range emails {
+		lowered = append(lowered, strings.ToLower(email))
+	}
+	var err error
+	for i := 0; i < retries; i++ {
+		var users []models.User
+		if err = s.db.SelectContext(ctx, &users, query, lowered, phones, coach); err != nil {
+			continue
+		}
+		return users, nil
+	}
+	metrics.PgErrCount.WithLabelValues("FindClientsByContact").Inc()
+
+	return nil, fmt.Errorf("find clients of coach %d by contact faild: %w", coach, err)
+}
+
 func (s *Store) GetUser(ctx context.Context, id int) (models.User, error) {
 	started := time.Now()
 	defer func() {
@@ -193,7 +328,7 @@ func (s *Store) GetUser(ctx context.Context, id int) (models.User, error) {
 
 	var user models.User
 	query := `
-SELECT id, last_name, first_name, phone, COALESCE(email, '') AS email, updated_at, created_at FROM users
+SELECT id, last_name, first_name, phone, COALESCE(email, '') AS email, time_zone, updated_at, created_at FROM users
 WHERE id = $1 AND NOT deleted;`
 	var err error
 	for i := 0; i < retries; i++ {
@@ -217,17 +352,8 @@ func (s *Store) UpdateUser(ctx context.Context, id int, user models.UserRequest)
 		metrics.PgDuration.WithLabelValues("UpdateUser").Observe(time.Since(started).Seconds())
 	}()
 
-	tx, err := s.db.Beginx()
-	if err != nil {
-		return models.User{}, fmt.Errorf("open transaction faild: %w", err)
-	}
-	defer func() {
-		if err = tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
-			s.log.Warnf("rollback transaction faild: %v", err)
-		}
-	}()
-
 	var updatedUser models.User
+	var err error
 	var args []interface{}
 	var query strings.Builder
 	query.WriteString(`UPDATE users SET` + ` `)
@@ -247,14 +373,18 @@ func (s *Store) UpdateUser(ctx context.Context, id int, user models.UserRequest)
 		args = append(args, *user.Email)
 		query.WriteString(`email = $` + fmt.Sprint(len(args)) + `, `)
 	}
-	args = append(args, id)
-	query.WriteString(fmt.Sprintf(` updated_at = NOW() WHERE id = $%d
-RETURNING id, last_name, first_name, phone, COALESCE(email, '') AS email, updated_at, created_at;`, len(args)))
+	if user.TimeZone != nil {
+		args = append(args, *user.TimeZone)
+		query.WriteString(`time_zone = $` + fmt.Sprint(len(args)) + `, `)
+	}
+	args = append(args, id, user.IfMatch)
+	query.WriteString(fmt.Sprintf(` updated_at = NOW() WHERE id = $%d AND ($%d::timestamptz IS NULL OR updated_at = $%[2]d)
+RETURNING id, last_name, first_name, phone, COALESCE(email, '') AS email, time_zone, updated_at, created_at;`, len(args)-1, len(args)))
 	for i := 0; i < retries; i++ {
-		err = tx.GetContext(ctx, &updatedUser, query.String(), args...)
+		err = s.db.GetContext(ctx, &updatedUser, query.String(), args...)
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return models.User{}, ErrUserNotFound
+			return models.User{}, s.userPrecondition(ctx, id, user.IfMatch)
 		case err != nil:
 			continue
 		}
@@ -265,7 +395,8 @@ RETURNING id, last_name, first_name, phone, COALESCE(email, '') AS email, update
 	return models.User{}, fmt.Errorf("update user %d faild: %w", id, err)
 }
 
-func (s *Store) DeleteUser(ctx context.Context, id int) (models.User, error) {
+// DeleteUser marks the user deleted. With ifMatch set the user must not have changed since that moment.
+func (s *Store) DeleteUser(ctx context.Context, id int, ifMatch *time.Time) (models.User, error) {
 	started := time.Now()
 	defer func() {
 		metrics.PgDuration.WithLabelValues("DeleteUser").Observe(time.Since(started).Seconds())
@@ -274,15 +405,15 @@ func (s *Store) DeleteUser(ctx context.Context, id int) (models.User, error) {
 	var deletedUser models.User
 	query := `
 UPDATE users
-SET deleted = true
-WHERE id = $1
-RETURNING id, last_name, first_name, phone, COALESCE(email, '') AS email, deleted, updated_at, created_at;`
+SET deleted = true, updated_at = NOW()
+WHERE id = $1 AND ($2::timestamptz IS NULL OR updated_at = $2)
+RETURNING id, last_name, first_name, phone, COALESCE(email, '') AS email, time_zone, deleted, updated_at, created_at;`
 	var err error
 	for i := 0; i < retries; i++ {
-		err = s.db.GetContext(ctx, &deletedUser, query, id)
+		err = s.db.GetContext(ctx, &deletedUser, query, id, ifMatch)
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return models.User{}, ErrUserNotFound
+			return models.User{}, s.userPrecondition(ctx, id, ifMatch)
 		case err != nil:
 			continue
 		}
@@ -293,6 +424,19 @@ RETURNING id, last_name, first_name, phone, COALESCE(email, '') AS email, delete
 	return models.User{}, fmt.Errorf("delete user %d faild: %w", id, err)
 }
 
+// userPrecondition explains why a conditional update of the user matched no rows.
+func (s *Store) userPrecondition(ctx context.Context, id int, ifMatch *time.Time) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1);`
+	if err := s.db.GetContext(ctx, &exists, query, id); err != nil {
+		return fmt.Errorf("check user %d faild: %w", id, err)
+	}
+	if exists && ifMatch != nil {
+		return models.ErrPreconditionFailed
+	}
+	return ErrUserNotFound
+}
+
 func (s *Store) CreateMeeting(ctx context.Context, meeting models.MeetingRequest) (models.Meeting, error) {
 	started := time.Now()
 	defer func() {
@@ -301,38 +445,134 @@ func (s *Store) CreateMeeting(ctx context.Context, meeting models.MeetingRequest
 
 	var newMeeting models.Meeting
 	query := `
-INSERT INTO meetings (manager, start_at, end_at, client)
-VALUES ($1, $2, $3, $4)
-RETURNING id, manager, start_at, end_at, client, updated_at, created_at;`
+INSERT INTO meetings (manager, start_at, end_at, client, status, capacity, external_id)
+VALUES ($1, $2, $3, $4, COALESCE($5, 'confirmed'), COALESCE($6, 1), $7)
+RETURNING ` + meetingColumns + `;`
 	var err error
 	for i := 0; i < retries; i++ {
-		if err = s.db.GetContext(ctx, &newMeeting, query, meeting.Manager, meeting.StartTime, meeting.EndTime, meeting.Client); err != nil {
+		err = s.inTx(ctx, func(tx *sqlx.Tx) error {
+			if err := tx.GetContext(ctx, &newMeeting, query,
+				meeting.Manager, meeting.StartTime, meeting.EndTime, meeting.Client, meeting.Status, meeting.Capacity,
+				meeting.ExternalID); err != nil {
+				return err
+			}
+			if meeting.Client == nil {
+				return nil
+			}
+			return consumeCredit(ctx, tx, newMeeting)
+		})
+		switch {
+		case isExclusionViolation(err):
+			return models.Meeting{}, s.meetingConflict(ctx, 0, meeting)
+		case isUniqueViolation(err):
+			return models.Meeting{}, models.ErrAlreadyImported
+		case errors.Is(err, models.ErrInsufficientCredits):
+			return models.Meeting{}, err
+		case err != nil:
 			continue
 		}
-		return newMeeting, err
+		return newMeeting, nil
 	}
 	metrics.PgErrCount.WithLabelValues("CreateMeeting").Inc()
 
 	return models.Meeting{}, fmt.Errorf("create meeting faild: %w", err)
 }
 
-func (s *Store) GetMeetings(ctx context.Context) ([]models.Meeting, error) {
+// GetMeetings returns a page of meetings matching the filter ordered by start_at.
+func (s *Store) GetMeetings(ctx context.Context, filter models.MeetingFilter) (models.MeetingPage, error) {
 	started := time.Now()
 	defer func() {
 		metrics.PgDuration.WithLabelValues("GetMeetings").Observe(time.Since(started).Seconds())
 	}()
 
+	var query strings.Builder
+	query.WriteString(`SELECT ` + meetingColumns + ` FROM meetings m WHERE `)
+	args := meetingConditions(&query, filter)
+	order, cmp := pageOrder(filter.Page)
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return models.MeetingPage{}, err
+		}
+		args = append(args, c.StartAt, c.ID)
+		query.WriteString(fmt.Sprintf(` AND (start_at, id) %s ($%d, $%d)`, cmp, len(args)-1, len(args)))
+	}
+	query.WriteString(` ORDER BY start_at ` + order + `, id ` + order)
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit+1)
+		query.WriteString(` LIMIT $` + fmt.Sprint(len(args)))
+	}
+	var err error
+	for i := 0; i < retries; i++ {
+		meetings := []models.Meeting{}
+		if err = s.db.SelectContext(ctx, &meetings, query.String(), args...); err != nil {
+			continue
+		}
+		page := models.MeetingPage{Meetings: meetings}
+		if filter.Limit > 0 && len(meetings) > filter.Limit {
+			page.Meetings = meetings[:filter.Limit]
+			last := page.Meetings[filter.Limit-1]
+			page.Next = encodeCursor(cursor{StartAt: last.StartTime, ID: last.ID})
+		}
+		return page, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetMeetings").Inc()
+
+	return models.MeetingPage{}, fmt.Errorf("get meetings faild: %w", err)
+}
+
+// meetingConditions writes the conditions of the filter, except its page, on meetings aliased m to the query
+// and returns their arguments.
+func meetingConditions(query *strings.Builder, filter models.MeetingFilter) []interface{} {
+	var args []interface{}
+	query.WriteString(`TRUE`)
+	if len(filter.Statuses) > 0 {
+		args = append(args, filter.Statuses)
+		query.WriteString(` AND m.status = ANY($` + fmt.Sprint(len(args)) + `)`)
+	}
+	if filter.Manager != nil {
+		args = append(args, *filter.Manager)
+		query.WriteString(` AND m.manager = $` + fmt.Sprint(len(args)))
+	}
+	if filter.Client != nil {
+		args = append(args, *filter.Client)
+		query.WriteString(` AND m.id IN (SELECT meeting_id FROM meeting_participants WHERE status = 'joined' AND client = $` +
+			fmt.Sprint(len(args)) + `)`)
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query.WriteString(` AND m.start_at >= $` + fmt.Sprint(len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query.WriteString(` AND m.start_at < $` + fmt.Sprint(len(args)))
+	}
+	return args
+}
+
+// GetManagerMeetings returns active meetings of the manager which overlap [from, to).
+func (s *Store) GetManagerMeetings(ctx context.Context, manager int, from, to time.Time) ([]models.Meeting, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetManagerMeetings").Observe(time.Since(started).Seconds())
+	}()
+
 	var meetings []models.Meeting
+	query := `
+SELECT ` + meetingColumns + ` FROM meetings
+WHERE manager = $1 AND tstzrange(start_at, end_at) && tstzrange($2, $3)
+AND ` + activeMeeting + `
+ORDER BY start_at;`
 	var err error
 	for i := 0; i < retries; i++ {
-		if err = s.db.SelectContext(ctx, &meetings, `SELECT id, manager, start_at, end_at, client, updated_at, created_at FROM meetings`); err != nil {
+		if err = s.db.SelectContext(ctx, &meetings, query, manager, from, to); err != nil {
 			continue
 		}
 		return meetings, nil
 	}
-	metrics.PgErrCount.WithLabelValues("GetMeetings").Inc()
+	metrics.PgErrCount.WithLabelValues("GetManagerMeetings").Inc()
 
-	return nil, fmt.Errorf("get meetings faild: %w", err)
+	return nil, fmt.Errorf("get meetings of manager %d faild: %w", manager, err)
 }
 
 func (s *Store) GetMeeting(ctx context.Context, id int) (models.Meeting, error) {
@@ -343,7 +583,7 @@ func (s *Store) GetMeeting(ctx context.Context, id int) (models.Meeting, error)
 
 	var meeting models.Meeting
 	query := `
-SELECT id, manager, start_at, end_at, client, updated_at, created_at FROM meetings
+SELECT ` + meetingColumns + ` FROM meetings
 WHERE id = $1;`
 	var err error
 	for i := 0; i < retries; i++ {
@@ -354,6 +594,11 @@ WHERE id = $1;`
 		case err != nil:
 			continue
 		}
+		if meeting.Capacity > 1 {
+			if meeting.Participants, err = getParticipants(ctx, s.db, id); err != nil {
+				continue
+			}
+		}
 		return meeting, nil
 	}
 	metrics.PgErrCount.WithLabelValues("GetMeeting").Inc()
@@ -361,6 +606,7 @@ WHERE id = $1;`
 	return models.Meeting{}, fmt.Errorf("get meeting %d faild: %w", id, err)
 }
 
+// UpdateMeeting moves the meeting. Its client is never changed, the credit spent on it belongs to the client.
 func (s *Store) UpdateMeeting(ctx context.Context, id int, meeting models.MeetingRequest) (models.Meeting, error) {
 	started := time.Now()
 	defer func() {
@@ -372,7 +618,7 @@ func (s *Store) UpdateMeeting(ctx context.Context, id int, meeting models.Meetin
 		return models.Meeting{}, fmt.Errorf("open transaction faild: %w", err)
 	}
 	defer func() {
-		if err = tx.Rollback(); err != nil && errors.Is(err, sql.ErrTxDone) {
+		if err = tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
 			s.log.Warnf("rollback transaction faild: %v", err)
 		}
 	}()
@@ -393,21 +639,27 @@ func (s *Store) UpdateMeeting(ctx context.Context, id int, meeting models.Meetin
 		args = append(args, *meeting.EndTime)
 		query.WriteString(`end_at = $` + fmt.Sprint(len(args)) + `, `)
 	}
-	if meeting.Client != nil {
-		args = append(args, *meeting.Client)
-		query.WriteString(`client = $` + fmt.Sprint(len(args)) + `, `)
-	}
-	args = append(args, id)
-	query.WriteString(fmt.Sprintf(` updated_at = NOW() WHERE id = $%d
-RETURNING id, manager, start_at, end_at, client, updated_at, created_at;`, len(args)))
+	args = append(args, id, meeting.IfMatch)
+	query.WriteString(fmt.Sprintf(` updated_at = NOW() WHERE id = $%d AND ($%d::timestamptz IS NULL OR updated_at = $%[2]d)
+RETURNING `+meetingColumns+`;`, len(args)-1, len(args)))
 	for i := 0; i < retries; i++ {
 		err = tx.GetContext(ctx, &updatedMeeting, query.String(), args...)
 		switch {
-		case errors.Is(err, sql.ErrNoRows):
+		case errors.Is(err, sql.ErrNoRows) && meeting.IfMatch == nil:
 			return models.Meeting{}, ErrMeetingNotFound
+		case errors.Is(err, sql.ErrNoRows):
+			if _, err = s.GetMeeting(ctx, id); err != nil {
+				return models.Meeting{}, err
+			}
+			return models.Meeting{}, models.ErrPreconditionFailed
+		case isExclusionViolation(err):
+			return models.Meeting{}, s.meetingConflict(ctx, id, meeting)
 		case err != nil:
 			continue
 		}
+		if err = tx.Commit(); err != nil {
+			return models.Meeting{}, fmt.Errorf("commit transaction faild: %w", err)
+		}
 		return updatedMeeting, nil
 	}
 	metrics.PgErrCount.WithLabelValues("UpdateMeeting").Inc()
@@ -415,31 +667,111 @@ RETURNING id, manager, start_at, end_at, client, updated_at, created_at;`, len(a
 	return models.Meeting{}, fmt.Errorf("update meeting %d faild: %w", id, err)
 }
 
-func (s *Store) DeleteMeeting(ctx context.Context, id int) (models.Meeting, error) {
+// SetMeetingStatus applies the status change to the meeting. ErrInvalidTransition is returned
+// when the meeting is not in status change.From anymore. Credits spent on the meeting are refunded
+// when it is cancelled on time. A cancelled occurrence of a series is recorded as an exception,
+// so it is not created again when the series changes.
+func (s *Store) SetMeetingStatus(ctx context.Context, id int, change models.StatusChange) (models.Meeting, error) {
 	started := time.Now()
 	defer func() {
-		metrics.PgDuration.WithLabelValues("DeleteMeeting").Observe(time.Since(started).Seconds())
+		metrics.PgDuration.WithLabelValues("SetMeetingStatus").Observe(time.Since(started).Seconds())
 	}()
 
-	var deletedMeeting models.Meeting
+	var updatedMeeting models.Meeting
 	query := `
-DELETE FROM meetings
-WHERE id = $1
-RETURNING id, manager, start_at, end_at, client, updated_at, created_at;`
+WITH updated AS (
+    UPDATE meetings
+    SET status            = $3,
+        cancel_reason     = CASE WHEN $3 = 'cancelled' THEN $4::varchar END,
+        cancelled_at      = CASE WHEN $3 = 'cancelled' THEN NOW() END,
+        cancelled_by      = CASE WHEN $3 = 'cancelled' THEN $5::int END,
+        late_cancellation = CASE WHEN $3 = 'cancelled' THEN $6::boolean END,
+        updated_at        = NOW()
+    WHERE id = $1 AND status = $2 AND ($7::timestamptz IS NULL OR updated_at = $7)
+    RETURNING ` + meetingColumns + `
+), exception AS (
+    INSERT INTO meeting_series_exceptions (series_id, original_start_at)
+    SELECT series_id, original_start_at FROM updated
+    WHERE series_id IS NOT NULL AND status = 'cancelled'
+    ON CONFLICT DO NOTHING
+), spent AS (
+    SELECT l.client, l.coach, l.package_id, -SUM(l.delta) AS credits FROM credit_ledger l
+    JOIN updated u ON u.id = l.meeting_id
+    WHERE u.status = 'cancelled' AND NOT u.late_cancellation
+    GROUP BY l.client, l.coach, l.package_id
+    HAVING SUM(l.delta) < 0
+), refunded AS (
+    UPDATE credit_packages p
+    SET remaining = p.remaining + spent.credits
+    FROM spent
+    WHERE p.id = spent.package_id
+), refund AS (
+    INSERT INTO credit_ledger (client, coach, package_id, meeting_id, delta, reason)
+    SELECT client, coach, package_id, $1, credits, 'refund' FROM spent
+)
+SELECT ` + meetingColumns + ` FROM updated;`
 	var err error
 	for i := 0; i < retries; i++ {
-		err = s.db.GetContext(ctx, &deletedMeeting, query, id)
+		err = s.db.GetContext(ctx, &updatedMeeting, query,
+			id, change.From, change.To, change.Reason, change.CancelledBy, change.LateCancellation, change.IfMatch)
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return models.Meeting{}, ErrMeetingNotFound
+			meeting, err := s.GetMeeting(ctx, id)
+			if err != nil {
+				return models.Meeting{}, err
+			}
+			if change.IfMatch != nil && !meeting.UpdatedAt.Equal(*change.IfMatch) {
+				return models.Meeting{}, models.ErrPreconditionFailed
+			}
+			return models.Meeting{}, models.ErrInvalidTransition
 		case err != nil:
 			continue
 		}
-		return deletedMeeting, nil
+		return updatedMeeting, nil
+	}
+	metrics.PgErrCount.WithLabelValues("SetMeetingStatus").Inc()
+
+	return models.Meeting{}, fmt.Errorf("set status of meeting %d faild: %w", id, err)
+}
+
+// meetingConflict builds MeetingConflictError for meeting. When id refers to an existing
+// meeting, the fields missing in meeting are taken from it and the meeting itself is skipped.
+func (s *Store) meetingConflict(ctx context.Context, id int, meeting models.MeetingRequest) error {
+	var meetings []models.Meeting
+	query := `
+SELECT ` + meetingColumns + ` FROM meetings
+WHERE id IN (
+    SELECT m.id FROM meetings m
+    LEFT JOIN meetings cur ON cur.id = $1
+    WHERE m.id <> $1 AND m.` + activeMeeting + `
+    AND (m.manager = COALESCE($2::int, cur.manager) OR EXISTS (
+        SELECT 1 FROM meeting_participants p
+        WHERE p.meeting_id = m.id AND p.status = 'joined' AND p.client = COALESCE($3::int, cur.client)
+    ))
+    AND tstzrange(m.start_at, m.end_at) && tstzrange(COALESCE($4::timestamptz, cur.start_at), COALESCE($5::timestamptz, cur.end_at))
+)
+ORDER BY start_at;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.SelectContext(ctx, &meetings, query, id, meeting.Manager, meeting.Client, meeting.StartTime, meeting.EndTime); err != nil {
+			continue
+		}
+		return &MeetingConflictError{Meetings: meetings}
 	}
-	metrics.PgErrCount.WithLabelValues("DeleteMeeting").Inc()
+	metrics.PgErrCount.WithLabelValues("meetingConflict").Inc()
+	s.log.Warnf("get conflicting meetings faild: %v", err)
+
+	return &MeetingConflictError{}
+}
 
-	return models.Meeting{}, fmt.Errorf("delete meeting %d faild: %w", id, err)
+func isExclusionViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == exclusionViolation
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
 }
 
 func (s *Store) UsersWithMeetings(ctx context.Context) ([]models.UserNotify, error) {
@@ -450,10 +782,12 @@ func (s *Store) UsersWithMeetings(ctx context.Context) ([]models.UserNotify, err
 
 	var result []models.UserNotify
 	query := `
-SELECT users.id AS user_id, m.id AS meeting_id, notified, last_name, first_name, start_at FROM users
-JOIN meetings m on users.id = m.client
-WHERE now() < start_at + users.notification
-AND NOT notified`
+SELECT users.id AS user_id, m.id AS meeting_id, notified, last_name, first_name, time_zone, m.start_at FROM users
+JOIN meeting_participants p on users.id = p.client AND p.status = 'joined'
+JOIN meetings m on m.id = p.meeting_id
+WHERE now() < m.start_at + users.notification
+AND NOT notified
+AND m.` + activeMeeting
 	var err error
 	for i := 0; i < retries; i++ {
 		if err = s.db.SelectContext(ctx, &result, query); err != nil {
@@ -493,7 +827,10 @@ RETURNING TRUE;`
 }
 
 func (s *Store) ResetTables(ctx context.Context, tables []string) error {
-	_, err := s.db.ExecContext(ctx, `TRUNCATE TABLE`+` `+strings.Join(tables, `, `))
+	_, err := s.db.ExecContext(ctx, `TRUNCATE TABLE`+` `+strings.Join(tables, `, `)+` `+`CASCADE`)
+	if err != nil {
+		return err
+	}
 	for _, table := range tables {
 		_, err = s.db.ExecContext(ctx, fmt.Sprintf(`ALTER SEQUENCE %s_id_seq RESTART`, table))
 		if err != nil {
@@ -0,0 +1,145 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/ratelimit"
+)
+
+// RateLimitStore keeps the counters of ratelimit.Limiter in Postgres, so the replicas of the service share them.
+type RateLimitStore struct {
+	s *Store
+}
+
+func (s *Store) RateLimitStore() *RateLimitStore {
+	return &RateLimitStore{s: s}
+}
+
+func (r *RateLimitStore) Incr(ctx context.Context, key string, now time.Time, ttl time.Duration) (ratelimit.Counter, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("IncrRateLimit").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+INSERT INTO rate_limits (key, count, updated_at, expires_at)
+VALUES ($1, 1, $2, $3)
+ON CONFLICT (key) DO UPDATE SET
+    count      = CASE WHEN rate_limits.expires_at <= $2 THEN 1 ELSE rate_limits.count + 1 END,
+    expires_at = CASE WHEN rate_limits.expires_at <= $2 THEN $3 ELSE rate_limits.expires_at END,
+    updated_at = $2
+RETURNING count, updated_at, expires_at;`
+	var counter ratelimit.Counter
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = r.s.db.GetContext(ctx, &counter, query, key, now, now.Add(ttl)); err != nil {
+			continue
+		}
+		return counter, nil
+	}
+	metrics.PgErrCount.WithLabelValues("IncrRateLimit").Inc()
+
+	return ratelimit.Counter{}, fmt.Errorf("increment rate limit %s faild: %w", key, err)
+}
+
+func (r *RateLimitStore) Get(ctx context.Context, key string, now time.Time) (ratelimit.Counter, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetRateLimit").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+SELECT count, updated_at, expires_at FROM rate_limits
+WHERE key = $1 AND expires_at > $2;`
+	var counter ratelimit.Counter
+	var err error
+	for i := 0; i < retries; i++ {
+		err = r.s.db.GetContext(ctx, &counter, query, key, now)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ratelimit.Counter{}, nil
+		case err != nil:
+			continue
+		}
+		return counter, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetRateLimit").Inc()
+
+	return ratelimit.Counter{}, fmt.Errorf("get rate limit %s faild: %w", key, err)
+}
+
+func (r *RateLimitStore) Delete(ctx context.Context, key string) error {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("DeleteRateLimit").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `DELETE FROM rate_limits WHERE key = $1;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if _, err = r.s.db.ExecContext(ctx, query, key); err != nil {
+			continue
+		}
+		return nil
+	}
+	metrics.PgErrCount.WithLabelValues("DeleteRateLimit").Inc()
+
+	return fmt.Errorf("delete rate limit %s faild: %w", key, err)
+}
+
+// DeleteExpiredRateLimits deletes the rate limit counters which expired before now.
+func (s *Store) DeleteExpiredRateLimits(ctx context.Context, now time.Time) (int64, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("DeleteExpiredRateLimits").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+DELETE FROM rate_limits
+WHERE expires_at <= $1;`
+	var (
+		res sql.Result
+		err error
+	)
+	for i := 0; i < retries; i++ {
+		res, err = s.db.ExecContext(ctx, query, now)
+		if err != nil {
+			continue
+		}
+		deleted, _ := res.RowsAffected()
+		return deleted, nil
+	}
+	metrics.PgErrCount.WithLabelValues("DeleteExpiredRateLimits").Inc()
+
+	return 0, fmt.Errorf("delete expired rate limits faild: %w", err)
+}
+
+// CreateLoginFailure saves the audit record of a failed login. The user is found by the phone.
+func (s *Store) CreateLoginFailure(ctx context.Context, failure models.LoginFailure) (models.LoginFailure, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("CreateLoginFailure").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+INSERT INTO login_failures (phone, user_id, ip, user_agent, locked_until)
+VALUES ($1, (SELECT id FROM users WHERE phone = $1 AND NOT deleted), $2, $3, $4)
+RETURNING id, phone, user_id, ip, user_agent, locked_until, created_at;`
+	var created models.LoginFailure
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.GetContext(ctx, &created, query, failure.Phone, failure.IP, failure.UserAgent, failure.LockedUntil); err != nil {
+			continue
+		}
+		return created, nil
+	}
+	metrics.PgErrCount.WithLabelValues("CreateLoginFailure").Inc()
+
+	return models.LoginFailure{}, fmt.Errorf("create login failure faild: %w", err)
+}
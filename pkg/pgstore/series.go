@@ -0,0 +1,300 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+const seriesColumns = `id, manager, client, start_at, end_at, time_zone, rule, updated_at, created_at`
+
+// CreateSeries stores the series together with its occurrences.
+func (s *Store) CreateSeries(ctx context.Context, series models.Series, occurrences []models.Interval) (models.Series, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("CreateSeries").Observe(time.Since(started).Seconds())
+	}()
+
+	var createdSeries models.Series
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		id, err := insertSeries(ctx, tx, series, occurrences)
+		if err != nil {
+			return err
+		}
+		createdSeries, err = getSeries(ctx, tx, id)
+		return err
+	})
+	switch {
+	case errors.Is(err, models.ErrInsufficientCredits):
+		return models.Series{}, err
+	case isExclusionViolation(err):
+		return models.Series{}, s.seriesConflict(ctx, 0, series, occurrences)
+	case err != nil:
+		metrics.PgErrCount.WithLabelValues("CreateSeries").Inc()
+		return models.Series{}, fmt.Errorf("create series faild: %w", err)
+	}
+	return createdSeries, nil
+}
+
+func (s *Store) GetSeries(ctx context.Context, id int) (models.Series, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetSeries").Observe(time.Since(started).Seconds())
+	}()
+
+	var series models.Series
+	var err error
+	for i := 0; i < retries; i++ {
+		series, err = getSeries(ctx, s.db, id)
+		switch {
+		case errors.Is(err, ErrSeriesNotFound):
+			return models.Series{}, err
+		case err != nil:
+			continue
+		}
+		return series, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetSeries").Inc()
+
+	return models.Series{}, fmt.Errorf("get series %d faild: %w", id, err)
+}
+
+// UpdateSeries updates the series and replaces its occurrences which originally start at or after from.
+// The replaced occurrences are cancelled by the user cancelledBy.
+func (s *Store) UpdateSeries(ctx context.Context, series models.Series, from time.Time, occurrences []models.Interval, cancelledBy int) (models.Series, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("UpdateSeries").Observe(time.Since(started).Seconds())
+	}()
+
+	var updatedSeries models.Series
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+UPDATE meeting_series
+SET manager = $2, client = $3, start_at = $4, end_at = $5, time_zone = $6, rule = $7, updated_at = NOW()
+WHERE id = $1;`, series.ID, series.Manager, series.Client, series.StartTime, series.EndTime, series.TimeZone, series.Rule); err != nil {
+			return err
+		}
+		if err := cancelOccurrences(ctx, tx, series.ID, from, cancelledBy); err != nil {
+			return err
+		}
+		if err := insertOccurrences(ctx, tx, series, occurrences); err != nil {
+			return err
+		}
+		var err error
+		updatedSeries, err = getSeries(ctx, tx, series.ID)
+		return err
+	})
+	switch {
+	case errors.Is(err, models.ErrInsufficientCredits):
+		return models.Series{}, err
+	case isExclusionViolation(err):
+		return models.Series{}, s.seriesConflict(ctx, series.ID, series, occurrences)
+	case err != nil:
+		metrics.PgErrCount.WithLabelValues("UpdateSeries").Inc()
+		return models.Series{}, fmt.Errorf("update series %d faild: %w", series.ID, err)
+	}
+	return updatedSeries, nil
+}
+
+// SplitSeries ends the series with rule before from and continues it with the next series. The occurrences
+// of the series from then on are cancelled by the user cancelledBy.
+func (s *Store) SplitSeries(ctx context.Context, id int, rule string, from time.Time, next models.Series, occurrences []models.Interval, cancelledBy int) (models.Series, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("SplitSeries").Observe(time.Since(started).Seconds())
+	}()
+
+	var nextSeries models.Series
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := truncateSeries(ctx, tx, id, rule); err != nil {
+			return err
+		}
+		if err := cancelOccurrences(ctx, tx, id, from, cancelledBy); err != nil {
+			return err
+		}
+		nextID, err := insertSeries(ctx, tx, next, occurrences)
+		if err != nil {
+			return err
+		}
+		nextSeries, err = getSeries(ctx, tx, nextID)
+		return err
+	})
+	switch {
+	case errors.Is(err, models.ErrInsufficientCredits):
+		return models.Series{}, err
+	case isExclusionViolation(err):
+		return models.Series{}, s.seriesConflict(ctx, id, next, occurrences)
+	case err != nil:
+		metrics.PgErrCount.WithLabelValues("SplitSeries").Inc()
+		return models.Series{}, fmt.Errorf("split series %d faild: %w", id, err)
+	}
+	return nextSeries, nil
+}
+
+// TruncateSeries replaces the rule of the series and cancels its occurrences which originally start at or after from
+// by the user cancelledBy.
+func (s *Store) TruncateSeries(ctx context.Context, id int, rule string, from time.Time, cancelledBy int) (models.Series, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("TruncateSeries").Observe(time.Since(started).Seconds())
+	}()
+
+	var series models.Series
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := truncateSeries(ctx, tx, id, rule); err != nil {
+			return err
+		}
+		if err := cancelOccurrences(ctx, tx, id, from, cancelledBy); err != nil {
+			return err
+		}
+		var err error
+		series, err = getSeries(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		metrics.PgErrCount.WithLabelValues("TruncateSeries").Inc()
+		return models.Series{}, fmt.Errorf("truncate series %d faild: %w", id, err)
+	}
+	return series, nil
+}
+
+func (s *Store) inTx(ctx context.Context, f func(tx *sqlx.Tx) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("open transaction faild: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.log.Warnf("rollback transaction faild: %v", err)
+		}
+	}()
+	if err = f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// seriesConflict looks for meetings which overlap the occurrences of the series. Meetings of the
+// series with id excludeSeries are ignored since they are replaced by the occurrences.
+func (s *Store) seriesConflict(ctx context.Context, excludeSeries int, series models.Series, occurrences []models.Interval) error {
+	query := `
+SELECT ` + meetingColumns + ` FROM meetings
+WHERE (manager = $1 OR client = $2)
+AND series_id IS DISTINCT FROM $3
+AND ` + activeMeeting + `
+AND tstzrange(start_at, end_at) && tstzrange($4, $5)
+ORDER BY start_at;`
+	seen := make(map[int]bool)
+	var result []models.Meeting
+	for _, occurrence := range occurrences {
+		var meetings []models.Meeting
+		if err := s.db.SelectContext(ctx, &meetings, query, series.Manager, series.Client, excludeSeries, occurrence.Start, occurrence.End); err != nil {
+			metrics.PgErrCount.WithLabelValues("seriesConflict").Inc()
+			s.log.Warnf("get conflicting meetings faild: %v", err)
+			return &MeetingConflictError{Meetings: result}
+		}
+		for _, meeting := range meetings {
+			if !seen[meeting.ID] {
+				seen[meeting.ID] = true
+				result = append(result, meeting)
+			}
+		}
+	}
+	return &MeetingConflictError{Meetings: result}
+}
+
+func getSeries(ctx context.Context, q sqlx.QueryerContext, id int) (models.Series, error) {
+	series := models.Series{Meetings: []models.Meeting{}}
+	err := sqlx.GetContext(ctx, q, &series, `
+SELECT `+seriesColumns+` FROM meeting_series
+WHERE id = $1;`, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return models.Series{}, ErrSeriesNotFound
+	case err != nil:
+		return models.Series{}, err
+	}
+	if err = sqlx.SelectContext(ctx, q, &series.Meetings, `
+SELECT `+meetingColumns+` FROM meetings
+WHERE series_id = $1 AND status <> 'cancelled'
+ORDER BY start_at;`, id); err != nil {
+		return models.Series{}, err
+	}
+	return series, nil
+}
+
+func insertSeries(ctx context.Context, tx *sqlx.Tx, series models.Series, occurrences []models.Interval) (int, error) {
+	if err := tx.GetContext(ctx, &series.ID, `
+INSERT INTO meeting_series (manager, client, start_at, end_at, time_zone, rule)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id;`, series.Manager, series.Client, series.StartTime, series.EndTime, series.TimeZone, series.Rule); err != nil {
+		return 0, err
+	}
+	if err := insertOccurrences(ctx, tx, series, occurrences); err != nil {
+		return 0, err
+	}
+	return series.ID, nil
+}
+
+// insertOccurrences creates meetings of the series skipping cancelled occurrences. A credit of the client
+// is spent on every meeting.
+func insertOccurrences(ctx context.Context, tx *sqlx.Tx, series models.Series, occurrences []models.Interval) error {
+	query := `
+INSERT INTO meetings (manager, start_at, end_at, client, series_id, original_start_at)
+SELECT $1::int, $2::timestamptz, $3::timestamptz, $4::int, $5::int, $2::timestamptz
+WHERE NOT EXISTS (
+    SELECT 1 FROM meeting_series_exceptions
+    WHERE series_id = $5 AND original_start_at = $2
+)
+RETURNING id;`
+	for _, occurrence := range occurrences {
+		meeting := models.Meeting{Manager: series.Manager, Client: series.Client}
+		err := tx.GetContext(ctx, &meeting.ID, query, series.Manager, occurrence.Start, occurrence.End, series.Client, series.ID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			continue
+		case err != nil:
+			return err
+		}
+		if err = consumeCredit(ctx, tx, meeting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func truncateSeries(ctx context.Context, tx *sqlx.Tx, id int, rule string) error {
+	result, err := tx.ExecContext(ctx, `
+UPDATE meeting_series
+SET rule = $2, updated_at = NOW()
+WHERE id = $1;`, id, rule)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrSeriesNotFound
+	}
+	return nil
+}
+
+// cancelOccurrences cancels active occurrences which originally start at or after from, the way the coach
+// cancels a meeting: nothing is late and the credits spent on them are refunded. No exceptions are recorded,
+// the occurrences are either replaced or dropped from the rule.
+func cancelOccurrences(ctx context.Context, tx *sqlx.Tx, id int, from time.Time, cancelledBy int) error {
+	var ids []int
+	if err := tx.SelectContext(ctx, &ids, `
+UPDATE meetings
+SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $3, late_cancellation = FALSE, updated_at = NOW()
+WHERE series_id = $1 AND original_start_at >= $2 AND `+activeMeeting+`
+RETURNING id;`, id, from, cancelledBy); err != nil {
+		return err
+	}
+	return refundCredits(ctx, tx, ids, nil)
+}
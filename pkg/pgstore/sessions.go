@@ -0,0 +1,208 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *Store) CreateSession(ctx context.Context, session models.Session, token models.RefreshToken) (models.Session, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("CreateSession").Observe(time.Since(started).Seconds())
+	}()
+
+	var newSession models.Session
+	query := `
+INSERT INTO auth_sessions (id, user_id)
+VALUES ($1, $2)
+RETURNING id, user_id, revoked_at, created_at;`
+	var err error
+	for i := 0; i < retries; i++ {
+		err = s.inTx(ctx, func(tx *sqlx.Tx) error {
+			if err := tx.GetContext(ctx, &newSession, query, session.ID, session.UserID); err != nil {
+				return err
+			}
+			token.SessionID = newSession.ID
+			return insertRefreshToken(ctx, tx, token)
+		})
+		if err != nil {
+			continue
+		}
+		return newSession, nil
+	}
+	metrics.PgErrCount.WithLabelValues("CreateSession").Inc()
+
+	return models.Session{}, fmt.Errorf("create session of user %d faild: %w", session.UserID, err)
+}
+
+func (s *Store) GetSession(ctx context.Context, id string) (models.Session, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetSession").Observe(time.Since(started).Seconds())
+	}()
+
+	var session models.Session
+	query := `
+SELECT id, user_id, revoked_at, created_at FROM auth_sessions
+WHERE id = $1;`
+	var err error
+	for i := 0; i < retries; i++ {
+		err = s.db.GetContext(ctx, &session, query, id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return models.Session{}, ErrSessionNotFound
+		case err != nil:
+			continue
+		}
+		return session, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetSession").Inc()
+
+	return models.Session{}, fmt.Errorf("get session %s faild: %w", id, err)
+}
+
+// RotateRefreshToken replaces the refresh token with hash by next and returns the session they belong to.
+// A refresh token which was already rotated is being reused, probably after it leaked, so the session is revoked.
+func (s *Store) RotateRefreshToken(ctx context.Context, hash string, next models.RefreshToken) (models.Session, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("RotateRefreshToken").Observe(time.Since(started).Seconds())
+	}()
+
+	var (
+		session models.Session
+		reused  bool
+	)
+	tokenQuery := `
+SELECT token_hash, session_id, expires_at, rotated_at FROM refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE;`
+	sessionQuery := `
+SELECT id, user_id, revoked_at, created_at FROM auth_sessions
+WHERE id = $1
+FOR UPDATE;`
+	rotateQuery := `
+UPDATE refresh_tokens SET rotated_at = NOW()
+WHERE token_hash = $1;`
+	var err error
+	for i := 0; i < retries; i++ {
+		reused = false
+		err = s.inTx(ctx, func(tx *sqlx.Tx) error {
+			var token models.RefreshToken
+			if err := tx.GetContext(ctx, &token, tokenQuery, hash); err != nil {
+				return err
+			}
+			if err := tx.GetContext(ctx, &session, sessionQuery, token.SessionID); err != nil {
+				return err
+			}
+			switch {
+			case session.RevokedAt != nil:
+				return models.ErrSessionRevoked
+			case token.RotatedAt != nil:
+				reused = true
+				return revokeSession(ctx, tx, session.ID)
+			case !token.ExpiresAt.After(time.Now()):
+				return models.ErrInvalidRefreshToken
+			}
+			if _, err := tx.ExecContext(ctx, rotateQuery, hash); err != nil {
+				return err
+			}
+			next.SessionID = session.ID
+			return insertRefreshToken(ctx, tx, next)
+		})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return models.Session{}, models.ErrInvalidRefreshToken
+		case errors.Is(err, models.ErrSessionRevoked), errors.Is(err, models.ErrInvalidRefreshToken):
+			return models.Session{}, err
+		case err != nil:
+			continue
+		case reused:
+			return models.Session{}, models.ErrSessionRevoked
+		}
+		return session, nil
+	}
+	metrics.PgErrCount.WithLabelValues("RotateRefreshToken").Inc()
+
+	return models.Session{}, fmt.Errorf("rotate refresh token faild: %w", err)
+}
+
+func (s *Store) RevokeSession(ctx context.Context, id string) error {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("RevokeSession").Observe(time.Since(started).Seconds())
+	}()
+
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = revokeSession(ctx, s.db, id); err != nil {
+			continue
+		}
+		return nil
+	}
+	metrics.PgErrCount.WithLabelValues("RevokeSession").Inc()
+
+	return fmt.Errorf("revoke session %s faild: %w", id, err)
+}
+
+// DeleteExpiredSessions deletes the refresh tokens which expired before now and the sessions they were the last
+// tokens of. Rotated tokens are kept until they expire, so their reuse is still detected. It returns how many
+// sessions were deleted.
+func (s *Store) DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("DeleteExpiredSessions").Observe(time.Since(started).Seconds())
+	}()
+
+	sessionsQuery := `
+DELETE FROM auth_sessions s
+WHERE NOT EXISTS (SELECT 1 FROM refresh_tokens t WHERE t.session_id = s.id AND t.expires_at > $1);`
+	tokensQuery := `
+DELETE FROM refresh_tokens
+WHERE expires_at <= $1;`
+	var (
+		deleted int64
+		err     error
+	)
+	for i := 0; i < retries; i++ {
+		err = s.inTx(ctx, func(tx *sqlx.Tx) error {
+			res, err := tx.ExecContext(ctx, sessionsQuery, now)
+			if err != nil {
+				return err
+			}
+			deleted, _ = res.RowsAffected()
+			_, err = tx.ExecContext(ctx, tokensQuery, now)
+			return err
+		})
+		if err != nil {
+			continue
+		}
+		return deleted, nil
+	}
+	metrics.PgErrCount.WithLabelValues("DeleteExpiredSessions").Inc()
+
+	return 0, fmt.Errorf("delete expired sessions faild: %w", err)
+}
+
+func insertRefreshToken(ctx context.Context, tx *sqlx.Tx, token models.RefreshToken) error {
+	query := `
+INSERT INTO refresh_tokens (token_hash, session_id, expires_at)
+VALUES ($1, $2, $3);`
+	_, err := tx.ExecContext(ctx, query, token.Hash, token.SessionID, token.ExpiresAt)
+	return err
+}
+
+func revokeSession(ctx context.Context, db sqlx.ExecerContext, id string) error {
+	query := `
+UPDATE auth_sessions SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;`
+	_, err := db.ExecContext(ctx, query, id)
+	return err
+}
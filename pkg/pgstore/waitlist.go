@@ -0,0 +1,242 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+const waitlistColumns = `id, coach, client, start_at, end_at, status, offered_at, hold_until, updated_at, created_at`
+
+// JoinWaitlist puts the client in the queue for the slot. The slot must be taken by an active meeting of the coach.
+func (s *Store) JoinWaitlist(ctx context.Context, entry models.WaitlistEntry) (models.WaitlistEntry, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("JoinWaitlist").Observe(time.Since(started).Seconds())
+	}()
+
+	var newEntry models.WaitlistEntry
+	query := `
+INSERT INTO waitlist_entries (coach, client, start_at, end_at)
+SELECT $1::int, $2::int, $3::timestamptz, $4::timestamptz
+WHERE EXISTS (
+    SELECT 1 FROM meetings
+    WHERE manager = $1 AND ` + activeMeeting + `
+    AND tstzrange(start_at, end_at) && tstzrange($3::timestamptz, $4::timestamptz)
+)
+RETURNING ` + waitlistColumns + `;`
+	var err error
+	for i := 0; i < retries; i++ {
+		err = s.db.GetContext(ctx, &newEntry, query, entry.CoachID, entry.ClientID, entry.StartTime, entry.EndTime)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return models.WaitlistEntry{}, models.ErrSlotAvailable
+		case isUniqueViolation(err):
+			return models.WaitlistEntry{}, models.ErrAlreadyWaitlisted
+		case err != nil:
+			continue
+		}
+		return newEntry, nil
+	}
+	metrics.PgErrCount.WithLabelValues("JoinWaitlist").Inc()
+
+	return models.WaitlistEntry{}, fmt.Errorf("join waitlist faild: %w", err)
+}
+
+func (s *Store) GetWaitlist(ctx context.Context, client int) ([]models.WaitlistEntry, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetWaitlist").Observe(time.Since(started).Seconds())
+	}()
+
+	entries := []models.WaitlistEntry{}
+	query := `
+SELECT ` + waitlistColumns + ` FROM waitlist_entries
+WHERE client = $1
+ORDER BY start_at, id;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.SelectContext(ctx, &entries, query, client); err != nil {
+			continue
+		}
+		return entries, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetWaitlist").Inc()
+
+	return nil, fmt.Errorf("get waitlist of client %d faild: %w", client, err)
+}
+
+func (s *Store) GetWaitlistEntry(ctx context.Context, id int) (models.WaitlistEntry, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetWaitlistEntry").Observe(time.Since(started).Seconds())
+	}()
+
+	var entry models.WaitlistEntry
+	query := `
+SELECT ` + waitlistColumns + ` FROM waitlist_entries
+WHERE id = $1;`
+	var err error
+	for i := 0; i < retries; i++ {
+		err = s.db.GetContext(ctx, &entry, query, id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return models.WaitlistEntry{}, ErrWaitlistEntryNotFound
+		case err != nil:
+			continue
+		}
+		return entry, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetWaitlistEntry").Inc()
+
+	return models.WaitlistEntry{}, fmt.Errorf("get waitlist entry %d faild: %w", id, err)
+}
+
+// SetWaitlistStatus moves the entry from one of the statuses from to status to.
+func (s *Store) SetWaitlistStatus(ctx context.Context, id int, from []string, to string) (models.WaitlistEntry, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("SetWaitlistStatus").Observe(time.Since(started).Seconds())
+	}()
+
+	var updatedEntry models.WaitlistEntry
+	query := `
+UPDATE waitlist_entries
+SET status = $3, updated_at = NOW()
+WHERE id = $1 AND status = ANY($2)
+RETURNING ` + waitlistColumns + `;`
+	var err error
+	for i := 0; i < retries; i++ {
+		err = s.db.GetContext(ctx, &updatedEntry, query, id, from, to)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return models.WaitlistEntry{}, models.ErrWaitlistEntryClosed
+		case err != nil:
+			continue
+		}
+		return updatedEntry, nil
+	}
+	metrics.PgErrCount.WithLabelValues("SetWaitlistStatus").Inc()
+
+	return models.WaitlistEntry{}, fmt.Errorf("set status of waitlist entry %d faild: %w", id, err)
+}
+
+// AcceptWaitlistOffer books the offered slot for the client while the hold is active.
+func (s *Store) AcceptWaitlistOffer(ctx context.Context, id int) (models.Meeting, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("AcceptWaitlistOffer").Observe(time.Since(started).Seconds())
+	}()
+
+	var entry models.WaitlistEntry
+	var meeting models.Meeting
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &entry, `
+UPDATE waitlist_entries
+SET status = 'accepted', updated_at = NOW()
+WHERE id = $1 AND status = 'offered' AND hold_until >= NOW()
+RETURNING `+waitlistColumns+`;`, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrWaitlistEntryClosed
+		}
+		if err != nil {
+			return err
+		}
+		if err = tx.GetContext(ctx, &meeting, `
+INSERT INTO meetings (manager, start_at, end_at, client)
+VALUES ($1, $2, $3, $4)
+RETURNING `+meetingColumns+`;`, entry.CoachID, entry.StartTime, entry.EndTime, entry.ClientID); err != nil {
+			return err
+		}
+		return consumeCredit(ctx, tx, meeting)
+	})
+	switch {
+	case errors.Is(err, models.ErrWaitlistEntryClosed), errors.Is(err, models.ErrInsufficientCredits):
+		return models.Meeting{}, err
+	case isExclusionViolation(err):
+		return models.Meeting{}, s.meetingConflict(ctx, 0, models.MeetingRequest{
+			Manager:   &entry.CoachID,
+			StartTime: &entry.StartTime,
+			EndTime:   &entry.EndTime,
+			Client:    &entry.ClientID,
+		})
+	case err != nil:
+		metrics.PgErrCount.WithLabelValues("AcceptWaitlistOffer").Inc()
+		return models.Meeting{}, fmt.Errorf("accept waitlist offer %d faild: %w", id, err)
+	}
+	return meeting, nil
+}
+
+// OfferWaitlistSlots expires stale entries and offers every slot which became free to the first
+// waiting client. The offers are held for hold and returned, so the clients can be notified.
+func (s *Store) OfferWaitlistSlots(ctx context.Context, hold time.Duration) ([]models.WaitlistEntry, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("OfferWaitlistSlots").Observe(time.Since(started).Seconds())
+	}()
+
+	offers := []models.WaitlistEntry{}
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+UPDATE waitlist_entries
+SET status = 'expired', updated_at = NOW()
+WHERE (status = 'offered' AND hold_until < NOW())
+OR (status IN ('waiting', 'offered') AND start_at <= NOW());`); err != nil {
+			return err
+		}
+		return tx.SelectContext(ctx, &offers, `
+UPDATE waitlist_entries
+SET status = 'offered', offered_at = NOW(), hold_until = NOW() + $1::int * INTERVAL '1 second', updated_at = NOW()
+WHERE id IN (
+    SELECT DISTINCT ON (coach, start_at, end_at) id FROM waitlist_entries e
+    WHERE status = 'waiting'
+    AND NOT EXISTS (
+        SELECT 1 FROM waitlist_entries o
+        WHERE o.status = 'offered' AND o.coach = e.coach AND o.start_at = e.start_at AND o.end_at = e.end_at
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM meetings m
+        WHERE m.manager = e.coach AND m.`+activeMeeting+`
+        AND tstzrange(m.start_at, m.end_at) && tstzrange(e.start_at, e.end_at)
+    )
+    ORDER BY coach, start_at, end_at, id
+)
+RETURNING `+waitlistColumns+`;`, int(hold.Seconds()))
+	})
+	if err != nil {
+		metrics.PgErrCount.WithLabelValues("OfferWaitlistSlots").Inc()
+		return nil, fmt.Errorf("offer waitlist slots faild: %w", err)
+	}
+	return offers, nil
+}
+
+// GetWaitlistHolds returns active offers of the coach which overlap [from, to).
+func (s *Store) GetWaitlistHolds(ctx context.Context, coach int, from, to time.Time) ([]models.WaitlistEntry, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetWaitlistHolds").Observe(time.Since(started).Seconds())
+	}()
+
+	var holds []models.WaitlistEntry
+	query := `
+SELECT ` + waitlistColumns + ` FROM waitlist_entries
+WHERE coach = $1 AND status = 'offered' AND hold_until >= NOW()
+AND tstzrange(start_at, end_at) && tstzrange($2, $3)
+ORDER BY start_at;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.SelectContext(ctx, &holds, query, coach, from, to); err != nil {
+			continue
+		}
+		return holds, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetWaitlistHolds").Inc()
+
+	return nil, fmt.Errorf("get waitlist holds of coach %d faild: %w", coach, err)
+}
@@ -0,0 +1,345 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pershin-daniil/TimeSlots/pkg/metrics"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// webhookColumns leave out the secret, it is only returned when the webhook is created.
+const (
+	webhookColumns  = `id, coach, url, active, created_at, updated_at`
+	deliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, last_error, delivered_at, redelivery_of, created_at, updated_at`
+)
+
+func (s *Store) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("CreateWebhook").Observe(time.Since(started).Seconds())
+	}()
+
+	var newWebhook models.Webhook
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &newWebhook, `
+INSERT INTO webhooks (coach, url, secret, active)
+VALUES ($1, $2, $3, $4)
+RETURNING `+webhookColumns+`, secret;`, webhook.CoachID, webhook.URL, webhook.Secret, webhook.Active); err != nil {
+			return err
+		}
+		if err := setWebhookEvents(ctx, tx, newWebhook.ID, webhook.Events); err != nil {
+			return err
+		}
+		var err error
+		newWebhook.Events, err = getWebhookEvents(ctx, tx, newWebhook.ID)
+		return err
+	})
+	if err != nil {
+		metrics.PgErrCount.WithLabelValues("CreateWebhook").Inc()
+		return models.Webhook{}, fmt.Errorf("create webhook faild: %w", err)
+	}
+	return newWebhook, nil
+}
+
+func (s *Store) GetWebhooks(ctx context.Context, coach int) ([]models.Webhook, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetWebhooks").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+SELECT ` + webhookColumns + ` FROM webhooks
+WHERE coach = $1
+ORDER BY id;`
+	var err error
+	for i := 0; i < retries; i++ {
+		webhooks := []models.Webhook{}
+		if err = s.db.SelectContext(ctx, &webhooks, query, coach); err != nil {
+			continue
+		}
+		for j := range webhooks {
+			if webhooks[j].Events, err = getWebhookEvents(ctx, s.db, webhooks[j].ID); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			continue
+		}
+		return webhooks, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetWebhooks").Inc()
+
+	return nil, fmt.Errorf("get webhooks of coach %d faild: %w", coach, err)
+}
+
+func (s *Store) GetWebhook(ctx context.Context, coach, id int) (models.Webhook, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetWebhook").Observe(time.Since(started).Seconds())
+	}()
+
+	var err error
+	for i := 0; i < retries; i++ {
+		var webhook models.Webhook
+		webhook, err = getWebhook(ctx, s.db, coach, id)
+		switch {
+		case errors.Is(err, models.ErrWebhookNotFound):
+			return models.Webhook{}, err
+		case err != nil:
+			continue
+		}
+		return webhook, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetWebhook").Inc()
+
+	return models.Webhook{}, fmt.Errorf("get webhook %d faild: %w", id, err)
+}
+
+// UpdateWebhook changes the fields set in data. Events replace the subscribed events.
+func (s *Store) UpdateWebhook(ctx context.Context, coach, id int, data models.WebhookRequest) (models.Webhook, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("UpdateWebhook").Observe(time.Since(started).Seconds())
+	}()
+
+	var updatedWebhook models.Webhook
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+UPDATE webhooks
+SET url = COALESCE($3, url), active = COALESCE($4, active), updated_at = NOW()
+WHERE coach = $1 AND id = $2;`, coach, id, data.URL, data.Active)
+		if err != nil {
+			return err
+		}
+		if updated, _ := res.RowsAffected(); updated == 0 {
+			return models.ErrWebhookNotFound
+		}
+		if data.Events != nil {
+			if err = setWebhookEvents(ctx, tx, id, data.Events); err != nil {
+				return err
+			}
+		}
+		updatedWebhook, err = getWebhook(ctx, tx, coach, id)
+		return err
+	})
+	switch {
+	case errors.Is(err, models.ErrWebhookNotFound):
+		return models.Webhook{}, err
+	case err != nil:
+		metrics.PgErrCount.WithLabelValues("UpdateWebhook").Inc()
+		return models.Webhook{}, fmt.Errorf("update webhook %d faild: %w", id, err)
+	}
+	return updatedWebhook, nil
+}
+
+// DeleteWebhook deletes the webhook with its delivery log.
+func (s *Store) DeleteWebhook(ctx context.Context, coach, id int) (models.Webhook, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("DeleteWebhook").Observe(time.Since(started).Seconds())
+	}()
+
+	var deletedWebhook models.Webhook
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		if deletedWebhook, err = getWebhook(ctx, tx, coach, id); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM webhooks
+WHERE id = $1;`, id)
+		return err
+	})
+	switch {
+	case errors.Is(err, models.ErrWebhookNotFound):
+		return models.Webhook{}, err
+	case err != nil:
+		metrics.PgErrCount.WithLabelValues("DeleteWebhook").Inc()
+		return models.Webhook{}, fmt.Errorf("delete webhook %d faild: %w", id, err)
+	}
+	return deletedWebhook, nil
+}
+
+// EnqueueWebhookEvent queues the payload for the active webhooks subscribed to the event. With coach set
+// only the webhooks of the coach get it. It returns how many deliveries were queued.
+func (s *Store) EnqueueWebhookEvent(ctx context.Context, event string, coach *int, payload []byte) (int64, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("EnqueueWebhookEvent").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+SELECT w.id, $1, $3 FROM webhooks w
+JOIN webhook_events e ON e.webhook_id = w.id AND e.event = $1
+WHERE w.active AND ($2::int IS NULL OR w.coach = $2);`
+	var (
+		res sql.Result
+		err error
+	)
+	for i := 0; i < retries; i++ {
+		res, err = s.db.ExecContext(ctx, query, event, coach, payload)
+		if err != nil {
+			continue
+		}
+		queued, _ := res.RowsAffected()
+		return queued, nil
+	}
+	metrics.PgErrCount.WithLabelValues("EnqueueWebhookEvent").Inc()
+
+	return 0, fmt.Errorf("enqueue webhook event %s faild: %w", event, err)
+}
+
+// GetWebhookDeliveries returns the latest deliveries of the webhook, newest first.
+func (s *Store) GetWebhookDeliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("GetWebhookDeliveries").Observe(time.Since(started).Seconds())
+	}()
+
+	deliveries := []models.WebhookDelivery{}
+	query := `
+SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = s.db.SelectContext(ctx, &deliveries, query, webhookID, limit); err != nil {
+			continue
+		}
+		return deliveries, nil
+	}
+	metrics.PgErrCount.WithLabelValues("GetWebhookDeliveries").Inc()
+
+	return nil, fmt.Errorf("get deliveries of webhook %d faild: %w", webhookID, err)
+}
+
+// RedeliverWebhookDelivery queues the payload of the delivery again as a new delivery.
+func (s *Store) RedeliverWebhookDelivery(ctx context.Context, webhookID, id int) (models.WebhookDelivery, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("RedeliverWebhookDelivery").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+INSERT INTO webhook_deliveries (webhook_id, event, payload, redelivery_of)
+SELECT webhook_id, event, payload, id FROM webhook_deliveries
+WHERE webhook_id = $1 AND id = $2
+RETURNING ` + deliveryColumns + `;`
+	var err error
+	for i := 0; i < retries; i++ {
+		var delivery models.WebhookDelivery
+		err = s.db.GetContext(ctx, &delivery, query, webhookID, id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return models.WebhookDelivery{}, models.ErrWebhookDeliveryNotFound
+		case err != nil:
+			continue
+		}
+		return delivery, nil
+	}
+	metrics.PgErrCount.WithLabelValues("RedeliverWebhookDelivery").Inc()
+
+	return models.WebhookDelivery{}, fmt.Errorf("redeliver webhook delivery %d faild: %w", id, err)
+}
+
+// ClaimWebhookDeliveries returns up to limit due deliveries of active webhooks. Claimed deliveries are
+// not due again for lease, so several workers don't send the same delivery.
+func (s *Store) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.PendingDelivery, error) {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("ClaimWebhookDeliveries").Observe(time.Since(started).Seconds())
+	}()
+
+	deliveries := []models.PendingDelivery{}
+	query := `
+WITH claimed AS (
+    UPDATE webhook_deliveries
+    SET next_attempt_at = NOW() + $2::int * INTERVAL '1 second'
+    WHERE id IN (
+        SELECT d.id FROM webhook_deliveries d
+        JOIN webhooks w ON w.id = d.webhook_id
+        WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.active
+        ORDER BY d.next_attempt_at, d.id
+        LIMIT $1
+        FOR UPDATE OF d SKIP LOCKED
+    )
+    RETURNING ` + deliveryColumns + `
+)
+SELECT c.*, w.url, w.secret FROM claimed c
+JOIN webhooks w ON w.id = c.webhook_id
+ORDER BY c.id;`
+	if err := s.db.SelectContext(ctx, &deliveries, query, limit, int(lease.Seconds())); err != nil {
+		metrics.PgErrCount.WithLabelValues("ClaimWebhookDeliveries").Inc()
+		return nil, fmt.Errorf("claim webhook deliveries faild: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookAttempt saves the outcome of sending the delivery.
+func (s *Store) RecordWebhookAttempt(ctx context.Context, id int, attempt models.DeliveryAttempt) error {
+	started := time.Now()
+	defer func() {
+		metrics.PgDuration.WithLabelValues("RecordWebhookAttempt").Observe(time.Since(started).Seconds())
+	}()
+
+	query := `
+UPDATE webhook_deliveries
+SET status = $2, attempts = attempts + 1, response_status = $3, last_error = $4, next_attempt_at = $5,
+    delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END, updated_at = NOW()
+WHERE id = $1;`
+	var err error
+	for i := 0; i < retries; i++ {
+		if _, err = s.db.ExecContext(ctx, query,
+			id, attempt.Status, attempt.ResponseStatus, attempt.Error, attempt.NextAttemptAt); err != nil {
+			continue
+		}
+		return nil
+	}
+	metrics.PgErrCount.WithLabelValues("RecordWebhookAttempt").Inc()
+
+	return fmt.Errorf("record attempt of webhook delivery %d faild: %w", id, err)
+}
+
+func getWebhook(ctx context.Context, q sqlx.QueryerContext, coach, id int) (models.Webhook, error) {
+	var webhook models.Webhook
+	err := sqlx.GetContext(ctx, q, &webhook, `
+SELECT `+webhookColumns+` FROM webhooks
+WHERE coach = $1 AND id = $2;`, coach, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return models.Webhook{}, models.ErrWebhookNotFound
+	case err != nil:
+		return models.Webhook{}, err
+	}
+	webhook.Events, err = getWebhookEvents(ctx, q, id)
+	return webhook, err
+}
+
+func getWebhookEvents(ctx context.Context, q sqlx.QueryerContext, id int) ([]string, error) {
+	events := []string{}
+	err := sqlx.SelectContext(ctx, q, &events, `
+SELECT event FROM webhook_events
+WHERE webhook_id = $1
+ORDER BY event;`, id)
+	return events, err
+}
+
+func setWebhookEvents(ctx context.Context, tx *sqlx.Tx, id int, events []string) error {
+	if _, err := tx.ExecContext(ctx, `
+DELETE FROM webhook_events
+WHERE webhook_id = $1;`, id); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+INSERT INTO webhook_events (webhook_id, event)
+SELECT $1, unnest($2::varchar[])
+ON CONFLICT DO NOTHING;`, id, events)
+	return err
+}
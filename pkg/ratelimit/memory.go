@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops expired counters.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps counters in memory. Counters are not shared between instances of the service.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]Counter
+	sweptAt  time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]Counter)}
+}
+
+func (m *MemoryStore) Incr(_ context.Context, key string, now time.Time, ttl time.Duration) (Counter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+	counter, ok := m.counters[key]
+	if !ok || !counter.ExpiresAt.After(now) {
+		counter = Counter{ExpiresAt: now.Add(ttl)}
+	}
+	counter.Count++
+	counter.UpdatedAt = now
+	m.counters[key] = counter
+	return counter, nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, key string, now time.Time) (Counter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counter, ok := m.counters[key]
+	if !ok || !counter.ExpiresAt.After(now) {
+		return Counter{}, nil
+	}
+	return counter, nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.counters, key)
+	return nil
+}
+
+// sweep drops the expired counters at most once per sweepInterval. The caller holds the lock.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.sweptAt) < sweepInterval {
+		return
+	}
+	m.sweptAt = now
+	for key, counter := range m.counters {
+		if !counter.ExpiresAt.After(now) {
+			delete(m.counters, key)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrLimited = errors.New("too many attempts")
+
+// LimitedError refuses an attempt until RetryAfter passes. It matches ErrLimited with errors.Is.
+type LimitedError struct {
+	RetryAfter time.Duration
+	// Locked is set when the attempt is refused because of earlier failures rather than a rate limit.
+	Locked bool
+}
+
+func (e *LimitedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("locked after failed attempts, retry in %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("%v, retry in %s", ErrLimited, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LimitedError) Unwrap() error {
+	return ErrLimited
+}
+
+// Counter counts events until ExpiresAt. UpdatedAt is the time of the last event.
+type Counter struct {
+	Count     int       `db:"count"`
+	UpdatedAt time.Time `db:"updated_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// Store keeps the counters of a Limiter. MemoryStore serves a single instance, replicas need a shared store.
+type Store interface {
+	// Incr adds one to the counter of key at now and returns it. A missing or expired counter starts
+	// again from one and expires ttl after now, later increments don't extend it.
+	Incr(ctx context.Context, key string, now time.Time, ttl time.Duration) (Counter, error)
+	// Get returns the counter of key, the zero Counter if it is missing or expired at now.
+	Get(ctx context.Context, key string, now time.Time) (Counter, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Config sets the limits of login attempts.
+type Config struct {
+	// IPLimit attempts are allowed from an address per IPWindow.
+	IPLimit  int
+	IPWindow time.Duration
+	// PhoneLimit attempts are allowed for a phone per PhoneWindow.
+	PhoneLimit  int
+	PhoneWindow time.Duration
+	// MaxFailures failed attempts lock the phone for Lockout. Every further failure doubles the lockout
+	// up to MaxLockout. Failures are forgotten after a success or FailureTTL after the first one.
+	MaxFailures int
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+	FailureTTL  time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		IPLimit:     30,
+		IPWindow:    time.Minute,
+		PhoneLimit:  10,
+		PhoneWindow: 15 * time.Minute,
+		MaxFailures: 5,
+		Lockout:     time.Minute,
+		MaxLockout:  time.Hour,
+		FailureTTL:  24 * time.Hour,
+	}
+}
+
+// Limiter limits attempts per address and per phone and locks phones out after repeated failures.
+type Limiter struct {
+	store  Store
+	config Config
+	now    func() time.Time
+}
+
+func New(store Store, config Config) *Limiter {
+	return &Limiter{store: store, config: config, now: time.Now}
+}
+
+// Allow counts an attempt from ip to log in as phone. It fails with *LimitedError when the phone is locked
+// or the attempt is over a limit, refused attempts count as well.
+func (l *Limiter) Allow(ctx context.Context, ip, phone string) error {
+	now := l.now()
+	failures, err := l.store.Get(ctx, failuresKey(phone), now)
+	if err != nil {
+		return fmt.Errorf("err getting failures: %w", err)
+	}
+	if until := failures.UpdatedAt.Add(l.lockout(failures.Count)); until.After(now) {
+		return &LimitedError{RetryAfter: until.Sub(now), Locked: true}
+	}
+	for _, limit := range []struct {
+		key    string
+		max    int
+		window time.Duration
+	}{
+		{"ip:" + ip, l.config.IPLimit, l.config.IPWindow},
+		{"phone:" + phone, l.config.PhoneLimit, l.config.PhoneWindow},
+	} {
+		if limit.max <= 0 {
+			continue
+		}
+		counter, err := l.store.Incr(ctx, limit.key, now, limit.window)
+		if err != nil {
+			return fmt.Errorf("err counting attempts: %w", err)
+		}
+		if counter.Count > limit.max {
+			return &LimitedError{RetryAfter: counter.ExpiresAt.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// Fail records a failed attempt to log in as phone. It returns how long the phone is locked for now, zero if it isn't.
+func (l *Limiter) Fail(ctx context.Context, phone string) (time.Duration, error) {
+	counter, err := l.store.Incr(ctx, failuresKey(phone), l.now(), l.config.FailureTTL)
+	if err != nil {
+		return 0, fmt.Errorf("err counting failures: %w", err)
+	}
+	return l.lockout(counter.Count), nil
+}
+
+// Succeed forgets the failed attempts of phone.
+func (l *Limiter) Succeed(ctx context.Context, phone string) error {
+	if err := l.store.Delete(ctx, failuresKey(phone)); err != nil {
+		return fmt.Errorf("err resetting failures: %w", err)
+	}
+	return nil
+}
+
+// lockout returns how long the phone is locked after the given number of failures in a row.
+func (l *Limiter) lockout(failures int) time.Duration {
+	if l.config.MaxFailures <= 0 || failures < l.config.MaxFailures {
+		return 0
+	}
+	lockout := l.config.Lockout
+	for i := l.config.MaxFailures; i < failures && lockout < l.config.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > l.config.MaxLockout {
+		lockout = l.config.MaxLockout
+	}
+	return lockout
+}
+
+func failuresKey(phone string) string {
+	return "failures:" + phone
+}
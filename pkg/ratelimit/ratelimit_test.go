@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(config Config) (*Limiter, *time.Time) {
+	now := time.Date(2023, 7, 20, 10, 0, 0, 0, time.UTC)
+	l := New(NewMemoryStore(), config)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func limited(t *testing.T, err error) *LimitedError {
+	t.Helper()
+	var limitedErr *LimitedError
+	require.True(t, errors.As(err, &limitedErr), err)
+	require.True(t, errors.Is(err, ErrLimited))
+	return limitedErr
+}
+
+func TestRateLimits(t *testing.T) {
+	ctx := context.Background()
+	l, now := newTestLimiter(Config{IPLimit: 3, IPWindow: time.Minute, PhoneLimit: 2, PhoneWindow: time.Hour})
+
+	require.NoError(t, l.Allow(ctx, "10.0.0.1", "+79990000001"))
+	require.NoError(t, l.Allow(ctx, "10.0.0.1", "+79990000001"))
+	err := l.Allow(ctx, "10.0.0.2", "+79990000001")
+	require.Equal(t, time.Hour, limited(t, err).RetryAfter)
+	require.False(t, limited(t, err).Locked)
+
+	require.NoError(t, l.Allow(ctx, "10.0.0.1", "+79990000002"))
+	err = l.Allow(ctx, "10.0.0.1", "+79990000003")
+	require.Equal(t, time.Minute, limited(t, err).RetryAfter)
+
+	*now = now.Add(time.Minute)
+	require.NoError(t, l.Allow(ctx, "10.0.0.1", "+79990000003"))
+	require.Error(t, l.Allow(ctx, "10.0.0.3", "+79990000001"))
+}
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+	config := Config{MaxFailures: 3, Lockout: time.Minute, MaxLockout: 3 * time.Minute, FailureTTL: 24 * time.Hour}
+	l, now := newTestLimiter(config)
+	const phone = "+79990000001"
+
+	fail := func(want time.Duration) {
+		t.Helper()
+		require.NoError(t, l.Allow(ctx, "10.0.0.1", phone))
+		lockout, err := l.Fail(ctx, phone)
+		require.NoError(t, err)
+		require.Equal(t, want, lockout)
+	}
+	fail(0)
+	fail(0)
+	fail(time.Minute)
+	err := l.Allow(ctx, "10.0.0.1", phone)
+	require.True(t, limited(t, err).Locked)
+	require.Equal(t, time.Minute, limited(t, err).RetryAfter)
+	require.NoError(t, l.Allow(ctx, "10.0.0.1", "+79990000002"))
+
+	*now = now.Add(time.Minute)
+	fail(2 * time.Minute)
+	*now = now.Add(2 * time.Minute)
+	fail(3 * time.Minute)
+	*now = now.Add(3 * time.Minute)
+	fail(3 * time.Minute)
+
+	*now = now.Add(3 * time.Minute)
+	require.NoError(t, l.Succeed(ctx, phone))
+	fail(0)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2023, 7, 20, 10, 0, 0, 0, time.UTC)
+
+	counter, err := store.Incr(ctx, "key", now, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, Counter{Count: 1, UpdatedAt: now, ExpiresAt: now.Add(time.Minute)}, counter)
+	counter, err = store.Incr(ctx, "key", now.Add(30*time.Second), time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, Counter{Count: 2, UpdatedAt: now.Add(30 * time.Second), ExpiresAt: now.Add(time.Minute)}, counter)
+
+	counter, err = store.Get(ctx, "key", now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Zero(t, counter)
+	counter, err = store.Incr(ctx, "key", now.Add(2*time.Minute), time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 1, counter.Count)
+	require.Len(t, store.counters, 1)
+
+	require.NoError(t, store.Delete(ctx, "key"))
+	counter, err = store.Get(ctx, "key", now.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Zero(t, counter)
+}
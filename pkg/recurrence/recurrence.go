@@ -0,0 +1,202 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily  Frequency = "DAILY"
+	Weekly Frequency = "WEEKLY"
+)
+
+// MaxOccurrences limits the number of occurrences a single rule may produce.
+const MaxOccurrences = 200
+
+const (
+	untilLayout     = "20060102T150405Z"
+	untilDateLayout = "20060102"
+)
+
+var (
+	ErrInvalidRule        = errors.New("invalid recurrence rule")
+	ErrTooManyOccurrences = fmt.Errorf("recurrence rule produces more than %d occurrences", MaxOccurrences)
+	weekdays              = map[string]time.Weekday{"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday}
+	weekdayNames          = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+)
+
+// Rule is a subset of RFC 5545 RRULE: daily and weekly rules bounded either by UNTIL or by COUNT.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	Until    time.Time
+	Count    int
+	// UntilDate is set when UNTIL is a date, it is then resolved in the time zone of the occurrences.
+	UntilDate bool
+}
+
+// Parse parses rules like "FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20230601T000000Z". The "RRULE:" prefix is optional.
+func Parse(rule string) (Rule, error) {
+	r := Rule{Interval: 1}
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			if r.Freq != Daily && r.Freq != Weekly {
+				return Rule{}, fmt.Errorf("%w: unsupported frequency %q", ErrInvalidRule, value)
+			}
+		case "INTERVAL":
+			if r.Interval, err = strconv.Atoi(value); err != nil || r.Interval < 1 {
+				return Rule{}, fmt.Errorf("%w: invalid interval %q", ErrInvalidRule, value)
+			}
+		case "COUNT":
+			if r.Count, err = strconv.Atoi(value); err != nil || r.Count < 1 || r.Count > MaxOccurrences {
+				return Rule{}, fmt.Errorf("%w: invalid count %q", ErrInvalidRule, value)
+			}
+		case "UNTIL":
+			if r.Until, err = time.Parse(untilLayout, value); err == nil {
+				break
+			}
+			if r.Until, err = time.Parse(untilDateLayout, value); err != nil {
+				return Rule{}, fmt.Errorf("%w: invalid until %q", ErrInvalidRule, value)
+			}
+			r.UntilDate = true
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(value), ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return Rule{}, fmt.Errorf("%w: invalid day %q", ErrInvalidRule, day)
+				}
+				r.ByDay = append(r.ByDay, weekday)
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				return Rule{}, fmt.Errorf("%w: unsupported week start %q", ErrInvalidRule, value)
+			}
+		default:
+			return Rule{}, fmt.Errorf("%w: unsupported part %q", ErrInvalidRule, key)
+		}
+	}
+	switch {
+	case r.Freq == "":
+		return Rule{}, fmt.Errorf("%w: frequency is required", ErrInvalidRule)
+	case r.Until.IsZero() == (r.Count == 0):
+		return Rule{}, fmt.Errorf("%w: exactly one of until and count is required", ErrInvalidRule)
+	case r.Freq == Daily && len(r.ByDay) > 0:
+		return Rule{}, fmt.Errorf("%w: byday is supported for weekly rules only", ErrInvalidRule)
+	}
+	return r, nil
+}
+
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, weekdayNames[day])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	switch {
+	case r.Count > 0:
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	case r.UntilDate:
+		parts = append(parts, "UNTIL="+r.Until.Format(untilDateLayout))
+	default:
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences expands the rule starting from start. Occurrences keep the wall clock time of start
+// in its location, so they stay at the same local time across DST transitions.
+func (r Rule) Occurrences(start time.Time) ([]time.Time, error) {
+	loc := start.Location()
+	until := r.Until
+	if r.UntilDate {
+		until = time.Date(until.Year(), until.Month(), until.Day()+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	}
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	at := func(days int) time.Time {
+		return time.Date(start.Year(), start.Month(), start.Day()+days, start.Hour(), start.Minute(), start.Second(), 0, loc)
+	}
+
+	var result []time.Time
+	// add reports whether the expansion should go on.
+	add := func(t time.Time) (bool, error) {
+		if !until.IsZero() && t.After(until) {
+			return false, nil
+		}
+		if len(result) == MaxOccurrences {
+			return false, ErrTooManyOccurrences
+		}
+		result = append(result, t)
+		return r.Count == 0 || len(result) < r.Count, nil
+	}
+
+	switch r.Freq {
+	case Daily:
+		for day := 0; ; day += interval {
+			next, err := add(at(day))
+			if err != nil {
+				return nil, err
+			}
+			if !next {
+				return result, nil
+			}
+		}
+	case Weekly:
+		offsets := make([]int, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			offsets = append(offsets, mondayOffset(day))
+		}
+		if len(offsets) == 0 {
+			offsets = append(offsets, mondayOffset(start.Weekday()))
+		}
+		sort.Ints(offsets)
+		weekStart := -mondayOffset(start.Weekday())
+		for week := 0; ; week += interval {
+			for _, offset := range offsets {
+				day := weekStart + 7*week + offset
+				if day < 0 {
+					continue
+				}
+				next, err := add(at(day))
+				if err != nil {
+					return nil, err
+				}
+				if !next {
+					return result, nil
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported frequency %q", ErrInvalidRule, r.Freq)
+	}
+}
+
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
@@ -0,0 +1,77 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{name: "weekly with count", rule: "RRULE:FREQ=WEEKLY;BYDAY=MO,TH;COUNT=10", want: "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=10"},
+		{name: "weekly with until", rule: "FREQ=WEEKLY;INTERVAL=2;UNTIL=20230601T000000Z", want: "FREQ=WEEKLY;INTERVAL=2;UNTIL=20230601T000000Z"},
+		{name: "daily with until date", rule: "FREQ=DAILY;UNTIL=20230601", want: "FREQ=DAILY;UNTIL=20230601"},
+		{name: "unbounded", rule: "FREQ=WEEKLY;BYDAY=MO", wantErr: true},
+		{name: "both count and until", rule: "FREQ=WEEKLY;COUNT=2;UNTIL=20230601", wantErr: true},
+		{name: "monthly", rule: "FREQ=MONTHLY;COUNT=2", wantErr: true},
+		{name: "invalid day", rule: "FREQ=WEEKLY;BYDAY=XX;COUNT=2", wantErr: true},
+		{name: "too many", rule: "FREQ=DAILY;COUNT=1000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidRule)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, rule.String())
+		})
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	t.Run("weekly by day", func(t *testing.T) {
+		rule, err := Parse("FREQ=WEEKLY;BYDAY=MO,TH;COUNT=4")
+		require.NoError(t, err)
+		// Tuesday, so the first occurrence is on Thursday.
+		occurrences, err := rule.Occurrences(time.Date(2023, 3, 7, 10, 0, 0, 0, berlin))
+		require.NoError(t, err)
+		require.Equal(t, []time.Time{
+			time.Date(2023, 3, 9, 10, 0, 0, 0, berlin),
+			time.Date(2023, 3, 13, 10, 0, 0, 0, berlin),
+			time.Date(2023, 3, 16, 10, 0, 0, 0, berlin),
+			time.Date(2023, 3, 20, 10, 0, 0, 0, berlin),
+		}, occurrences)
+	})
+
+	t.Run("keeps wall clock across DST", func(t *testing.T) {
+		rule, err := Parse("FREQ=WEEKLY;UNTIL=20230405")
+		require.NoError(t, err)
+		occurrences, err := rule.Occurrences(time.Date(2023, 3, 22, 18, 30, 0, 0, berlin))
+		require.NoError(t, err)
+		require.Len(t, occurrences, 3)
+		for _, occurrence := range occurrences {
+			require.Equal(t, 18, occurrence.Hour())
+			require.Equal(t, 30, occurrence.Minute())
+		}
+		require.Equal(t, 167*time.Hour, occurrences[1].Sub(occurrences[0]))
+		require.Equal(t, 168*time.Hour, occurrences[2].Sub(occurrences[1]))
+	})
+
+	t.Run("too many occurrences", func(t *testing.T) {
+		rule, err := Parse("FREQ=DAILY;UNTIL=20300101T000000Z")
+		require.NoError(t, err)
+		_, err = rule.Occurrences(time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC))
+		require.ErrorIs(t, err, ErrTooManyOccurrences)
+	})
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// Login checks the credentials and opens a new session of the user.
+func (s *ScheduleService) Login(ctx context.Context, phone, password string) (models.TokenResponse, error) {
+	if normalized, ok := normalizePhone(phone); ok {
+		phone = normalized
+	}
+	user, err := s.store.GetUserByPhone(ctx, phone)
+	switch {
+	case errors.Is(err, pgstore.ErrUserNotFound):
+		return models.TokenResponse{}, models.ErrInvalidCredentials
+	case err != nil:
+		return models.TokenResponse{}, fmt.Errorf("err login: %w", err)
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return models.TokenResponse{}, models.ErrInvalidCredentials
+	}
+	refreshToken, token, err := newRefreshToken()
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	session, err := s.store.CreateSession(ctx, models.Session{ID: uuid.NewString(), UserID: user.ID}, token)
+	if err != nil {
+		return models.TokenResponse{}, fmt.Errorf("err creating session of user (id %d): %w", user.ID, err)
+	}
+	return s.generateTokens(user, session, refreshToken)
+}
+
+// RecordLoginFailure saves the audit record of a failed login.
+func (s *ScheduleService) RecordLoginFailure(ctx context.Context, failure models.LoginFailure) error {
+	if normalized, ok := normalizePhone(failure.Phone); ok {
+		failure.Phone = normalized
+	}
+	if _, err := s.store.CreateLoginFailure(ctx, failure); err != nil {
+		return fmt.Errorf("err recording login failure: %w", err)
+	}
+	return nil
+}
+
+// Refresh rotates the refresh token and issues a new access token of the same session.
+func (s *ScheduleService) Refresh(ctx context.Context, refreshToken string) (models.TokenResponse, error) {
+	nextRefreshToken, next, err := newRefreshToken()
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	session, err := s.store.RotateRefreshToken(ctx, hashToken(refreshToken), next)
+	switch {
+	case errors.Is(err, models.ErrInvalidRefreshToken), errors.Is(err, models.ErrSessionRevoked):
+		return models.TokenResponse{}, err
+	case err != nil:
+		return models.TokenResponse{}, fmt.Errorf("err rotating refresh token: %w", err)
+	}
+	user, err := s.store.GetUser(ctx, session.UserID)
+	switch {
+	case errors.Is(err, pgstore.ErrUserNotFound):
+		return models.TokenResponse{}, models.ErrInvalidRefreshToken
+	case err != nil:
+		return models.TokenResponse{}, fmt.Errorf("err getting user (id %d) from store: %w", session.UserID, err)
+	}
+	return s.generateTokens(user, session, nextRefreshToken)
+}
+
+// Logout revokes the session, its access and refresh tokens are not accepted anymore.
+func (s *ScheduleService) Logout(ctx context.Context, sessionID string) error {
+	if err := s.store.RevokeSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("err revoking session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// ValidateSession checks the session of the access token is still open.
+func (s *ScheduleService) ValidateSession(ctx context.Context, claims *models.Claims) error {
+	session, err := s.store.GetSession(ctx, claims.SessionID)
+	switch {
+	case errors.Is(err, pgstore.ErrSessionNotFound):
+		return models.ErrSessionRevoked
+	case err != nil:
+		return fmt.Errorf("err getting session %s from store: %w", claims.SessionID, err)
+	case session.RevokedAt != nil || session.UserID != claims.UserID:
+		return models.ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s *ScheduleService) generateTokens(user models.User, session models.Session, refreshToken string) (models.TokenResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(accessTokenTTL)
+	signed, err := s.keys.Sign(&models.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		UserID:    user.ID,
+		Role:      user.Role,
+		SessionID: session.ID,
+	})
+	if err != nil {
+		return models.TokenResponse{}, fmt.Errorf("err signing token: %w", err)
+	}
+	return models.TokenResponse{Token: signed, ExpiresAt: expiresAt, RefreshToken: refreshToken}, nil
+}
+
+// newRefreshToken returns a random refresh token and its stored form.
+func newRefreshToken() (string, models.RefreshToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", models.RefreshToken{}, fmt.Errorf("err generating refresh token: %w", err)
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(b)
+	return refreshToken, models.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}, nil
+}
+
+// newRandomToken returns a random URL safe token.
+func newRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("err generating token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
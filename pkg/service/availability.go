@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+)
+
+const (
+	clockLayout = "15:04"
+	dateLayout  = "2006-01-02"
+)
+
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+func (s *ScheduleService) GetAvailability(ctx context.Context, coachID int) (models.Availability, error) {
+	availability, err := s.store.GetAvailability(ctx, coachID)
+	if err != nil {
+		return models.Availability{}, fmt.Errorf("err getting availability (coach %d) from store: %w", coachID, err)
+	}
+	return availability, nil
+}
+
+func (s *ScheduleService) SetAvailability(ctx context.Context, coachID int, data models.AvailabilityRequest) (models.Availability, error) {
+	if data.TimeZone != nil {
+		if _, err := time.LoadLocation(*data.TimeZone); err != nil {
+			return models.Availability{}, models.ErrInvalidAvailability.Errorf("unknown time zone %q", *data.TimeZone)
+		}
+	}
+	for _, period := range data.WorkingHours {
+		if err := validatePeriod(period); err != nil {
+			return models.Availability{}, err
+		}
+	}
+	for _, period := range data.Breaks {
+		if err := validatePeriod(period); err != nil {
+			return models.Availability{}, err
+		}
+	}
+	availability, err := s.store.SetAvailability(ctx, coachID, data)
+	if err != nil {
+		return models.Availability{}, fmt.Errorf("err setting availability (coach %d) in store: %w", coachID, err)
+	}
+	return availability, nil
+}
+
+func (s *ScheduleService) SaveAvailabilityOverride(ctx context.Context, coachID int, override models.AvailabilityOverride) (models.AvailabilityOverride, error) {
+	if _, err := time.Parse(dateLayout, override.Date); err != nil {
+		return models.AvailabilityOverride{}, models.ErrInvalidAvailability.Errorf("invalid date %q", override.Date)
+	}
+	if override.Available {
+		if override.StartTime == nil || override.EndTime == nil {
+			return models.AvailabilityOverride{}, models.ErrInvalidAvailability.Errorf("start and end time are required")
+		}
+		if _, _, err := parseClockRange(*override.StartTime, *override.EndTime); err != nil {
+			return models.AvailabilityOverride{}, err
+		}
+	} else {
+		override.StartTime, override.EndTime = nil, nil
+	}
+	savedOverride, err := s.store.SaveAvailabilityOverride(ctx, coachID, override)
+	if err != nil {
+		return models.AvailabilityOverride{}, fmt.Errorf("err saving availability override (coach %d) in store: %w", coachID, err)
+	}
+	return savedOverride, nil
+}
+
+func (s *ScheduleService) DeleteAvailabilityOverride(ctx context.Context, coachID, id int) (models.AvailabilityOverride, error) {
+	deletedOverride, err := s.store.DeleteAvailabilityOverride(ctx, coachID, id)
+	if err != nil {
+		return models.AvailabilityOverride{}, fmt.Errorf("err deleting availability override (id %d) from store: %w", id, err)
+	}
+	return deletedOverride, nil
+}
+
+// checkAvailability makes sure every meeting fits into a single working window of the coach.
+// Coaches who have not configured their availability accept meetings at any time.
+func (s *ScheduleService) checkAvailability(ctx context.Context, coachID int, meetings ...models.Interval) error {
+	if len(meetings) == 0 {
+		return nil
+	}
+	availability, err := s.store.GetAvailability(ctx, coachID)
+	switch {
+	case errors.Is(err, pgstore.ErrAvailabilityNotFound):
+		return nil
+	case err != nil:
+		return fmt.Errorf("err getting availability (coach %d) from store: %w", coachID, err)
+	}
+	from, to := meetings[0].Start, meetings[0].End
+	for _, meeting := range meetings {
+		if meeting.Start.Before(from) {
+			from = meeting.Start
+		}
+		if meeting.End.After(to) {
+			to = meeting.End
+		}
+	}
+	windows, err := availabilityWindows(availability, from, to)
+	if err != nil {
+		return err
+	}
+	for _, meeting := range meetings {
+		if !fits(windows, meeting) {
+			return models.ErrOutsideAvailability
+		}
+	}
+	return nil
+}
+
+func fits(windows []interval, meeting models.Interval) bool {
+	for _, window := range windows {
+		if !meeting.Start.Before(window.start) && !meeting.End.After(window.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// availabilityWindows returns merged working windows of the coach which intersect [from, to).
+// Windows are not clipped, so a meeting can be checked for containment.
+func availabilityWindows(availability models.Availability, from, to time.Time) ([]interval, error) {
+	loc, err := time.LoadLocation(availability.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("err loading time zone %q: %w", availability.TimeZone, err)
+	}
+	overrides := make(map[string]models.AvailabilityOverride, len(availability.Overrides))
+	for _, override := range availability.Overrides {
+		overrides[override.Date] = override
+	}
+
+	var windows []interval
+	localFrom := from.In(loc)
+	day := time.Date(localFrom.Year(), localFrom.Month(), localFrom.Day()-1, 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		if override, ok := overrides[day.Format(dateLayout)]; ok {
+			if !override.Available || override.StartTime == nil || override.EndTime == nil {
+				continue
+			}
+			window, err := dayInterval(day, *override.StartTime, *override.EndTime)
+			if err != nil {
+				return nil, err
+			}
+			windows = append(windows, window)
+			continue
+		}
+		var dayWindows []interval
+		for _, period := range availability.WorkingHours {
+			if time.Weekday(period.Weekday) != day.Weekday() {
+				continue
+			}
+			window, err := dayInterval(day, period.StartTime, period.EndTime)
+			if err != nil {
+				return nil, err
+			}
+			dayWindows = append(dayWindows, window)
+		}
+		for _, period := range availability.Breaks {
+			if time.Weekday(period.Weekday) != day.Weekday() {
+				continue
+			}
+			pause, err := dayInterval(day, period.StartTime, period.EndTime)
+			if err != nil {
+				return nil, err
+			}
+			dayWindows = subtract(dayWindows, pause)
+		}
+		windows = append(windows, dayWindows...)
+	}
+
+	result := make([]interval, 0, len(windows))
+	for _, window := range merge(windows) {
+		if window.end.After(from) && window.start.Before(to) {
+			result = append(result, window)
+		}
+	}
+	return result, nil
+}
+
+func dayInterval(day time.Time, startTime, endTime string) (interval, error) {
+	start, end, err := parseClockRange(startTime, endTime)
+	if err != nil {
+		return interval{}, err
+	}
+	return interval{
+		start: time.Date(day.Year(), day.Month(), day.Day(), 0, start, 0, 0, day.Location()),
+		end:   time.Date(day.Year(), day.Month(), day.Day(), 0, end, 0, 0, day.Location()),
+	}, nil
+}
+
+func validatePeriod(period models.WeeklyPeriod) error {
+	if period.Weekday < int(time.Sunday) || period.Weekday > int(time.Saturday) {
+		return models.ErrInvalidAvailability.Errorf("invalid weekday %d", period.Weekday)
+	}
+	_, _, err := parseClockRange(period.StartTime, period.EndTime)
+	return err
+}
+
+// parseClockRange returns the start and the end of the range as minutes since midnight.
+func parseClockRange(startTime, endTime string) (int, int, error) {
+	start, err := parseClock(startTime)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(endTime)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start >= end {
+		return 0, 0, models.ErrInvalidAvailability.Errorf("%s is not before %s", startTime, endTime)
+	}
+	return start, end, nil
+}
+
+func parseClock(clock string) (int, error) {
+	if clock == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse(clockLayout, clock)
+	if err != nil {
+		return 0, models.ErrInvalidAvailability.Errorf("invalid time %q", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func merge(intervals []interval) []interval {
+	if len(intervals) == 0 {
+		return nil
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].start.Before(intervals[j].start)
+	})
+	result := []interval{intervals[0]}
+	for _, next := range intervals[1:] {
+		last := &result[len(result)-1]
+		if next.start.After(last.end) {
+			result = append(result, next)
+			continue
+		}
+		if next.end.After(last.end) {
+			last.end = next.end
+		}
+	}
+	return result
+}
+
+func subtract(intervals []interval, busy interval) []interval {
+	result := make([]interval, 0, len(intervals))
+	for _, in := range intervals {
+		if !busy.start.Before(in.end) || !busy.end.After(in.start) {
+			result = append(result, in)
+			continue
+		}
+		if busy.start.After(in.start) {
+			result = append(result, interval{start: in.start, end: busy.start})
+		}
+		if busy.end.Before(in.end) {
+			result = append(result, interval{start: busy.end, end: in.end})
+		}
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/ical"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// calendarHistory is how far back calendar feeds reach.
+const calendarHistory = 90 * 24 * time.Hour
+
+var eventStatuses = map[string]string{
+	models.StatusRequested: ical.StatusTentative,
+	models.StatusConfirmed: ical.StatusConfirmed,
+	models.StatusCompleted: ical.StatusConfirmed,
+	models.StatusNoShow:    ical.StatusConfirmed,
+	models.StatusCancelled: ical.StatusCancelled,
+}
+
+// CreateCalendarToken issues the token of the calendar feed of the user. The previous token stops working.
+func (s *ScheduleService) CreateCalendarToken(ctx context.Context, userID int) (models.CalendarFeed, error) {
+	if _, err := s.store.GetUser(ctx, userID); err != nil {
+		return models.CalendarFeed{}, fmt.Errorf("err getting user (id %d) from store: %w", userID, err)
+	}
+	token, err := newRandomToken()
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+	createdAt, err := s.store.SetCalendarToken(ctx, userID, hashToken(token))
+	if err != nil {
+		return models.CalendarFeed{}, fmt.Errorf("err saving calendar token of user (id %d): %w", userID, err)
+	}
+	return models.CalendarFeed{Token: token, CreatedAt: createdAt}, nil
+}
+
+func (s *ScheduleService) RevokeCalendarToken(ctx context.Context, userID int) error {
+	if err := s.store.DeleteCalendarToken(ctx, userID); err != nil {
+		return fmt.Errorf("err revoking calendar token of user (id %d): %w", userID, err)
+	}
+	return nil
+}
+
+// GetCalendar returns the meetings of the user as a calendar if token is the calendar token of the user.
+func (s *ScheduleService) GetCalendar(ctx context.Context, userID int, token string) (ical.Calendar, error) {
+	hash, err := s.store.GetCalendarToken(ctx, userID)
+	if err != nil {
+		return ical.Calendar{}, fmt.Errorf("err getting calendar token of user (id %d): %w", userID, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashToken(token))) != 1 {
+		return ical.Calendar{}, models.ErrInvalidCalendarToken
+	}
+	meetings, err := s.store.GetUserMeetings(ctx, userID, time.Now().Add(-calendarHistory))
+	if err != nil {
+		return ical.Calendar{}, fmt.Errorf("err getting meetings of user (id %d) from store: %w", userID, err)
+	}
+	names := make(map[int]string)
+	calendar := ical.Calendar{Name: "TimeSlots"}
+	for _, meeting := range meetings {
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:          fmt.Sprintf("meeting-%d@timeslots", meeting.ID),
+			Sequence:     meeting.Sequence,
+			Status:       eventStatuses[meeting.Status],
+			Summary:      s.meetingSummary(ctx, userID, meeting, names),
+			Start:        meeting.StartTime,
+			End:          meeting.EndTime,
+			Created:      meeting.CreatedAt,
+			LastModified: meeting.UpdatedAt,
+		})
+	}
+	return calendar, nil
+}
+
+// meetingSummary names the meeting after the other side of it for the user. names caches the names of users.
+func (s *ScheduleService) meetingSummary(ctx context.Context, userID int, meeting models.Meeting, names map[int]string) string {
+	other := meeting.Manager
+	if meeting.Manager == userID {
+		if meeting.Capacity > 1 {
+			return "Group session"
+		}
+		other = meeting.Client
+	}
+	name, ok := names[other]
+	if !ok {
+		if user, err := s.store.GetUser(ctx, other); err == nil {
+			name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		}
+		names[other] = name
+	}
+	if name == "" {
+		return "Training"
+	}
+	return "Training with " + name
+}
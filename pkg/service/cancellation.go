@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+)
+
+// GetCancellationPolicy returns the policy of the coach. Coaches without a policy
+// allow free cancellation until the start of a meeting.
+func (s *ScheduleService) GetCancellationPolicy(ctx context.Context, coachID int) (models.CancellationPolicy, error) {
+	policy, err := s.store.GetCancellationPolicy(ctx, coachID)
+	switch {
+	case errors.Is(err, pgstore.ErrCancellationPolicyNotFound):
+		return models.CancellationPolicy{CoachID: coachID, AllowLateCancellation: true}, nil
+	case err != nil:
+		return models.CancellationPolicy{}, fmt.Errorf("err getting cancellation policy (coach %d) from store: %w", coachID, err)
+	}
+	return policy, nil
+}
+
+func (s *ScheduleService) SetCancellationPolicy(ctx context.Context, coachID int, data models.CancellationPolicyRequest) (models.CancellationPolicy, error) {
+	policy, err := s.GetCancellationPolicy(ctx, coachID)
+	if err != nil {
+		return models.CancellationPolicy{}, err
+	}
+	if data.FreeCancellationMinutes != nil {
+		policy.FreeCancellationMinutes = *data.FreeCancellationMinutes
+	}
+	if data.AllowLateCancellation != nil {
+		policy.AllowLateCancellation = *data.AllowLateCancellation
+	}
+	if policy.FreeCancellationMinutes < 0 {
+		return models.CancellationPolicy{}, models.ErrInvalidCancellationPolicy.Errorf("negative free cancellation period")
+	}
+	savedPolicy, err := s.store.SetCancellationPolicy(ctx, policy)
+	if err != nil {
+		return models.CancellationPolicy{}, fmt.Errorf("err setting cancellation policy (coach %d) from store: %w", coachID, err)
+	}
+	return savedPolicy, nil
+}
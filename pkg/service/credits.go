@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// GrantCredits sells a package of sessions with the coach to the client. Packages are for clients only,
+// the caller checks the client is on the roster of the coach.
+func (s *ScheduleService) GrantCredits(ctx context.Context, coachID, clientID int, data models.CreditPackageRequest) (models.CreditPackage, error) {
+	if data.Sessions == nil || *data.Sessions <= 0 {
+		return models.CreditPackage{}, models.ErrInvalidCreditPackage.Errorf("sessions must be positive")
+	}
+	if data.ExpiresAt == nil || !data.ExpiresAt.After(time.Now()) {
+		return models.CreditPackage{}, models.ErrInvalidCreditPackage.Errorf("expiry must be in the future")
+	}
+	client, err := s.store.GetUser(ctx, clientID)
+	if err != nil {
+		return models.CreditPackage{}, fmt.Errorf("err granting credits (client %d): %w", clientID, err)
+	}
+	if client.Role != models.RoleClient {
+		return models.CreditPackage{}, models.ErrInvalidCreditPackage.Errorf("credits are granted to clients only")
+	}
+	pkg, err := s.store.GrantCredits(ctx, models.CreditPackage{
+		ClientID:  clientID,
+		CoachID:   coachID,
+		Sessions:  *data.Sessions,
+		ExpiresAt: *data.ExpiresAt,
+	})
+	if err != nil {
+		return models.CreditPackage{}, fmt.Errorf("err granting credits (client %d) from store: %w", clientID, err)
+	}
+	return pkg, nil
+}
+
+// GetCreditBalance returns the active packages of the client, optionally only those with the coach.
+func (s *ScheduleService) GetCreditBalance(ctx context.Context, clientID int, coachID *int) (models.CreditBalance, error) {
+	packages, err := s.store.GetCreditPackages(ctx, clientID, coachID)
+	if err != nil {
+		return models.CreditBalance{}, fmt.Errorf("err getting credits (client %d) from store: %w", clientID, err)
+	}
+	balance := models.CreditBalance{ClientID: clientID, Packages: packages}
+	for _, pkg := range packages {
+		balance.Balance += pkg.Remaining
+	}
+	return balance, nil
+}
+
+func (s *ScheduleService) GetCreditLedger(ctx context.Context, clientID int, coachID *int) ([]models.CreditEntry, error) {
+	entries, err := s.store.GetCreditLedger(ctx, clientID, coachID)
+	if err != nil {
+		return nil, fmt.Errorf("err getting credit ledger (client %d) from store: %w", clientID, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// ExportMeetings passes the meetings matching the filter to fn one by one, ordered by start.
+func (s *ScheduleService) ExportMeetings(ctx context.Context, filter models.MeetingFilter, fn func(models.MeetingExport) error) error {
+	if err := s.store.ExportMeetings(ctx, filter, fn); err != nil {
+		return fmt.Errorf("err exporting meetings: %w", err)
+	}
+	return nil
+}
+
+// ExportUsers passes the users matching the filter to fn one by one, ordered by id.
+func (s *ScheduleService) ExportUsers(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error {
+	if err := s.store.ExportUsers(ctx, filter, fn); err != nil {
+		return fmt.Errorf("err exporting users: %w", err)
+	}
+	return nil
+}
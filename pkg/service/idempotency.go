@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// BeginIdempotentRequest reserves the key of a request. If the same request was already made it returns
+// the saved key with the response to replay.
+func (s *ScheduleService) BeginIdempotentRequest(ctx context.Context, key models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	saved, created, err := s.store.ReserveIdempotencyKey(ctx, key)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("err reserving idempotency key: %w", err)
+	case created:
+		return nil, nil
+	case saved.RequestHash != key.RequestHash:
+		return nil, models.ErrIdempotencyKeyReused
+	case saved.Status == nil:
+		return nil, models.ErrRequestInProgress
+	}
+	return &saved, nil
+}
+
+// FinishIdempotentRequest saves the response of the request. Server errors are not saved so the request can be retried.
+func (s *ScheduleService) FinishIdempotentRequest(ctx context.Context, key models.IdempotencyKey) error {
+	if key.Status == nil || *key.Status >= http.StatusInternalServerError {
+		if err := s.store.ReleaseIdempotencyKey(ctx, key); err != nil {
+			return fmt.Errorf("err releasing idempotency key: %w", err)
+		}
+		return nil
+	}
+	if err := s.store.CompleteIdempotencyKey(ctx, key); err != nil {
+		return fmt.Errorf("err completing idempotency key: %w", err)
+	}
+	return nil
+}
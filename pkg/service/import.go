@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/ical"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/recurrence"
+)
+
+const (
+	// importHorizon is how far ahead recurring events without an end are imported.
+	importHorizon = 180 * 24 * time.Hour
+	// maxImportItems limits the upcoming occurrences of a calendar.
+	maxImportItems = 1000
+	untilLayout    = "20060102T150405Z"
+)
+
+// importOccurrence is an occurrence of a calendar event. Key identifies it across imports of the calendar,
+// reason is set when the occurrence is skipped before matching the attendees.
+type importOccurrence struct {
+	event  ical.Event
+	key    string
+	start  time.Time
+	end    time.Time
+	reason string
+}
+
+// ImportMeetings creates meetings of the coach from the upcoming events of the iCalendar data. Recurring
+// events become single meetings, one per occurrence. The client of a meeting is the single attendee matching
+// a user by email or phone. With dryRun nothing is created and the report tells what would be.
+func (s *ScheduleService) ImportMeetings(ctx context.Context, coachID int, data io.Reader, dryRun bool) (models.ImportReport, error) {
+	coach, err := s.store.GetUser(ctx, coachID)
+	if err != nil {
+		return models.ImportReport{}, fmt.Errorf("err getting user (id %d) from store: %w", coachID, err)
+	}
+	calendar, err := ical.Decode(data, coach.Location())
+	if err != nil {
+		return models.ImportReport{}, models.ErrInvalidCalendar.Errorf("%s", strings.TrimPrefix(err.Error(), ical.ErrInvalidCalendar.Error()+": "))
+	}
+	now := time.Now()
+	occurrences, err := importOccurrences(calendar.Events, now)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+	report := models.ImportReport{DryRun: dryRun, Items: []models.ImportItem{}}
+	if len(occurrences) == 0 {
+		return report, nil
+	}
+	keys := make([]string, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		keys = append(keys, occurrence.key)
+	}
+	imported, err := s.store.GetImportedMeetings(ctx, coachID, keys)
+	if err != nil {
+		return models.ImportReport{}, fmt.Errorf("err getting imported meetings of coach (id %d): %w", coachID, err)
+	}
+	matcher, err := s.newAttendeeMatcher(ctx, coachID, calendar.Events)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+	var plan *importPlan
+	if dryRun {
+		if plan, err = s.newImportPlan(ctx, coachID, occurrences); err != nil {
+			return models.ImportReport{}, err
+		}
+	}
+
+	for _, occurrence := range occurrences {
+		item := models.ImportItem{
+			UID:       occurrence.event.UID,
+			Summary:   occurrence.event.Summary,
+			StartTime: occurrence.start,
+			EndTime:   occurrence.end,
+			Result:    models.ImportSkipped,
+			Reason:    occurrence.reason,
+		}
+		if id, ok := imported[occurrence.key]; ok && item.Reason == "" {
+			item.Reason, item.MeetingID = models.ErrAlreadyImported.Error(), &id
+		}
+		if item.Reason == "" {
+			item.Client, item.Reason = matcher.match(occurrence.event)
+		}
+		if item.Reason != "" {
+			report.Add(item)
+			continue
+		}
+
+		status := models.StatusConfirmed
+		if occurrence.event.Status == ical.StatusTentative {
+			status = models.StatusRequested
+		}
+		key, start, end := occurrence.key, occurrence.start, occurrence.end
+		request := models.MeetingRequest{
+			Manager:    &coachID,
+			Client:     item.Client,
+			StartTime:  &start,
+			EndTime:    &end,
+			Status:     &status,
+			ExternalID: &key,
+		}
+		if dryRun {
+			err = s.checkImport(ctx, plan, request)
+		} else {
+			var meeting models.Meeting
+			if meeting, err = s.CreateMeeting(ctx, request); err == nil {
+				item.MeetingID = &meeting.ID
+			}
+		}
+		if item.Result, item.Reason, err = importResult(err); err != nil {
+			return models.ImportReport{}, fmt.Errorf("err importing meetings of coach (id %d): %w", coachID, err)
+		}
+		report.Add(item)
+	}
+	return report, nil
+}
+
+// importOccurrences expands the events into their upcoming occurrences ordered by start. Occurrences
+// excluded by EXDATE or replaced by another event with the same UID and a RECURRENCE-ID are left out.
+func importOccurrences(events []ical.Event, now time.Time) ([]importOccurrence, error) {
+	replaced := make(map[string]bool)
+	for _, event := range events {
+		if event.RecurrenceID != nil {
+			replaced[occurrenceKey(event.UID, *event.RecurrenceID)] = true
+		}
+	}
+	var result []importOccurrence
+	for _, event := range events {
+		key := event.UID
+		if event.RecurrenceID != nil {
+			key = occurrenceKey(event.UID, *event.RecurrenceID)
+		}
+		var reason string
+		switch {
+		case event.Status == ical.StatusCancelled:
+			reason = "event is cancelled"
+		case event.AllDay:
+			reason = "all-day events are not imported"
+		}
+		starts := []time.Time{event.Start}
+		if event.Rule != "" && event.RecurrenceID == nil && reason == "" {
+			var err error
+			if starts, err = ruleOccurrences(event, now); err != nil {
+				starts, reason = []time.Time{event.Start}, err.Error()
+			}
+		}
+		if reason != "" {
+			if event.End.After(now) {
+				result = append(result, importOccurrence{event: event, key: key, start: event.Start, end: event.End, reason: reason})
+			}
+			continue
+		}
+		duration := event.End.Sub(event.Start)
+		for _, start := range starts {
+			if event.Rule != "" && event.RecurrenceID == nil {
+				key = occurrenceKey(event.UID, start)
+				if replaced[key] || excluded(event.ExDates, start) {
+					continue
+				}
+			}
+			if start.After(now) {
+				result = append(result, importOccurrence{event: event, key: key, start: start, end: start.Add(duration)})
+			}
+		}
+	}
+	if len(result) > maxImportItems {
+		return nil, models.ErrInvalidCalendar.Errorf("calendar has more than %d upcoming events", maxImportItems)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].start.Before(result[j].start)
+	})
+	return result, nil
+}
+
+// ruleOccurrences expands the rule of the event up to importHorizon. Past periods of the rule are skipped,
+// so long-running events do not run into recurrence.MaxOccurrences.
+func ruleOccurrences(event ical.Event, now time.Time) ([]time.Time, error) {
+	horizon := now.Add(importHorizon)
+	rule := event.Rule
+	upper := strings.ToUpper(rule)
+	if !strings.Contains(upper, "UNTIL=") && !strings.Contains(upper, "COUNT=") {
+		rule += ";UNTIL=" + horizon.UTC().Format(untilLayout)
+	}
+	r, err := recurrence.Parse(rule)
+	if err != nil {
+		return nil, err
+	}
+	start := event.Start
+	if r.Count == 0 {
+		if r.Until.After(horizon) {
+			r.Until, r.UntilDate = horizon, false
+		}
+		days := r.Interval
+		if r.Freq == recurrence.Weekly {
+			days *= 7
+		}
+		if periods := int(now.Sub(start) / (time.Duration(days) * 24 * time.Hour)); periods > 0 {
+			start = time.Date(start.Year(), start.Month(), start.Day()+periods*days,
+				start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		}
+	}
+	return r.Occurrences(start)
+}
+
+func occurrenceKey(uid string, start time.Time) string {
+	return uid + "/" + start.UTC().Format(untilLayout)
+}
+
+func excluded(dates []time.Time, start time.Time) bool {
+	for _, date := range dates {
+		if date.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// importResult turns the error of creating a meeting into the result of the import item. Errors which
+// are not about the meeting are returned.
+func importResult(err error) (string, string, error) {
+	if err == nil {
+		return models.ImportCreated, "", nil
+	}
+	result := models.ImportSkipped
+	if errors.Is(err, models.ErrMeetingConflict) || errors.Is(err, models.ErrSlotHeld) {
+		result = models.ImportConflict
+	}
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		return result, validationErr.Error(), nil
+	}
+	var domainErr *models.Error
+	if errors.As(err, &domainErr) {
+		return result, domainErr.Error(), nil
+	}
+	if result == models.ImportConflict {
+		return result, models.ErrMeetingConflict.Error(), nil
+	}
+	return "", "", err
+}
+
+// attendeeMatcher finds the clients of attendees by email or phone. Only the roster of the coach is searched,
+// so an import can't be used to learn who else has an account.
+type attendeeMatcher struct {
+	coach   int
+	byEmail map[string][]int
+	byPhone map[string][]int
+}
+
+func (s *ScheduleService) newAttendeeMatcher(ctx context.Context, coachID int, events []ical.Event) (attendeeMatcher, error) {
+	m := attendeeMatcher{coach: coachID, byEmail: make(map[string][]int), byPhone: make(map[string][]int)}
+	var emails, phones []string
+	for _, event := range events {
+		for _, attendee := range event.Attendees {
+			if attendee.Email != "" {
+				emails = append(emails, attendee.Email)
+			}
+			if phone, ok := normalizePhone(attendee.Phone); ok {
+				phones = append(phones, phone)
+			}
+		}
+	}
+	if len(emails) == 0 && len(phones) == 0 {
+		return m, nil
+	}
+	users, err := s.store.FindClientsByContact(ctx, coachID, emails, phones)
+	if err != nil {
+		return attendeeMatcher{}, fmt.Errorf("err finding attendees of calendar: %w", err)
+	}
+	for _, user := range users {
+		if user.Email != "" {
+			email := strings.ToLower(user.Email)
+			m.byEmail[email] = append(m.byEmail[email], user.ID)
+		}
+		m.byPhone[user.Phone] = append(m.byPhone[user.Phone], user.ID)
+	}
+	return m, nil
+}
+
+// match returns the client of the event or the reason the event is skipped. The coach is not a client,
+// events with several clients are group sessions which are not imported.
+func (m attendeeMatcher) match(event ical.Event) (*int, string) {
+	clients := make(map[int]bool)
+	for _, attendee := range event.Attendees {
+		users := make(map[int]bool)
+		for _, id := range m.byEmail[strings.ToLower(attendee.Email)] {
+			users[id] = true
+		}
+		if phone, ok := normalizePhone(attendee.Phone); ok {
+			for _, id := range m.byPhone[phone] {
+				users[id] = true
+			}
+		}
+		delete(users, m.coach)
+		if len(users) > 1 {
+			return nil, fmt.Sprintf("attendee %s matches several clients", attendeeName(attendee))
+		}
+		for id := range users {
+			clients[id] = true
+		}
+	}
+	switch len(clients) {
+	case 0:
+		return nil, "no attendee matches a client of the coach"
+	case 1:
+		for id := range clients {
+			return &id, ""
+		}
+	}
+	return nil, "attendees match several clients, group sessions are not imported"
+}
+
+func attendeeName(attendee ical.Attendee) string {
+	for _, name := range []string{attendee.Name, attendee.Email, attendee.Phone} {
+		if name != "" {
+			return name
+		}
+	}
+	return "without address"
+}
+
+// importPlan holds the active meetings of the coach and of the clients during a dry run. The meetings
+// the dry run would create are added to it, so occurrences conflict with each other as well.
+type importPlan struct {
+	coach   []models.Interval
+	clients map[int][]models.Interval
+}
+
+func (s *ScheduleService) newImportPlan(ctx context.Context, coachID int, occurrences []importOccurrence) (*importPlan, error) {
+	from, to := occurrences[0].start, occurrences[0].end
+	for _, occurrence := range occurrences {
+		if occurrence.end.After(to) {
+			to = occurrence.end
+		}
+	}
+	meetings, err := s.store.GetManagerMeetings(ctx, coachID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("err getting meetings of coach (id %d) from store: %w", coachID, err)
+	}
+	plan := &importPlan{clients: make(map[int][]models.Interval)}
+	for _, meeting := range meetings {
+		plan.coach = append(plan.coach, models.Interval{Start: meeting.StartTime, End: meeting.EndTime})
+	}
+	return plan, nil
+}
+
+// checkImport runs the checks of CreateMeeting against the plan instead of the store. Credits are not checked.
+func (s *ScheduleService) checkImport(ctx context.Context, plan *importPlan, meeting models.MeetingRequest) error {
+	if err := s.checkNewMeeting(ctx, meeting); err != nil {
+		return err
+	}
+	client := *meeting.Client
+	if _, ok := plan.clients[client]; !ok {
+		meetings, err := s.store.GetUserMeetings(ctx, client, time.Now())
+		if err != nil {
+			return fmt.Errorf("err getting meetings of user (id %d) from store: %w", client, err)
+		}
+		intervals := []models.Interval{}
+		for _, m := range meetings {
+			if m.Status == models.StatusRequested || m.Status == models.StatusConfirmed {
+				intervals = append(intervals, models.Interval{Start: m.StartTime, End: m.EndTime})
+			}
+		}
+		plan.clients[client] = intervals
+	}
+	interval := models.Interval{Start: *meeting.StartTime, End: *meeting.EndTime}
+	if overlaps(plan.coach, interval) || overlaps(plan.clients[client], interval) {
+		return models.ErrMeetingConflict
+	}
+	plan.coach = append(plan.coach, interval)
+	plan.clients[client] = append(plan.clients[client], interval)
+	return nil
+}
+
+func overlaps(intervals []models.Interval, interval models.Interval) bool {
+	for _, other := range intervals {
+		if other.Start.Before(interval.End) && interval.Start.Before(other.End) {
+			return true
+		}
+	}
+	return false
+}
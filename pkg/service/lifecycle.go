@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *ScheduleService) ConfirmMeeting(ctx context.Context, id int) (models.Meeting, error) {
+	meeting, err := s.transitionMeeting(ctx, id, models.StatusChange{To: models.StatusConfirmed})
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err confirming meeting (id %d): %w", id, err)
+	}
+	return meeting, nil
+}
+
+// CancelMeeting cancels the meeting on behalf of the user. Cancellations made by anyone but the manager
+// are checked against the cancellation policy of the coach.
+func (s *ScheduleService) CancelMeeting(ctx context.Context, id, userID int, reason *string) (models.Meeting, error) {
+	meeting, err := s.store.GetMeeting(ctx, id)
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err cancelling meeting (id %d): %w", id, err)
+	}
+	change := models.StatusChange{To: models.StatusCancelled, Reason: reason, CancelledBy: &userID}
+	if userID != meeting.Manager {
+		policy, err := s.GetCancellationPolicy(ctx, meeting.Manager)
+		if err != nil {
+			return models.Meeting{}, fmt.Errorf("err cancelling meeting (id %d): %w", id, err)
+		}
+		change.LateCancellation = policy.IsLate(meeting.StartTime, time.Now())
+		if change.LateCancellation && !policy.AllowLateCancellation {
+			return models.Meeting{}, fmt.Errorf("err cancelling meeting (id %d): %w", id, models.ErrLateCancellation)
+		}
+	}
+	cancelledMeeting, err := s.changeMeetingStatus(ctx, meeting, change)
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err cancelling meeting (id %d): %w", id, err)
+	}
+	return cancelledMeeting, nil
+}
+
+func (s *ScheduleService) CompleteMeeting(ctx context.Context, id int) (models.Meeting, error) {
+	meeting, err := s.transitionMeeting(ctx, id, models.StatusChange{To: models.StatusCompleted})
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err completing meeting (id %d): %w", id, err)
+	}
+	return meeting, nil
+}
+
+func (s *ScheduleService) MarkNoShow(ctx context.Context, id int) (models.Meeting, error) {
+	meeting, err := s.transitionMeeting(ctx, id, models.StatusChange{To: models.StatusNoShow})
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err marking meeting (id %d) as no-show: %w", id, err)
+	}
+	return meeting, nil
+}
+
+func (s *ScheduleService) transitionMeeting(ctx context.Context, id int, change models.StatusChange) (models.Meeting, error) {
+	meeting, err := s.store.GetMeeting(ctx, id)
+	if err != nil {
+		return models.Meeting{}, err
+	}
+	if change.IfMatch != nil && !meeting.UpdatedAt.Equal(*change.IfMatch) {
+		return models.Meeting{}, models.ErrPreconditionFailed
+	}
+	return s.changeMeetingStatus(ctx, meeting, change)
+}
+
+// changeMeetingStatus moves the meeting to status change.To. The store compares the current status,
+// so concurrent transitions of the same meeting can't both succeed.
+func (s *ScheduleService) changeMeetingStatus(ctx context.Context, meeting models.Meeting, change models.StatusChange) (models.Meeting, error) {
+	if !models.CanTransition(meeting.Status, change.To) {
+		return models.Meeting{}, models.ErrInvalidTransition.Errorf("%s -> %s", meeting.Status, change.To)
+	}
+	if (change.To == models.StatusCompleted || change.To == models.StatusNoShow) && time.Now().Before(meeting.StartTime) {
+		return models.Meeting{}, models.ErrInvalidTransition.Errorf("meeting has not started yet")
+	}
+	change.From = meeting.Status
+	changedMeeting, err := s.store.SetMeetingStatus(ctx, meeting.ID, change)
+	if err != nil {
+		return models.Meeting{}, err
+	}
+	s.publishMeetingStatus(ctx, changedMeeting)
+	return changedMeeting, nil
+}
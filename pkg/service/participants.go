@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// JoinMeeting adds the client to the group session if it has free places.
+func (s *ScheduleService) JoinMeeting(ctx context.Context, id, clientID int) (models.Meeting, error) {
+	meeting, err := s.store.AddParticipant(ctx, id, clientID)
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err joining meeting (id %d): %w", id, err)
+	}
+	s.publish(ctx, models.EventMeetingUpdated, meeting)
+	return meeting, nil
+}
+
+// LeaveMeeting frees the place of the client in the group session. The credit spent on the session
+// is refunded when the client leaves before the notice period of the cancellation policy.
+func (s *ScheduleService) LeaveMeeting(ctx context.Context, id, clientID int) (models.Meeting, error) {
+	meeting, err := s.store.GetMeeting(ctx, id)
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err leaving meeting (id %d): %w", id, err)
+	}
+	policy, err := s.GetCancellationPolicy(ctx, meeting.Manager)
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err leaving meeting (id %d): %w", id, err)
+	}
+	meeting, err = s.store.RemoveParticipant(ctx, id, clientID, !policy.IsLate(meeting.StartTime, time.Now()))
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err leaving meeting (id %d): %w", id, err)
+	}
+	s.publish(ctx, models.EventMeetingUpdated, meeting)
+	return meeting, nil
+}
+
+func validateCapacity(meeting models.MeetingRequest) error {
+	if meeting.Capacity == nil {
+		return nil
+	}
+	if *meeting.Capacity < 1 {
+		return models.ErrInvalidCapacity.Errorf("capacity must be positive")
+	}
+	if *meeting.Capacity > 1 && meeting.Client != nil {
+		return models.ErrInvalidCapacity.Errorf("clients join group sessions by themselves")
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/recurrence"
+)
+
+func (s *ScheduleService) CreateSeries(ctx context.Context, data models.SeriesRequest) (models.Series, error) {
+	if data.Manager == nil || data.Client == nil || data.StartTime == nil || data.EndTime == nil || data.Rule == nil {
+		return models.Series{}, models.ErrInvalidSeries.Errorf("manager, client, start time, end time and rule are required")
+	}
+	series := models.Series{
+		Manager:   *data.Manager,
+		Client:    *data.Client,
+		StartTime: *data.StartTime,
+		EndTime:   *data.EndTime,
+		TimeZone:  "UTC",
+		Rule:      *data.Rule,
+	}
+	if data.TimeZone != nil {
+		series.TimeZone = *data.TimeZone
+	}
+	occurrences, err := seriesOccurrences(&series, time.Time{})
+	if err != nil {
+		return models.Series{}, err
+	}
+	if err = s.checkAvailability(ctx, series.Manager, occurrences...); err != nil {
+		return models.Series{}, fmt.Errorf("err creating series: %w", err)
+	}
+	createdSeries, err := s.store.CreateSeries(ctx, series, occurrences)
+	if err != nil {
+		return models.Series{}, fmt.Errorf("err creating series: %w", err)
+	}
+	s.publishSeriesChanges(ctx, nil, createdSeries.Meetings)
+	return createdSeries, nil
+}
+
+func (s *ScheduleService) GetSeries(ctx context.Context, id int) (models.Series, error) {
+	series, err := s.store.GetSeries(ctx, id)
+	if err != nil {
+		return models.Series{}, fmt.Errorf("err getting series (id %d) from store: %w", id, err)
+	}
+	return series, nil
+}
+
+// UpdateSeriesMeetings applies data to the series of the meeting. With ScopeAll the series is changed
+// from now on, with ScopeFollowing it is split at the meeting and the returned series starts there.
+// Only the wall clock time of StartTime is used, the dates of occurrences come from the rule.
+func (s *ScheduleService) UpdateSeriesMeetings(ctx context.Context, meetingID, userID int, scope string, data models.SeriesRequest) (models.Series, error) {
+	meeting, series, err := s.meetingSeries(ctx, meetingID)
+	if err != nil {
+		return models.Series{}, err
+	}
+	next := series
+	if data.Manager != nil {
+		next.Manager = *data.Manager
+	}
+	if data.Client != nil {
+		next.Client = *data.Client
+	}
+	if data.TimeZone != nil {
+		next.TimeZone = *data.TimeZone
+	}
+	if data.Rule != nil {
+		next.Rule = *data.Rule
+	}
+	loc, err := time.LoadLocation(next.TimeZone)
+	if err != nil {
+		return models.Series{}, models.ErrInvalidSeries.Errorf("unknown time zone %q", next.TimeZone)
+	}
+	duration := series.EndTime.Sub(series.StartTime)
+	if data.EndTime != nil {
+		start := meeting.StartTime
+		if data.StartTime != nil {
+			start = *data.StartTime
+		}
+		duration = data.EndTime.Sub(start)
+	}
+	clock := series.StartTime.In(loc)
+	if data.StartTime != nil {
+		clock = data.StartTime.In(loc)
+	}
+
+	switch scope {
+	case models.ScopeAll:
+		next.StartTime = withClock(series.StartTime.In(loc), clock)
+		next.EndTime = next.StartTime.Add(duration)
+		now := time.Now()
+		occurrences, err := seriesOccurrences(&next, now)
+		if err != nil {
+			return models.Series{}, err
+		}
+		if err = s.checkAvailability(ctx, next.Manager, occurrences...); err != nil {
+			return models.Series{}, fmt.Errorf("err updating series (id %d): %w", series.ID, err)
+		}
+		updatedSeries, err := s.store.UpdateSeries(ctx, next, now, occurrences, userID)
+		if err != nil {
+			return models.Series{}, fmt.Errorf("err updating series (id %d) in store: %w", series.ID, err)
+		}
+		s.publishSeriesChanges(ctx, series.Meetings, updatedSeries.Meetings)
+		return updatedSeries, nil
+	case models.ScopeFollowing:
+		rule, err := recurrence.Parse(series.Rule)
+		if err != nil {
+			return models.Series{}, fmt.Errorf("err parsing rule of series (id %d): %w", series.ID, err)
+		}
+		anchor := *meeting.OriginalStartTime
+		if data.Rule == nil && rule.Count > 0 {
+			following, err := countFrom(rule, series, anchor)
+			if err != nil {
+				return models.Series{}, err
+			}
+			nextRule := rule
+			nextRule.Count = following
+			next.Rule = nextRule.String()
+		}
+		next.StartTime = withClock(anchor.In(loc), clock)
+		next.EndTime = next.StartTime.Add(duration)
+		occurrences, err := seriesOccurrences(&next, time.Time{})
+		if err != nil {
+			return models.Series{}, err
+		}
+		if err = s.checkAvailability(ctx, next.Manager, occurrences...); err != nil {
+			return models.Series{}, fmt.Errorf("err updating series (id %d): %w", series.ID, err)
+		}
+		nextSeries, err := s.store.SplitSeries(ctx, series.ID, truncatedRule(rule, anchor), anchor, next, occurrences, userID)
+		if err != nil {
+			return models.Series{}, fmt.Errorf("err splitting series (id %d) in store: %w", series.ID, err)
+		}
+		if truncatedSeries, err := s.store.GetSeries(ctx, series.ID); err != nil {
+			s.log.Warnf("err getting split series (id %d), its changes are not published: %v", series.ID, err)
+		} else {
+			s.publishSeriesChanges(ctx, series.Meetings, append(truncatedSeries.Meetings, nextSeries.Meetings...))
+		}
+		return nextSeries, nil
+	default:
+		return models.Series{}, models.ErrInvalidSeries.Errorf("unknown scope %q", scope)
+	}
+}
+
+// DeleteSeriesMeetings cancels the meeting and the following occurrences of its series (ScopeFollowing)
+// or every occurrence which has not started yet (ScopeAll).
+func (s *ScheduleService) DeleteSeriesMeetings(ctx context.Context, meetingID, userID int, scope string) (models.Series, error) {
+	meeting, series, err := s.meetingSeries(ctx, meetingID)
+	if err != nil {
+		return models.Series{}, err
+	}
+	var from time.Time
+	switch scope {
+	case models.ScopeAll:
+		from = time.Now()
+	case models.ScopeFollowing:
+		from = *meeting.OriginalStartTime
+	default:
+		return models.Series{}, models.ErrInvalidSeries.Errorf("unknown scope %q", scope)
+	}
+	rule, err := recurrence.Parse(series.Rule)
+	if err != nil {
+		return models.Series{}, fmt.Errorf("err parsing rule of series (id %d): %w", series.ID, err)
+	}
+	truncatedSeries, err := s.store.TruncateSeries(ctx, series.ID, truncatedRule(rule, from), from, userID)
+	if err != nil {
+		return models.Series{}, fmt.Errorf("err truncating series (id %d) in store: %w", series.ID, err)
+	}
+	s.publishSeriesChanges(ctx, series.Meetings, truncatedSeries.Meetings)
+	return truncatedSeries, nil
+}
+
+func (s *ScheduleService) meetingSeries(ctx context.Context, meetingID int) (models.Meeting, models.Series, error) {
+	meeting, err := s.store.GetMeeting(ctx, meetingID)
+	if err != nil {
+		return models.Meeting{}, models.Series{}, fmt.Errorf("err getting meeting (id %d) from store: %w", meetingID, err)
+	}
+	if meeting.SeriesID == nil || meeting.OriginalStartTime == nil {
+		return models.Meeting{}, models.Series{}, models.ErrNotInSeries
+	}
+	series, err := s.store.GetSeries(ctx, *meeting.SeriesID)
+	if err != nil {
+		return models.Meeting{}, models.Series{}, fmt.Errorf("err getting series (id %d) from store: %w", *meeting.SeriesID, err)
+	}
+	return meeting, series, nil
+}
+
+// seriesOccurrences validates the series, normalises its rule and returns occurrences starting at or after from.
+func seriesOccurrences(series *models.Series, from time.Time) ([]models.Interval, error) {
+	loc, err := time.LoadLocation(series.TimeZone)
+	if err != nil {
+		return nil, models.ErrInvalidSeries.Errorf("unknown time zone %q", series.TimeZone)
+	}
+	if !series.StartTime.Before(series.EndTime) {
+		return nil, models.ErrInvalidSeries.Errorf("start time is not before end time")
+	}
+	rule, err := recurrence.Parse(series.Rule)
+	if err != nil {
+		return nil, models.ErrInvalidSeries.Errorf("%v", err)
+	}
+	series.Rule = rule.String()
+	starts, err := rule.Occurrences(series.StartTime.In(loc))
+	if errors.Is(err, recurrence.ErrTooManyOccurrences) {
+		return nil, models.ErrInvalidSeries.Errorf("%v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	duration := series.EndTime.Sub(series.StartTime)
+	occurrences := make([]models.Interval, 0, len(starts))
+	for _, start := range starts {
+		if start.Before(from) {
+			continue
+		}
+		occurrences = append(occurrences, models.Interval{Start: start, End: start.Add(duration)})
+	}
+	return occurrences, nil
+}
+
+// countFrom returns the number of occurrences of the series which start at or after from.
+func countFrom(rule recurrence.Rule, series models.Series, from time.Time) (int, error) {
+	loc, err := time.LoadLocation(series.TimeZone)
+	if err != nil {
+		return 0, models.ErrInvalidSeries.Errorf("unknown time zone %q", series.TimeZone)
+	}
+	starts, err := rule.Occurrences(series.StartTime.In(loc))
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, start := range starts {
+		if !start.Before(from) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// truncatedRule returns the rule which ends right before from.
+func truncatedRule(rule recurrence.Rule, from time.Time) string {
+	if rule.Count == 0 && !rule.UntilDate && rule.Until.Before(from) {
+		return rule.String()
+	}
+	rule.Count = 0
+	rule.UntilDate = false
+	rule.Until = from.Add(-time.Second)
+	return rule.String()
+}
+
+func withClock(day, clock time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, day.Location())
+}
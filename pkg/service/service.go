@@ -2,54 +2,105 @@ package service
 
 import (
 	"context"
-	"crypto/rsa"
-	"crypto/x509"
-	_ "embed"
-	"encoding/pem"
-	"errors"
 	"fmt"
-	"github.com/golang-jwt/jwt/v4"
-	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/signing"
 	"github.com/sirupsen/logrus"
 )
 
 type Store interface {
-	GetUsers(ctx context.Context) ([]models.User, error)
+	GetUsers(ctx context.Context, filter models.UserFilter) (models.UserPage, error)
 	CreateUser(ctx context.Context, user models.UserRequest) (models.User, error)
 	GetUser(ctx context.Context, id int) (models.User, error)
+	InRoster(ctx context.Context, coach, client int) (bool, error)
 	UpdateUser(ctx context.Context, id int, data models.UserRequest) (models.User, error)
-	DeleteUser(ctx context.Context, id int) (models.User, error)
+	DeleteUser(ctx context.Context, id int, ifMatch *time.Time) (models.User, error)
 	ResetTables(ctx context.Context, table []string) error
-	GetMeetings(ctx context.Context) ([]models.Meeting, error)
+	GetMeetings(ctx context.Context, filter models.MeetingFilter) (models.MeetingPage, error)
 	CreateMeeting(ctx context.Context, meeting models.MeetingRequest) (models.Meeting, error)
 	GetMeeting(ctx context.Context, id int) (models.Meeting, error)
 	UpdateMeeting(ctx context.Context, id int, data models.MeetingRequest) (models.Meeting, error)
-	DeleteMeeting(ctx context.Context, id int) (models.Meeting, error)
+	SetMeetingStatus(ctx context.Context, id int, change models.StatusChange) (models.Meeting, error)
 	GetUserByPhone(ctx context.Context, phone string) (models.User, error)
+	GetAvailability(ctx context.Context, coach int) (models.Availability, error)
+	SetAvailability(ctx context.Context, coach int, data models.AvailabilityRequest) (models.Availability, error)
+	SaveAvailabilityOverride(ctx context.Context, coach int, override models.AvailabilityOverride) (models.AvailabilityOverride, error)
+	DeleteAvailabilityOverride(ctx context.Context, coach, id int) (models.AvailabilityOverride, error)
+	GetManagerMeetings(ctx context.Context, manager int, from, to time.Time) ([]models.Meeting, error)
+	CreateSeries(ctx context.Context, series models.Series, occurrences []models.Interval) (models.Series, error)
+	GetSeries(ctx context.Context, id int) (models.Series, error)
+	UpdateSeries(ctx context.Context, series models.Series, from time.Time, occurrences []models.Interval, cancelledBy int) (models.Series, error)
+	SplitSeries(ctx context.Context, id int, rule string, from time.Time, next models.Series, occurrences []models.Interval, cancelledBy int) (models.Series, error)
+	TruncateSeries(ctx context.Context, id int, rule string, from time.Time, cancelledBy int) (models.Series, error)
+	GetCancellationPolicy(ctx context.Context, coach int) (models.CancellationPolicy, error)
+	SetCancellationPolicy(ctx context.Context, policy models.CancellationPolicy) (models.CancellationPolicy, error)
+	JoinWaitlist(ctx context.Context, entry models.WaitlistEntry) (models.WaitlistEntry, error)
+	GetWaitlist(ctx context.Context, client int) ([]models.WaitlistEntry, error)
+	GetWaitlistEntry(ctx context.Context, id int) (models.WaitlistEntry, error)
+	SetWaitlistStatus(ctx context.Context, id int, from []string, to string) (models.WaitlistEntry, error)
+	AcceptWaitlistOffer(ctx context.Context, id int) (models.Meeting, error)
+	GetWaitlistHolds(ctx context.Context, coach int, from, to time.Time) ([]models.WaitlistEntry, error)
+	AddParticipant(ctx context.Context, id, client int) (models.Meeting, error)
+	RemoveParticipant(ctx context.Context, id, client int, refund bool) (models.Meeting, error)
+	GrantCredits(ctx context.Context, pkg models.CreditPackage) (models.CreditPackage, error)
+	GetCreditPackages(ctx context.Context, client int, coach *int) ([]models.CreditPackage, error)
+	GetCreditLedger(ctx context.Context, client int, coach *int) ([]models.CreditEntry, error)
+	CreateSession(ctx context.Context, session models.Session, token models.RefreshToken) (models.Session, error)
+	CreateLoginFailure(ctx context.Context, failure models.LoginFailure) (models.LoginFailure, error)
+	GetSession(ctx context.Context, id string) (models.Session, error)
+	RotateRefreshToken(ctx context.Context, hash string, next models.RefreshToken) (models.Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	ReserveIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (models.IdempotencyKey, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, key models.IdempotencyKey) error
+	ReleaseIdempotencyKey(ctx context.Context, key models.IdempotencyKey) error
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, coach int) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, coach, id int) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, coach, id int, data models.WebhookRequest) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, coach, id int) (models.Webhook, error)
+	EnqueueWebhookEvent(ctx context.Context, event string, coach *int, payload []byte) (int64, error)
+	GetWebhookDeliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, webhookID, id int) (models.WebhookDelivery, error)
+	SetCalendarToken(ctx context.Context, userID int, hash string) (time.Time, error)
+	GetCalendarToken(ctx context.Context, userID int) (string, error)
+	DeleteCalendarToken(ctx context.Context, userID int) error
+	GetUserMeetings(ctx context.Context, userID int, from time.Time) ([]models.Meeting, error)
+	FindClientsByContact(ctx context.Context, coach int, emails, phones []string) ([]models.User, error)
+	GetImportedMeetings(ctx context.Context, manager int, externalIDs []string) (map[string]int, error)
+	ExportMeetings(ctx context.Context, filter models.MeetingFilter, fn func(models.MeetingExport) error) error
+	ExportUsers(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error
+}
+
+type Calendar interface {
+	BusyIntervals(ctx context.Context, calendarID string, from, to time.Time) ([]models.Interval, error)
 }
 
-//go:embed private_rsa
-var privateSigningKey []byte
-
 type ScheduleService struct {
-	log        *logrus.Entry
-	store      Store
-	privateKey *rsa.PrivateKey
+	log   *logrus.Entry
+	store Store
+	cal   Calendar
+	keys  *signing.Keys
 }
 
-func NewScheduleService(log *logrus.Logger, store Store) *ScheduleService {
+// NewScheduleService creates the service. cal may be nil if there is no external calendar.
+func NewScheduleService(log *logrus.Logger, store Store, cal Calendar, keys *signing.Keys) *ScheduleService {
 	s := ScheduleService{
-		log:        log.WithField("module", "service"),
-		store:      store,
-		privateKey: mustGetPrivateKey(privateSigningKey),
+		log:   log.WithField("module", "service"),
+		store: store,
+		cal:   cal,
+		keys:  keys,
 	}
 	return &s
 }
 
 func (s *ScheduleService) CreateUser(ctx context.Context, user models.UserRequest) (models.User, error) {
+	if err := validateUser(&user, true); err != nil {
+		return models.User{}, fmt.Errorf("err creating user: %w", err)
+	}
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(*user.Password), 0)
 	if err != nil {
 		return models.User{}, fmt.Errorf("err generating from password: %w", err)
@@ -60,13 +111,14 @@ func (s *ScheduleService) CreateUser(ctx context.Context, user models.UserReques
 	if err != nil {
 		return models.User{}, fmt.Errorf("err creating user: %w", err)
 	}
+	s.publish(ctx, models.EventUserCreated, newUser)
 	return newUser, nil
 }
 
-func (s *ScheduleService) GetUsers(ctx context.Context) ([]models.User, error) {
-	users, err := s.store.GetUsers(ctx)
+func (s *ScheduleService) GetUsers(ctx context.Context, filter models.UserFilter) (models.UserPage, error) {
+	users, err := s.store.GetUsers(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("err getting users from store: %w", err)
+		return models.UserPage{}, fmt.Errorf("err getting users from store: %w", err)
 	}
 	return users, nil
 }
@@ -79,7 +131,19 @@ func (s *ScheduleService) GetUser(ctx context.Context, id int) (models.User, err
 	return user, nil
 }
 
+// InRoster reports whether the client has meetings or session packages with the coach.
+func (s *ScheduleService) InRoster(ctx context.Context, coachID, clientID int) (bool, error) {
+	inRoster, err := s.store.InRoster(ctx, coachID, clientID)
+	if err != nil {
+		return false, fmt.Errorf("err checking roster of coach (id %d): %w", coachID, err)
+	}
+	return inRoster, nil
+}
+
 func (s *ScheduleService) UpdateUser(ctx context.Context, id int, data models.UserRequest) (models.User, error) {
+	if err := validateUser(&data, false); err != nil {
+		return models.User{}, fmt.Errorf("err updating user (id %d): %w", id, err)
+	}
 	updatedUser, err := s.store.UpdateUser(ctx, id, data)
 	if err != nil {
 		return models.User{}, fmt.Errorf("err updating user (id %d) from store: %w", id, err)
@@ -87,8 +151,8 @@ func (s *ScheduleService) UpdateUser(ctx context.Context, id int, data models.Us
 	return updatedUser, nil
 }
 
-func (s *ScheduleService) DeleteUser(ctx context.Context, id int) (models.User, error) {
-	deletedUser, err := s.store.DeleteUser(ctx, id)
+func (s *ScheduleService) DeleteUser(ctx context.Context, id int, ifMatch *time.Time) (models.User, error) {
+	deletedUser, err := s.store.DeleteUser(ctx, id, ifMatch)
 	if err != nil {
 		return models.User{}, fmt.Errorf("err deleting user (id %d) from store: %w", id, err)
 	}
@@ -96,17 +160,38 @@ func (s *ScheduleService) DeleteUser(ctx context.Context, id int) (models.User,
 }
 
 func (s *ScheduleService) CreateMeeting(ctx context.Context, meeting models.MeetingRequest) (models.Meeting, error) {
+	if err := s.checkNewMeeting(ctx, meeting); err != nil {
+		return models.Meeting{}, fmt.Errorf("err creating meeting: %w", err)
+	}
 	createdMeeting, err := s.store.CreateMeeting(ctx, meeting)
 	if err != nil {
 		return models.Meeting{}, fmt.Errorf("err creating meeting: %w", err)
 	}
+	s.publish(ctx, models.EventMeetingCreated, createdMeeting)
 	return createdMeeting, nil
 }
 
-func (s *ScheduleService) GetMeetings(ctx context.Context) ([]models.Meeting, error) {
-	meetings, err := s.store.GetMeetings(ctx)
+// checkNewMeeting runs the checks of CreateMeeting which come before the store, conflicts and credits are checked there.
+func (s *ScheduleService) checkNewMeeting(ctx context.Context, meeting models.MeetingRequest) error {
+	if meeting.Status != nil && *meeting.Status != models.StatusRequested && *meeting.Status != models.StatusConfirmed {
+		return fmt.Errorf("%w: %s", models.ErrInvalidStatus, *meeting.Status)
+	}
+	if err := validateCapacity(meeting); err != nil {
+		return err
+	}
+	if err := s.validateNewMeeting(ctx, meeting); err != nil {
+		return err
+	}
+	if err := s.checkAvailability(ctx, *meeting.Manager, models.Interval{Start: *meeting.StartTime, End: *meeting.EndTime}); err != nil {
+		return err
+	}
+	return s.checkWaitlistHolds(ctx, *meeting.Manager, meeting.Client, *meeting.StartTime, *meeting.EndTime)
+}
+
+func (s *ScheduleService) GetMeetings(ctx context.Context, filter models.MeetingFilter) (models.MeetingPage, error) {
+	meetings, err := s.store.GetMeetings(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("err getting meetings: %w", err)
+		return models.MeetingPage{}, fmt.Errorf("err getting meetings: %w", err)
 	}
 	return meetings, nil
 }
@@ -120,54 +205,49 @@ func (s *ScheduleService) GetMeeting(ctx context.Context, id int) (models.Meetin
 }
 
 func (s *ScheduleService) UpdateMeeting(ctx context.Context, id int, data models.MeetingRequest) (models.Meeting, error) {
+	meeting, err := s.store.GetMeeting(ctx, id)
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err updating meeting (id %d) from store: %w", id, err)
+	}
+	if data.Manager != nil {
+		meeting.Manager = *data.Manager
+	}
+	if data.StartTime != nil {
+		meeting.StartTime = *data.StartTime
+	}
+	if data.EndTime != nil {
+		meeting.EndTime = *data.EndTime
+	}
+	// The credit of a booked meeting is spent by its client, so another client cancels it and books a new one.
+	var errs fieldErrors
+	if data.Client != nil && *data.Client != meeting.Client {
+		errs.add("client", "can't be changed, cancel the meeting and book a new one")
+	}
+	if err = s.validateMeeting(ctx, errs, data, meeting.StartTime, meeting.EndTime); err != nil {
+		return models.Meeting{}, fmt.Errorf("err updating meeting (id %d): %w", id, err)
+	}
+	if data.Manager != nil || data.StartTime != nil || data.EndTime != nil {
+		if err = s.checkAvailability(ctx, meeting.Manager, models.Interval{Start: meeting.StartTime, End: meeting.EndTime}); err != nil {
+			return models.Meeting{}, fmt.Errorf("err updating meeting (id %d): %w", id, err)
+		}
+		if err = s.checkWaitlistHolds(ctx, meeting.Manager, &meeting.Client, meeting.StartTime, meeting.EndTime); err != nil {
+			return models.Meeting{}, fmt.Errorf("err updating meeting (id %d): %w", id, err)
+		}
+	}
 	updatedMeeting, err := s.store.UpdateMeeting(ctx, id, data)
 	if err != nil {
 		return models.Meeting{}, fmt.Errorf("err updating meeting (id %d) from store: %w", id, err)
 	}
+	s.publish(ctx, models.EventMeetingUpdated, updatedMeeting)
 	return updatedMeeting, nil
 }
 
-func (s *ScheduleService) DeleteMeeting(ctx context.Context, id int) (models.Meeting, error) {
-	deletedMeeting, err := s.store.DeleteMeeting(ctx, id)
+// DeleteMeeting cancels the meeting. Meetings are never removed, so their history is kept.
+// With ifMatch set the meeting must not have changed since that moment.
+func (s *ScheduleService) DeleteMeeting(ctx context.Context, id int, ifMatch *time.Time) (models.Meeting, error) {
+	deletedMeeting, err := s.transitionMeeting(ctx, id, models.StatusChange{To: models.StatusCancelled, IfMatch: ifMatch})
 	if err != nil {
 		return models.Meeting{}, fmt.Errorf("err deleting meeting (id %d) from store: %w", id, err)
 	}
 	return deletedMeeting, nil
 }
-
-func (s *ScheduleService) Login(ctx context.Context, phone, password string) (string, error) {
-	user, err := s.store.GetUserByPhone(ctx, phone)
-	switch {
-	case errors.Is(err, pgstore.ErrUserNotFound):
-		return "", models.ErrInvalidCredentials
-	case err != nil:
-		return "", fmt.Errorf("err login: %w", err)
-	}
-	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", models.ErrInvalidCredentials
-	}
-	return s.generateToken(user)
-}
-
-func (s *ScheduleService) generateToken(user models.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, &models.Claims{
-		UserID: user.ID,
-		Role:   user.Role,
-	})
-	return token.SignedString(s.privateKey)
-}
-
-func mustGetPrivateKey(keyBytes []byte) *rsa.PrivateKey {
-	if len(keyBytes) == 0 {
-		panic("env PRIVATE_SIGNING_KEY not set")
-	}
-	block, _ := pem.Decode(keyBytes)
-	if block == nil {
-		panic("unable to decode private key to blocks")
-	}
-	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
-	if err != nil {
-		panic(err)
-	}
-	return key
-}
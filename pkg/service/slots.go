@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+)
+
+const maxSlotRange = 31 * 24 * time.Hour
+
+// GetSlots returns start times between from and to at which a meeting of the given duration
+// can be booked with the coach. Slots follow the coach availability and skip existing meetings,
+// slots held for waitlisted clients and busy events of the external calendar.
+func (s *ScheduleService) GetSlots(ctx context.Context, coachID int, from, to time.Time, duration time.Duration) ([]models.Slot, error) {
+	if duration <= 0 || !from.Before(to) || to.Sub(from) > maxSlotRange {
+		return nil, models.ErrInvalidSlotRange
+	}
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+	slots := make([]models.Slot, 0)
+	if !from.Before(to) {
+		return slots, nil
+	}
+
+	availability, err := s.store.GetAvailability(ctx, coachID)
+	switch {
+	case errors.Is(err, pgstore.ErrAvailabilityNotFound):
+		return slots, nil
+	case err != nil:
+		return nil, fmt.Errorf("err getting availability (coach %d) from store: %w", coachID, err)
+	}
+	free, err := availabilityWindows(availability, from, to)
+	if err != nil {
+		return nil, err
+	}
+	busy, err := s.busyIntervals(ctx, coachID, availability.CalendarID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range busy {
+		free = subtract(free, b)
+	}
+
+	for _, window := range free {
+		if window.start.Before(from) {
+			window.start = from
+		}
+		if window.end.After(to) {
+			window.end = to
+		}
+		for start := window.start; !start.Add(duration).After(window.end); start = start.Add(duration) {
+			slots = append(slots, models.Slot{StartTime: start, EndTime: start.Add(duration)})
+		}
+	}
+	return slots, nil
+}
+
+// busyIntervals returns the meetings and waitlist holds of the coach and the busy events of calendarID,
+// the external calendar of the coach.
+func (s *ScheduleService) busyIntervals(ctx context.Context, coachID int, calendarID string, from, to time.Time) ([]interval, error) {
+	meetings, err := s.store.GetManagerMeetings(ctx, coachID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("err getting meetings (coach %d) from store: %w", coachID, err)
+	}
+	busy := make([]interval, 0, len(meetings))
+	for _, meeting := range meetings {
+		busy = append(busy, interval{start: meeting.StartTime, end: meeting.EndTime})
+	}
+	holds, err := s.store.GetWaitlistHolds(ctx, coachID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("err getting waitlist holds (coach %d) from store: %w", coachID, err)
+	}
+	for _, hold := range holds {
+		busy = append(busy, interval{start: hold.StartTime, end: hold.EndTime})
+	}
+	if s.cal == nil || calendarID == "" {
+		return busy, nil
+	}
+	events, err := s.cal.BusyIntervals(ctx, calendarID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("err getting busy intervals (coach %d) from calendar: %w", coachID, err)
+	}
+	for _, event := range events {
+		busy = append(busy, interval{start: event.Start, end: event.End})
+	}
+	return busy, nil
+}
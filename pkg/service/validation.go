@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+)
+
+const (
+	minPhoneDigits = 8
+	maxPhoneDigits = 15
+)
+
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// fieldErrors collects the invalid fields of a request, so that all of them are reported at once.
+type fieldErrors []models.FieldError
+
+func (e *fieldErrors) add(field, message string) {
+	*e = append(*e, models.FieldError{Field: field, Message: message})
+}
+
+func (e fieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return models.NewValidationError(e...)
+}
+
+// validateUser checks the user and normalises its phone. Missing fields are only reported for new users,
+// an update keeps them as they are.
+func validateUser(user *models.UserRequest, create bool) error {
+	var errs fieldErrors
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"lastName", user.LastName},
+		{"firstName", user.FirstName},
+		{"phone", user.Phone},
+		{"password", user.Password},
+	} {
+		if field.value == nil && create || field.value != nil && strings.TrimSpace(*field.value) == "" {
+			errs.add(field.name, "is required")
+		}
+	}
+	if user.Phone != nil && strings.TrimSpace(*user.Phone) != "" {
+		if phone, ok := normalizePhone(*user.Phone); ok {
+			user.Phone = &phone
+		} else {
+			errs.add("phone", "must be an international number such as +79991234567")
+		}
+	}
+	if user.Email != nil && *user.Email != "" && !validEmail(*user.Email) {
+		errs.add("email", "is not a valid email address")
+	}
+	if user.TimeZone != nil {
+		if _, err := time.LoadLocation(*user.TimeZone); err != nil || *user.TimeZone == "" {
+			errs.add("timeZone", fmt.Sprintf("unknown time zone %q", *user.TimeZone))
+		}
+	}
+	return errs.err()
+}
+
+// normalizePhone formats the phone as E.164. A phone without the leading + is taken to start with the
+// country code, the way Telegram shares contacts.
+func normalizePhone(phone string) (string, bool) {
+	digits := strings.TrimPrefix(phoneSeparators.Replace(phone), "+")
+	if len(digits) < minPhoneDigits || len(digits) > maxPhoneDigits || digits[0] == '0' {
+		return phone, false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return phone, false
+		}
+	}
+	return "+" + digits, true
+}
+
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// validateWebhook checks the webhook. The url and the events are required for new webhooks.
+func validateWebhook(data models.WebhookRequest, create bool) error {
+	var errs fieldErrors
+	switch {
+	case data.URL == nil && create || data.URL != nil && strings.TrimSpace(*data.URL) == "":
+		errs.add("url", "is required")
+	case data.URL != nil && !validWebhookURL(*data.URL):
+		errs.add("url", "must be an absolute http or https URL")
+	}
+	if data.Events == nil && create || data.Events != nil && len(data.Events) == 0 {
+		errs.add("events", "is required")
+	}
+	for _, event := range data.Events {
+		if !models.IsWebhookEvent(event) {
+			errs.add("events", fmt.Sprintf("unknown event %q", event))
+		}
+	}
+	return errs.err()
+}
+
+func validWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validateNewMeeting checks the meeting to be created.
+func (s *ScheduleService) validateNewMeeting(ctx context.Context, meeting models.MeetingRequest) error {
+	var errs fieldErrors
+	if meeting.Manager == nil {
+		errs.add("manager", "is required")
+	}
+	if meeting.Client == nil && (meeting.Capacity == nil || *meeting.Capacity <= 1) {
+		errs.add("client", "is required")
+	}
+	var start, end time.Time
+	if meeting.StartTime == nil {
+		errs.add("startTime", "is required")
+	} else {
+		start = *meeting.StartTime
+	}
+	if meeting.EndTime == nil {
+		errs.add("endTime", "is required")
+	} else {
+		end = *meeting.EndTime
+	}
+	return s.validateMeeting(ctx, errs, meeting, start, end)
+}
+
+// validateMeeting adds the errors of the request to errs. start and end are the times of the meeting after the request.
+func (s *ScheduleService) validateMeeting(ctx context.Context, errs fieldErrors, data models.MeetingRequest, start, end time.Time) error {
+	if !start.IsZero() && !end.IsZero() {
+		switch duration := end.Sub(start); {
+		case duration <= 0:
+			errs.add("endTime", "must be after startTime")
+		case duration < models.MinMeetingDuration:
+			errs.add("endTime", fmt.Sprintf("meeting must be at least %s long", models.MinMeetingDuration))
+		case duration > models.MaxMeetingDuration:
+			errs.add("endTime", fmt.Sprintf("meeting must be at most %s long", models.MaxMeetingDuration))
+		}
+	}
+	if data.StartTime != nil && !data.StartTime.After(time.Now()) {
+		errs.add("startTime", "must be in the future")
+	}
+	for _, ref := range []struct {
+		field string
+		id    *int
+	}{
+		{"manager", data.Manager},
+		{"client", data.Client},
+	} {
+		if ref.id == nil {
+			continue
+		}
+		_, err := s.store.GetUser(ctx, *ref.id)
+		switch {
+		case errors.Is(err, pgstore.ErrUserNotFound):
+			errs.add(ref.field, fmt.Sprintf("user %d not found", *ref.id))
+		case err != nil:
+			return fmt.Errorf("err getting user (id %d) from store: %w", *ref.id, err)
+		}
+	}
+	return errs.err()
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+)
+
+// JoinWaitlist puts the client in the queue for a taken slot of the coach.
+func (s *ScheduleService) JoinWaitlist(ctx context.Context, coachID, clientID int, data models.WaitlistRequest) (models.WaitlistEntry, error) {
+	if data.StartTime == nil || data.EndTime == nil || !data.StartTime.Before(*data.EndTime) || data.StartTime.Before(time.Now()) {
+		return models.WaitlistEntry{}, models.ErrInvalidWaitlistSlot
+	}
+	entry, err := s.store.JoinWaitlist(ctx, models.WaitlistEntry{
+		CoachID:   coachID,
+		ClientID:  clientID,
+		StartTime: *data.StartTime,
+		EndTime:   *data.EndTime,
+	})
+	if err != nil {
+		return models.WaitlistEntry{}, fmt.Errorf("err joining waitlist (coach %d): %w", coachID, err)
+	}
+	return entry, nil
+}
+
+func (s *ScheduleService) GetWaitlist(ctx context.Context, clientID int) ([]models.WaitlistEntry, error) {
+	entries, err := s.store.GetWaitlist(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("err getting waitlist (client %d) from store: %w", clientID, err)
+	}
+	return entries, nil
+}
+
+func (s *ScheduleService) LeaveWaitlist(ctx context.Context, id, clientID int) (models.WaitlistEntry, error) {
+	if _, err := s.clientWaitlistEntry(ctx, id, clientID); err != nil {
+		return models.WaitlistEntry{}, fmt.Errorf("err leaving waitlist (id %d): %w", id, err)
+	}
+	entry, err := s.store.SetWaitlistStatus(ctx, id, []string{models.WaitlistWaiting, models.WaitlistOffered}, models.WaitlistLeft)
+	if err != nil {
+		return models.WaitlistEntry{}, fmt.Errorf("err leaving waitlist (id %d): %w", id, err)
+	}
+	return entry, nil
+}
+
+// AcceptWaitlistOffer books the slot offered to the client.
+func (s *ScheduleService) AcceptWaitlistOffer(ctx context.Context, id, clientID int) (models.Meeting, error) {
+	entry, err := s.clientWaitlistEntry(ctx, id, clientID)
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err accepting waitlist offer (id %d): %w", id, err)
+	}
+	if entry.Status != models.WaitlistOffered {
+		return models.Meeting{}, fmt.Errorf("err accepting waitlist offer (id %d): %w", id, models.ErrWaitlistEntryClosed)
+	}
+	meeting, err := s.store.AcceptWaitlistOffer(ctx, id)
+	if err != nil {
+		return models.Meeting{}, fmt.Errorf("err accepting waitlist offer (id %d): %w", id, err)
+	}
+	s.publish(ctx, models.EventMeetingCreated, meeting)
+	return meeting, nil
+}
+
+// DeclineWaitlistOffer gives up the offered slot, so it is offered to the next client.
+func (s *ScheduleService) DeclineWaitlistOffer(ctx context.Context, id, clientID int) (models.WaitlistEntry, error) {
+	if _, err := s.clientWaitlistEntry(ctx, id, clientID); err != nil {
+		return models.WaitlistEntry{}, fmt.Errorf("err declining waitlist offer (id %d): %w", id, err)
+	}
+	entry, err := s.store.SetWaitlistStatus(ctx, id, []string{models.WaitlistOffered}, models.WaitlistDeclined)
+	if err != nil {
+		return models.WaitlistEntry{}, fmt.Errorf("err declining waitlist offer (id %d): %w", id, err)
+	}
+	return entry, nil
+}
+
+// clientWaitlistEntry returns the entry if it belongs to the client. Entries of other clients are reported as not found.
+func (s *ScheduleService) clientWaitlistEntry(ctx context.Context, id, clientID int) (models.WaitlistEntry, error) {
+	entry, err := s.store.GetWaitlistEntry(ctx, id)
+	if err != nil {
+		return models.WaitlistEntry{}, err
+	}
+	if entry.ClientID != clientID {
+		return models.WaitlistEntry{}, pgstore.ErrWaitlistEntryNotFound
+	}
+	return entry, nil
+}
+
+// checkWaitlistHolds rejects a meeting which takes a slot held for another waitlisted client.
+func (s *ScheduleService) checkWaitlistHolds(ctx context.Context, coachID int, clientID *int, from, to time.Time) error {
+	holds, err := s.store.GetWaitlistHolds(ctx, coachID, from, to)
+	if err != nil {
+		return fmt.Errorf("err getting waitlist holds (coach %d) from store: %w", coachID, err)
+	}
+	for _, hold := range holds {
+		if clientID == nil || hold.ClientID != *clientID {
+			return models.ErrSlotHeld
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+// deliveryLogLimit is how many of the latest deliveries the delivery log shows.
+const deliveryLogLimit = 100
+
+func (s *ScheduleService) CreateWebhook(ctx context.Context, coachID int, data models.WebhookRequest) (models.Webhook, error) {
+	if err := validateWebhook(data, true); err != nil {
+		return models.Webhook{}, fmt.Errorf("err creating webhook: %w", err)
+	}
+	secret, err := newRandomToken()
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	webhook := models.Webhook{CoachID: coachID, URL: *data.URL, Events: data.Events, Active: true, Secret: secret}
+	if data.Active != nil {
+		webhook.Active = *data.Active
+	}
+	createdWebhook, err := s.store.CreateWebhook(ctx, webhook)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("err creating webhook: %w", err)
+	}
+	return createdWebhook, nil
+}
+
+func (s *ScheduleService) GetWebhooks(ctx context.Context, coachID int) ([]models.Webhook, error) {
+	webhooks, err := s.store.GetWebhooks(ctx, coachID)
+	if err != nil {
+		return nil, fmt.Errorf("err getting webhooks (coach %d) from store: %w", coachID, err)
+	}
+	return webhooks, nil
+}
+
+func (s *ScheduleService) GetWebhook(ctx context.Context, coachID, id int) (models.Webhook, error) {
+	webhook, err := s.store.GetWebhook(ctx, coachID, id)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("err getting webhook (id %d) from store: %w", id, err)
+	}
+	return webhook, nil
+}
+
+func (s *ScheduleService) UpdateWebhook(ctx context.Context, coachID, id int, data models.WebhookRequest) (models.Webhook, error) {
+	if err := validateWebhook(data, false); err != nil {
+		return models.Webhook{}, fmt.Errorf("err updating webhook (id %d): %w", id, err)
+	}
+	webhook, err := s.store.UpdateWebhook(ctx, coachID, id, data)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("err updating webhook (id %d) in store: %w", id, err)
+	}
+	return webhook, nil
+}
+
+func (s *ScheduleService) DeleteWebhook(ctx context.Context, coachID, id int) (models.Webhook, error) {
+	webhook, err := s.store.DeleteWebhook(ctx, coachID, id)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("err deleting webhook (id %d) from store: %w", id, err)
+	}
+	return webhook, nil
+}
+
+// GetWebhookDeliveries returns the delivery log of the webhook.
+func (s *ScheduleService) GetWebhookDeliveries(ctx context.Context, coachID, id int) ([]models.WebhookDelivery, error) {
+	if _, err := s.store.GetWebhook(ctx, coachID, id); err != nil {
+		return nil, fmt.Errorf("err getting deliveries of webhook (id %d): %w", id, err)
+	}
+	deliveries, err := s.store.GetWebhookDeliveries(ctx, id, deliveryLogLimit)
+	if err != nil {
+		return nil, fmt.Errorf("err getting deliveries of webhook (id %d) from store: %w", id, err)
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhookDelivery sends the payload of the delivery once more, as a new delivery.
+func (s *ScheduleService) RedeliverWebhookDelivery(ctx context.Context, coachID, id, deliveryID int) (models.WebhookDelivery, error) {
+	if _, err := s.store.GetWebhook(ctx, coachID, id); err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("err redelivering delivery (id %d): %w", deliveryID, err)
+	}
+	delivery, err := s.store.RedeliverWebhookDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("err redelivering delivery (id %d): %w", deliveryID, err)
+	}
+	return delivery, nil
+}
+
+// publish queues the event for the subscribed webhooks. The change has already been made,
+// so a failure is only logged. Meeting events go to the webhooks of the managing coach.
+func (s *ScheduleService) publish(ctx context.Context, event string, data interface{}) {
+	var coach *int
+	if meeting, ok := data.(models.Meeting); ok {
+		coach = &meeting.Manager
+	}
+	payload, err := json.Marshal(models.WebhookEvent{Event: event, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		s.log.Warnf("err encoding %s event: %v", event, err)
+		return
+	}
+	if _, err = s.store.EnqueueWebhookEvent(ctx, event, coach, payload); err != nil {
+		s.log.Warnf("err publishing %s event: %v", event, err)
+	}
+}
+
+// publishMeetingStatus reports a status change of the meeting.
+func (s *ScheduleService) publishMeetingStatus(ctx context.Context, meeting models.Meeting) {
+	event := models.EventMeetingUpdated
+	if meeting.Status == models.StatusCancelled {
+		event = models.EventMeetingCancelled
+	}
+	s.publish(ctx, event, meeting)
+}
+
+// publishSeriesChanges reports the meetings of a series which were created or cancelled by a change
+// of the series. Occurrences are replaced when a series changes, so moved meetings are reported as
+// a cancelled meeting and a created one.
+func (s *ScheduleService) publishSeriesChanges(ctx context.Context, before, after []models.Meeting) {
+	kept := make(map[int]bool, len(after))
+	for _, meeting := range after {
+		kept[meeting.ID] = true
+	}
+	existed := make(map[int]bool, len(before))
+	for _, meeting := range before {
+		existed[meeting.ID] = true
+		if !kept[meeting.ID] {
+			meeting.Status = models.StatusCancelled
+			s.publish(ctx, models.EventMeetingCancelled, meeting)
+		}
+	}
+	for _, meeting := range after {
+		if !existed[meeting.ID] {
+			s.publish(ctx, models.EventMeetingCreated, meeting)
+		}
+	}
+}
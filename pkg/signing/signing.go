@@ -0,0 +1,220 @@
+package signing
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const defaultKeyID = "default"
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Config tells Load where to find the keys.
+type Config struct {
+	// Dir holds the keys as <kid>.pem for private keys and <kid>.pub for public keys of retired keys.
+	Dir string
+	// PrivateKey is a PEM encoded private key, for example taken from the environment. Its kid is ActiveKeyID or "default".
+	PrivateKey string
+	// ActiveKeyID selects the key new tokens are signed with. The last private key in lexical order is used if it is empty.
+	ActiveKeyID string
+}
+
+// Keys holds the RSA keys tokens are signed and verified with. Tokens are signed with the active key and verified
+// with the key named by their kid, so tokens signed with a retired key stay valid until they expire.
+type Keys struct {
+	active  string
+	private map[string]*rsa.PrivateKey
+	public  map[string]*rsa.PublicKey
+}
+
+// New creates keys signing with the private key active. Public keys of the private keys are added for verification.
+func New(active string, private map[string]*rsa.PrivateKey, public map[string]*rsa.PublicKey) (*Keys, error) {
+	k := Keys{
+		active:  active,
+		private: private,
+		public:  make(map[string]*rsa.PublicKey),
+	}
+	if _, ok := private[active]; !ok {
+		return nil, fmt.Errorf("%w: active key %q has no private key", ErrUnknownKey, active)
+	}
+	for kid, key := range public {
+		k.public[kid] = key
+	}
+	for kid, key := range private {
+		k.public[kid] = &key.PublicKey
+	}
+	return &k, nil
+}
+
+// Generate creates keys with a single new private key.
+func Generate(kid string) (*Keys, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("err generating key: %w", err)
+	}
+	return New(kid, map[string]*rsa.PrivateKey{kid: key}, nil)
+}
+
+// Load reads the keys described by cfg.
+func Load(cfg Config) (*Keys, error) {
+	private := make(map[string]*rsa.PrivateKey)
+	public := make(map[string]*rsa.PublicKey)
+	if cfg.PrivateKey != "" {
+		kid := cfg.ActiveKeyID
+		if kid == "" {
+			kid = defaultKeyID
+		}
+		key, err := parsePrivateKey([]byte(cfg.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("err parsing private key %s: %w", kid, err)
+		}
+		private[kid] = key
+	}
+	if cfg.Dir != "" {
+		if err := readDir(cfg.Dir, private, public); err != nil {
+			return nil, err
+		}
+	}
+	if len(private) == 0 {
+		return nil, errors.New("no private signing key found")
+	}
+	active := cfg.ActiveKeyID
+	if active == "" {
+		kids := make([]string, 0, len(private))
+		for kid := range private {
+			kids = append(kids, kid)
+		}
+		sort.Strings(kids)
+		active = kids[len(kids)-1]
+	}
+	return New(active, private, public)
+}
+
+func readDir(dir string, private map[string]*rsa.PrivateKey, public map[string]*rsa.PublicKey) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("err reading keys from %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || ext != ".pem" && ext != ".pub" {
+			continue
+		}
+		kid := strings.TrimSuffix(entry.Name(), ext)
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("err reading key %s: %w", kid, err)
+		}
+		if ext == ".pem" {
+			if private[kid], err = parsePrivateKey(data); err != nil {
+				return fmt.Errorf("err parsing private key %s: %w", kid, err)
+			}
+			continue
+		}
+		if public[kid], err = parsePublicKey(data); err != nil {
+			return fmt.Errorf("err parsing public key %s: %w", kid, err)
+		}
+	}
+	return nil
+}
+
+// Sign signs the claims with the active key and names it in the kid header.
+func (k *Keys) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = k.active
+	return token.SignedString(k.private[k.active])
+}
+
+// Keyfunc returns the public key a token was signed with, for use with jwt.Parse.
+func (k *Keys) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("invalid signing method: %v", token.Header["alg"])
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.public[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// JWK is an RSA public key in the JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns all public keys ordered by kid.
+func (k *Keys) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(k.public))}
+	for kid, key := range k.public {
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("unable to decode private key to blocks")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return rsaKey, nil
+}
+
+func parsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("unable to decode public key to blocks")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return rsaKey, nil
+}
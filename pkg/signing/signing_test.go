@@ -0,0 +1,67 @@
+package signing
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotation(t *testing.T) {
+	old, err := Generate("2023-01")
+	require.NoError(t, err)
+	oldToken, err := old.Sign(jwt.RegisteredClaims{Subject: "old"})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeKey(t, filepath.Join(dir, "2023-01.pub"), "PUBLIC KEY", func() ([]byte, error) {
+		return x509.MarshalPKIXPublicKey(old.public["2023-01"])
+	})
+	next, err := Generate("2023-06")
+	require.NoError(t, err)
+	writeKey(t, filepath.Join(dir, "2023-06.pem"), "RSA PRIVATE KEY", func() ([]byte, error) {
+		return x509.MarshalPKCS1PrivateKey(next.private["2023-06"]), nil
+	})
+
+	keys, err := Load(Config{Dir: dir})
+	require.NoError(t, err)
+	newToken, err := keys.Sign(jwt.RegisteredClaims{Subject: "new"})
+	require.NoError(t, err)
+
+	for subject, token := range map[string]string{"old": oldToken, "new": newToken} {
+		var claims jwt.RegisteredClaims
+		_, err = jwt.ParseWithClaims(token, &claims, keys.Keyfunc)
+		require.NoError(t, err)
+		require.Equal(t, subject, claims.Subject)
+	}
+	_, err = jwt.Parse(newToken, old.Keyfunc)
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	set := keys.JWKS()
+	require.Len(t, set.Keys, 2)
+	require.Equal(t, "2023-01", set.Keys[0].Kid)
+	require.Equal(t, "2023-06", set.Keys[1].Kid)
+	require.Equal(t, "AQAB", set.Keys[1].E)
+}
+
+func TestLoadWithoutPrivateKey(t *testing.T) {
+	_, err := Load(Config{Dir: t.TempDir()})
+	require.Error(t, err)
+	_, err = Load(Config{PrivateKey: "not a key"})
+	require.Error(t, err)
+	_, err = New("missing", map[string]*rsa.PrivateKey{}, nil)
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func writeKey(t *testing.T, path, blockType string, marshal func() ([]byte, error)) {
+	t.Helper()
+	der, err := marshal()
+	require.NoError(t, err)
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600)
+	require.NoError(t, err)
+}
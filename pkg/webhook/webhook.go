@@ -0,0 +1,138 @@
+// Package webhook signs and sends webhook deliveries.
+//
+// A delivery is a POST of the JSON payload with the headers below. The signature is
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<payload>" keyed with the secret
+// of the webhook, so receivers can check the payload came from us and reject replays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	EventHeader     = "X-TimeSlots-Event"
+	DeliveryHeader  = "X-TimeSlots-Delivery"
+	TimestampHeader = "X-TimeSlots-Timestamp"
+	SignatureHeader = "X-TimeSlots-Signature"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it is given up.
+	MaxAttempts = 10
+	// firstRetry is the delay after the first failed attempt, it doubles with every attempt up to maxRetry.
+	firstRetry = 30 * time.Second
+	maxRetry   = 2 * time.Hour
+	// maxErrorBody is how much of the response of a failed attempt is kept in the error.
+	maxErrorBody = 512
+)
+
+// Message is a delivery to send.
+type Message struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int
+	Payload    []byte
+}
+
+// Sign returns the signature of the payload sent at timestamp.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of the payload in constant time.
+func Verify(secret, signature string, timestamp int64, payload []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, payload)))
+}
+
+// Backoff returns the delay before the next attempt after attempts failed ones.
+func Backoff(attempts int) time.Duration {
+	delay := firstRetry
+	for i := 1; i < attempts && delay < maxRetry; i++ {
+		delay *= 2
+	}
+	if delay > maxRetry {
+		delay = maxRetry
+	}
+	return delay
+}
+
+// ErrPrivateAddress is returned when a receiver resolves to an address of our own network.
+var ErrPrivateAddress = errors.New("receiver address is not public")
+
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender which gives up on a receiver after timeout. Unless allowPrivate is set it refuses to
+// connect to loopback, private and link-local addresses, so a webhook can't be used to reach our internal network.
+// The address is checked when it is dialed, which covers redirects and names resolving to internal addresses.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = publicOnly
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the receiver, so the check would never see the receiver.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &Sender{client: &http.Client{Timeout: timeout, Transport: transport}}
+}
+
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+// Send posts the message. It returns the status of the response, which is 0 if there was none,
+// and an error unless the receiver answered with 2xx.
+func (s *Sender) Send(ctx context.Context, msg Message) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.URL, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("create webhook request faild: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TimeSlots-Webhook")
+	req.Header.Set(EventHeader, msg.Event)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(msg.DeliveryID))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(msg.Secret, timestamp, msg.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send webhook faild: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("receiver answered %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
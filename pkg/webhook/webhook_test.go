@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	const secret = "secret"
+	payload := []byte(`{"event":"meeting.created"}`)
+	status := http.StatusNoContent
+	var received *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("try later\n"))
+	}))
+	defer receiver.Close()
+
+	sender := NewSender(time.Second, true)
+	msg := Message{URL: receiver.URL, Secret: secret, Event: "meeting.created", DeliveryID: 7, Payload: payload}
+	code, err := sender.Send(context.Background(), msg)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, code)
+	require.Equal(t, payload, body)
+	require.Equal(t, "meeting.created", received.Header.Get(EventHeader))
+	require.Equal(t, "7", received.Header.Get(DeliveryHeader))
+	timestamp, err := strconv.ParseInt(received.Header.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	require.True(t, Verify(secret, received.Header.Get(SignatureHeader), timestamp, body))
+	require.False(t, Verify("other", received.Header.Get(SignatureHeader), timestamp, body))
+	require.False(t, Verify(secret, received.Header.Get(SignatureHeader), timestamp+1, body))
+
+	status = http.StatusServiceUnavailable
+	code, err = sender.Send(context.Background(), msg)
+	require.EqualError(t, err, "receiver answered 503: try later")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	receiver.Close()
+	code, err = sender.Send(context.Background(), msg)
+	require.Error(t, err)
+	require.Zero(t, code)
+}
+
+func TestSendRefusesPrivateAddresses(t *testing.T) {
+	var called bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	sender := NewSender(time.Second, false)
+	code, err := sender.Send(context.Background(), Message{URL: receiver.URL, Event: "meeting.created", Payload: []byte(`{}`)})
+	require.ErrorIs(t, err, ErrPrivateAddress)
+	require.Zero(t, code)
+	require.False(t, called)
+
+	for _, address := range []string{"10.0.0.1:80", "172.16.0.1:80", "192.168.1.1:443", "169.254.169.254:80", "[::1]:80", "[fd00::1]:80", "0.0.0.0:80"} {
+		require.ErrorIs(t, publicOnly("tcp", address, nil), ErrPrivateAddress, address)
+	}
+	require.NoError(t, publicOnly("tcp", "93.184.216.34:443", nil))
+	require.NoError(t, publicOnly("tcp6", "[2606:2800:220:1::]:443", nil))
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 30*time.Second, Backoff(1))
+	require.Equal(t, time.Minute, Backoff(2))
+	require.Equal(t, 4*time.Minute, Backoff(4))
+	require.Equal(t, 2*time.Hour, Backoff(MaxAttempts))
+	require.Equal(t, 2*time.Hour, Backoff(100))
+}
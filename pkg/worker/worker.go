@@ -9,6 +9,7 @@ import (
 	tele "gopkg.in/telebot.v3"
 
 	"github.com/pershin-daniil/TimeSlots/pkg/notifier"
+	"github.com/pershin-daniil/TimeSlots/pkg/webhook"
 
 	"github.com/pershin-daniil/TimeSlots/pkg/models"
 	"github.com/sirupsen/logrus"
@@ -21,22 +22,49 @@ const (
 	run  workerCode = 1
 )
 
+// timeLayout is how times are shown to users in their time zone.
+const timeLayout = "02.01 15:04"
+
+// offerHold is how long a slot freed for the waitlist is held for the client it is offered to.
+const offerHold = 30 * time.Minute
+
+// expireInterval is how often expired idempotency keys, rate limits and sessions are deleted.
+const expireInterval = time.Hour
+
+const (
+	// deliverInterval is how often due webhook deliveries are looked up.
+	deliverInterval = time.Second
+	// deliveryBatch is how many deliveries are claimed at once. A claim lasts deliveryLease, which
+	// has to be longer than sending the whole batch takes.
+	deliveryBatch = 20
+	deliveryLease = 5 * time.Minute
+)
+
 type Store interface {
 	UsersWithMeetings(ctx context.Context) ([]models.UserNotify, error)
 	SwitchNotificationStatus(ctx context.Context, meetingID int) error
+	OfferWaitlistSlots(ctx context.Context, hold time.Duration) ([]models.WaitlistEntry, error)
+	GetUser(ctx context.Context, id int) (models.User, error)
+	DeleteIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+	DeleteExpiredRateLimits(ctx context.Context, now time.Time) (int64, error)
+	DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.PendingDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, id int, attempt models.DeliveryAttempt) error
 }
 
 type Worker struct {
 	log      *logrus.Logger
 	store    Store
 	notifier *notifier.Notifier
+	sender   *webhook.Sender
 }
 
-func New(log *logrus.Logger, store Store, notifier *notifier.Notifier) *Worker {
+func New(log *logrus.Logger, store Store, notifier *notifier.Notifier, sender *webhook.Sender) *Worker {
 	return &Worker{
 		log:      log,
 		store:    store,
 		notifier: notifier,
+		sender:   sender,
 	}
 }
 
@@ -55,7 +83,8 @@ func (w *Worker) SendNotificationBeforeTraining(ctx context.Context) error {
 			if user.Notified {
 				continue
 			}
-			msg := fmt.Sprintf("У вас тренировка в %s", user.StartAt.String())
+			startAt := user.StartAt.In(models.Location(user.TimeZone))
+			msg := fmt.Sprintf("У вас тренировка в %s", startAt.Format(timeLayout))
 			if err = w.notifier.NotifyTelegram(ctx, msg, user); err != nil && !errors.Is(err, tele.ErrChatNotFound) {
 				return fmt.Errorf("worker send notification faild: %w", err)
 			}
@@ -70,3 +99,131 @@ func (w *Worker) SendNotificationBeforeTraining(ctx context.Context) error {
 	}
 	return nil
 }
+
+// OfferWaitlistSlots offers slots freed by cancelled or moved meetings to the waitlisted clients.
+// An offer which is not accepted in time passes to the next client in the queue.
+func (w *Worker) OfferWaitlistSlots(ctx context.Context) {
+	worker := run
+	go func() {
+		<-ctx.Done()
+		worker = stop
+	}()
+	for {
+		offers, err := w.store.OfferWaitlistSlots(ctx, offerHold)
+		if err != nil {
+			w.log.Warnf("worker offer waitlist slots faild: %v", err)
+		}
+		for _, offer := range offers {
+			loc := time.UTC
+			if client, err := w.store.GetUser(ctx, offer.ClientID); err == nil {
+				loc = client.Location()
+			}
+			msg := fmt.Sprintf("Освободилось время %s. Чтобы записаться, отправьте /accept %d до %s, чтобы отказаться — /decline %d",
+				offer.StartTime.In(loc).Format(timeLayout), offer.ID, offer.HoldUntil.In(loc).Format("15:04"), offer.ID)
+			if err = w.notifier.SendMessage(ctx, offer.ClientID, msg); err != nil {
+				w.log.Warnf("worker notify waitlist offer %d faild: %v", offer.ID, err)
+			}
+		}
+		time.Sleep(5 * time.Second)
+		if worker == stop {
+			break
+		}
+	}
+}
+
+// ExpireIdempotencyKeys deletes idempotency keys older than models.IdempotencyKeyTTL.
+func (w *Worker) ExpireIdempotencyKeys(ctx context.Context) {
+	for {
+		deleted, err := w.store.DeleteIdempotencyKeys(ctx, time.Now().Add(-models.IdempotencyKeyTTL))
+		if err != nil {
+			w.log.Warnf("worker expire idempotency keys faild: %v", err)
+		} else if deleted > 0 {
+			w.log.Infof("worker expired %d idempotency keys", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(expireInterval):
+		}
+	}
+}
+
+// ExpireRateLimits deletes expired login rate limit counters kept in Postgres.
+func (w *Worker) ExpireRateLimits(ctx context.Context) {
+	for {
+		deleted, err := w.store.DeleteExpiredRateLimits(ctx, time.Now())
+		if err != nil {
+			w.log.Warnf("worker expire rate limits faild: %v", err)
+		} else if deleted > 0 {
+			w.log.Infof("worker expired %d rate limits", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(expireInterval):
+		}
+	}
+}
+
+// ExpireSessions deletes expired refresh tokens and the sessions which have none left.
+func (w *Worker) ExpireSessions(ctx context.Context) {
+	for {
+		deleted, err := w.store.DeleteExpiredSessions(ctx, time.Now())
+		if err != nil {
+			w.log.Warnf("worker expire sessions faild: %v", err)
+		} else if deleted > 0 {
+			w.log.Infof("worker expired %d sessions", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(expireInterval):
+		}
+	}
+}
+
+// DeliverWebhooks sends due webhook deliveries. A failed delivery is retried with backoff
+// until webhook.MaxAttempts attempts were made.
+func (w *Worker) DeliverWebhooks(ctx context.Context) {
+	for {
+		deliveries, err := w.store.ClaimWebhookDeliveries(ctx, deliveryBatch, deliveryLease)
+		if err != nil {
+			w.log.Warnf("worker claim webhook deliveries faild: %v", err)
+		}
+		for _, delivery := range deliveries {
+			w.deliver(ctx, delivery)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(deliverInterval):
+		}
+	}
+}
+
+func (w *Worker) deliver(ctx context.Context, delivery models.PendingDelivery) {
+	status, err := w.sender.Send(ctx, webhook.Message{
+		URL:        delivery.URL,
+		Secret:     delivery.Secret,
+		Event:      delivery.Event,
+		DeliveryID: delivery.ID,
+		Payload:    delivery.Payload,
+	})
+	attempt := models.DeliveryAttempt{Status: models.DeliveryDelivered}
+	if status != 0 {
+		attempt.ResponseStatus = &status
+	}
+	if err != nil {
+		msg := err.Error()
+		attempt.Error = &msg
+		attempt.Status = models.DeliveryFailed
+		if attempts := delivery.Attempts + 1; attempts < webhook.MaxAttempts {
+			next := time.Now().Add(webhook.Backoff(attempts))
+			attempt.Status = models.DeliveryPending
+			attempt.NextAttemptAt = &next
+		}
+	}
+	if err = w.store.RecordWebhookAttempt(ctx, delivery.ID, attempt); err != nil {
+		w.log.Warnf("worker record webhook delivery %d faild: %v", delivery.ID, err)
+	}
+}
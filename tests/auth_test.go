@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/signing"
+)
+
+func (s *IntegrationTestSuite) TestTokens() {
+	ctx := context.Background()
+	testUser, _ := s.createUser(ctx, user)
+
+	s.Run("access token expires", func() {
+		tokens := s.login(ctx, testUser.Phone, *user.Password)
+		s.Require().NotEmpty(tokens.RefreshToken)
+		s.Require().WithinDuration(time.Now().Add(15*time.Minute), tokens.ExpiresAt, time.Minute)
+	})
+
+	s.Run("refresh rotates the refresh token", func() {
+		tokens := s.login(ctx, testUser.Phone, *user.Password)
+		var refreshed models.TokenResponse
+		resp := s.sendRequest(ctx, http.MethodPost, "/api/v1/auth/refresh", models.RefreshRequest{RefreshToken: &tokens.RefreshToken}, &refreshed)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().NotEqual(tokens.RefreshToken, refreshed.RefreshToken)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, refreshed.Token, "/api/v1/users/me", nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		// Reusing the rotated token revokes the whole session.
+		resp = s.sendRequest(ctx, http.MethodPost, "/api/v1/auth/refresh", models.RefreshRequest{RefreshToken: &tokens.RefreshToken}, nil)
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+		resp = s.sendRequest(ctx, http.MethodPost, "/api/v1/auth/refresh", models.RefreshRequest{RefreshToken: &refreshed.RefreshToken}, nil)
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, refreshed.Token, "/api/v1/users/me", nil, nil)
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	s.Run("unknown refresh token", func() {
+		unknown := "unknown"
+		resp := s.sendRequest(ctx, http.MethodPost, "/api/v1/auth/refresh", models.RefreshRequest{RefreshToken: &unknown}, nil)
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	s.Run("logout revokes the session", func() {
+		tokens := s.login(ctx, testUser.Phone, *user.Password)
+		other := s.login(ctx, testUser.Phone, *user.Password)
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, tokens.Token, "/api/v1/auth/logout", nil, nil)
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, tokens.Token, "/api/v1/users/me", nil, nil)
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+		resp = s.sendRequest(ctx, http.MethodPost, "/api/v1/auth/refresh", models.RefreshRequest{RefreshToken: &tokens.RefreshToken}, nil)
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, other.Token, "/api/v1/users/me", nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+	})
+}
+
+func (s *IntegrationTestSuite) TestExpireSessions() {
+	ctx := context.Background()
+	testUser, _ := s.createUser(ctx, user)
+	expired := s.login(ctx, testUser.Phone, *user.Password)
+	resp := s.sendRequest(ctx, http.MethodPost, "/api/v1/auth/refresh", models.RefreshRequest{RefreshToken: &expired.RefreshToken}, nil)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	err := s.store.Exec(ctx, `UPDATE refresh_tokens SET expires_at = NOW() - INTERVAL '1 minute'
+WHERE session_id IN (SELECT id FROM auth_sessions WHERE user_id = $1)`, testUser.ID)
+	s.Require().NoError(err)
+	live := s.login(ctx, testUser.Phone, *user.Password)
+
+	deleted, err := s.store.DeleteExpiredSessions(ctx, time.Now())
+	s.Require().NoError(err)
+	s.Require().GreaterOrEqual(deleted, int64(1))
+
+	var sessions, tokens int
+	rows, err := s.store.Query(ctx, `SELECT COUNT(DISTINCT s.id), COUNT(t.token_hash) FROM auth_sessions s
+JOIN refresh_tokens t ON t.session_id = s.id WHERE s.user_id = $1`, testUser.ID)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(rows.Close())
+	}()
+	s.Require().True(rows.Next())
+	s.Require().NoError(rows.Scan(&sessions, &tokens))
+	s.Require().Equal(1, sessions)
+	s.Require().Equal(1, tokens)
+	resp = s.sendAuthorisedRequest(ctx, http.MethodGet, live.Token, "/api/v1/users/me", nil, nil)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (s *IntegrationTestSuite) TestJWKS() {
+	ctx := context.Background()
+	testUser, token := s.createUser(ctx, user)
+	s.Require().NotZero(testUser.ID)
+
+	var set signing.JWKS
+	resp := s.sendRequest(ctx, http.MethodGet, "/.well-known/jwks.json", nil, &set)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Len(set.Keys, 1)
+	s.Require().Equal("RS256", set.Keys[0].Alg)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &models.Claims{})
+	s.Require().NoError(err)
+	s.Require().Equal(set.Keys[0].Kid, parsed.Header["kid"])
+}
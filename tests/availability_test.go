@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestAvailability() {
+	ctx := context.Background()
+	coach, token := s.createCoach(ctx)
+	client, clientToken := s.createUser(ctx, user)
+	url := "/api/v1/coaches/" + strconv.Itoa(coach.ID) + "/availability"
+
+	timeZone := "UTC"
+	data := models.AvailabilityRequest{TimeZone: &timeZone}
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		data.WorkingHours = append(data.WorkingHours, models.WeeklyPeriod{Weekday: int(weekday), StartTime: "08:00", EndTime: "20:00"})
+		data.Breaks = append(data.Breaks, models.WeeklyPeriod{Weekday: int(weekday), StartTime: "13:00", EndTime: "14:00"})
+	}
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	book := func(start, end time.Time) *http.Response {
+		meeting := models.MeetingRequest{Manager: &coach.ID, StartTime: &start, EndTime: &end, Client: &client.ID}
+		return s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/meetings", meeting, nil)
+	}
+
+	s.Run("set availability", func() {
+		var respAvailability models.Availability
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPut, token, url, data, &respAvailability)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(coach.ID, respAvailability.CoachID)
+		s.Require().Len(respAvailability.WorkingHours, 7)
+		s.Require().Len(respAvailability.Breaks, 7)
+	})
+
+	s.Run("calendar is shown to its coach only", func() {
+		calendarID := "coach@group.calendar.google.com"
+		withCalendar := data
+		withCalendar.CalendarID = &calendarID
+		var respAvailability models.Availability
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPut, token, url, withCalendar, &respAvailability)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(calendarID, respAvailability.CalendarID)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, token, url, nil, &respAvailability)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(calendarID, respAvailability.CalendarID)
+
+		var clientAvailability models.Availability
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, clientToken, url, nil, &clientAvailability)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Empty(clientAvailability.CalendarID)
+	})
+
+	s.Run("set availability of another coach", func() {
+		s.requireForbidden(ctx, http.MethodPut, clientToken, url, data)
+	})
+
+	s.Run("invalid working hours", func() {
+		invalid := models.AvailabilityRequest{WorkingHours: []models.WeeklyPeriod{{Weekday: 1, StartTime: "18:00", EndTime: "09:00"}}}
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPut, token, url, invalid, nil)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	s.Run("book within working hours", func() {
+		resp := book(tomorrow.Add(10*time.Hour), tomorrow.Add(11*time.Hour))
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	})
+
+	s.Run("book during break", func() {
+		resp := book(tomorrow.Add(13*time.Hour), tomorrow.Add(14*time.Hour))
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	s.Run("book after working hours", func() {
+		resp := book(tomorrow.Add(21*time.Hour), tomorrow.Add(22*time.Hour))
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	s.Run("book on day off", func() {
+		dayOff := tomorrow.Add(24 * time.Hour)
+		override := models.AvailabilityOverride{Date: dayOff.Format("2006-01-02")}
+		var respOverride models.AvailabilityOverride
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, token, url+"/overrides", override, &respOverride)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().False(respOverride.Available)
+
+		resp = book(dayOff.Add(10*time.Hour), dayOff.Add(11*time.Hour))
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodDelete, token, url+"/overrides/"+strconv.Itoa(respOverride.ID), nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		resp = book(dayOff.Add(10*time.Hour), dayOff.Add(11*time.Hour))
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	})
+}
+
+func (s *IntegrationTestSuite) TestSlots() {
+	ctx := context.Background()
+	coach, token := s.createCoach(ctx)
+	client, _ := s.createUser(ctx, user)
+
+	timeZone := "UTC"
+	data := models.AvailabilityRequest{TimeZone: &timeZone}
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		data.WorkingHours = append(data.WorkingHours, models.WeeklyPeriod{Weekday: int(weekday), StartTime: "10:00", EndTime: "13:00"})
+	}
+	resp := s.sendAuthorisedRequest(ctx, http.MethodPut, token, "/api/v1/coaches/"+strconv.Itoa(coach.ID)+"/availability", data, nil)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	start, end := tomorrow.Add(11*time.Hour), tomorrow.Add(12*time.Hour)
+	meeting := models.MeetingRequest{Manager: &coach.ID, StartTime: &start, EndTime: &end, Client: &client.ID}
+	resp = s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/meetings", meeting, nil)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	s.Run("free slots", func() {
+		url := "/api/v1/coaches/" + strconv.Itoa(coach.ID) + "/slots?duration=1h" +
+			"&from=" + tomorrow.Format(time.RFC3339) + "&to=" + tomorrow.Add(24*time.Hour).Format(time.RFC3339)
+		var slots []models.Slot
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, token, url, nil, &slots)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(slots, 2)
+		s.Require().Equal(tomorrow.Add(10*time.Hour), slots[0].StartTime.UTC())
+		s.Require().Equal(tomorrow.Add(12*time.Hour), slots[1].StartTime.UTC())
+	})
+
+	s.Run("invalid duration", func() {
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, token, "/api/v1/coaches/"+strconv.Itoa(coach.ID)+"/slots?duration=-1h", nil, nil)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+}
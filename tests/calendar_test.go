@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestCalendarFeed() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	client, clientToken := s.createUser(ctx, user)
+	tokenURL := "/api/v1/users/" + strconv.Itoa(client.ID) + "/calendar-token"
+
+	getFeed := func(url string) (int, string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, testURL+url, nil)
+		s.Require().NoError(err)
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		defer func() {
+			s.Require().NoError(resp.Body.Close())
+		}()
+		body, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		if resp.StatusCode == http.StatusOK {
+			s.Require().Equal("text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+		}
+		return resp.StatusCode, strings.ReplaceAll(string(body), "\r\n ", "")
+	}
+	// event returns the properties of the event with the uid in the feed.
+	event := func(feed, uid string) []string {
+		for _, vevent := range strings.Split(feed, "BEGIN:VEVENT\r\n")[1:] {
+			lines := strings.Split(vevent, "\r\n")
+			for _, line := range lines {
+				if line == "UID:"+uid {
+					return lines
+				}
+			}
+		}
+		s.FailNow("event is not in the feed", uid)
+		return nil
+	}
+
+	s.Run("only the user issues the token", func() {
+		s.requireForbidden(ctx, http.MethodPost, coachToken, tokenURL, nil)
+	})
+
+	var feed models.CalendarFeed
+	resp := s.sendAuthorisedRequest(ctx, http.MethodPost, clientToken, tokenURL, nil, &feed)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	s.Require().NotEmpty(feed.Token)
+	s.Require().Contains(feed.URL, "/api/v1/users/"+strconv.Itoa(client.ID)+"/calendar.ics?token=")
+
+	s.Run("invalid tokens", func() {
+		status, _ := getFeed("/api/v1/users/" + strconv.Itoa(client.ID) + "/calendar.ics?token=wrong")
+		s.Require().Equal(http.StatusUnauthorized, status)
+		status, _ = getFeed("/api/v1/users/" + strconv.Itoa(coach.ID) + "/calendar.ics?token=" + feed.Token)
+		s.Require().Equal(http.StatusUnauthorized, status)
+	})
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	end := start.Add(time.Hour)
+	var meeting models.Meeting
+	resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings",
+		models.MeetingRequest{Manager: &coach.ID, StartTime: &start, EndTime: &end, Client: &client.ID}, &meeting)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	uid := "meeting-" + strconv.Itoa(meeting.ID) + "@timeslots"
+	meetingURL := "/api/v1/meetings/" + strconv.Itoa(meeting.ID)
+
+	s.Run("meetings are events", func() {
+		status, body := getFeed(feed.URL)
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().True(strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+		props := event(body, uid)
+		s.Require().Contains(props, "DTSTART:"+start.UTC().Format("20060102T150405Z"))
+		s.Require().Contains(props, "DTEND:"+end.UTC().Format("20060102T150405Z"))
+		s.Require().Contains(props, "SEQUENCE:0")
+		s.Require().Contains(props, "SUMMARY:Training with "+coach.FirstName+" "+coach.LastName)
+	})
+
+	s.Run("updates bump the sequence", func() {
+		start = start.Add(time.Hour)
+		end = end.Add(time.Hour)
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, coachToken, meetingURL,
+			models.MeetingRequest{StartTime: &start, EndTime: &end}, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		_, body := getFeed(feed.URL)
+		props := event(body, uid)
+		s.Require().Contains(props, "DTSTART:"+start.UTC().Format("20060102T150405Z"))
+		s.Require().Contains(props, "SEQUENCE:1")
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, meetingURL+"/cancel", nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		_, body = getFeed(feed.URL)
+		props = event(body, uid)
+		s.Require().Contains(props, "STATUS:CANCELLED")
+		s.Require().Contains(props, "SEQUENCE:2")
+	})
+
+	s.Run("a new token revokes the old one", func() {
+		var next models.CalendarFeed
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, clientToken, tokenURL, nil, &next)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		status, _ := getFeed(feed.URL)
+		s.Require().Equal(http.StatusUnauthorized, status)
+		status, _ = getFeed(next.URL)
+		s.Require().Equal(http.StatusOK, status)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodDelete, clientToken, tokenURL, nil, nil)
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		status, _ = getFeed(next.URL)
+		s.Require().Equal(http.StatusUnauthorized, status)
+	})
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestCancellationPolicy() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	client, clientToken := s.createUser(ctx, user)
+	policyURL := "/api/v1/coaches/" + strconv.Itoa(coach.ID) + "/cancellation-policy"
+	newMeeting := func(start time.Time) models.Meeting {
+		end := start.Add(time.Hour)
+		data := models.MeetingRequest{Manager: &coach.ID, StartTime: &start, EndTime: &end, Client: &client.ID}
+		var respMeeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, &respMeeting)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		return respMeeting
+	}
+	cancel := func(token string, id int, dest interface{}) *http.Response {
+		return s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/meetings/"+strconv.Itoa(id)+"/cancel", nil, dest)
+	}
+
+	s.Run("default policy", func() {
+		var policy models.CancellationPolicy
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, clientToken, policyURL, nil, &policy)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Zero(policy.FreeCancellationMinutes)
+		s.Require().True(policy.AllowLateCancellation)
+	})
+
+	s.Run("only the coach sets the policy", func() {
+		minutes := 12 * 60
+		data := models.CancellationPolicyRequest{FreeCancellationMinutes: &minutes}
+		s.requireForbidden(ctx, http.MethodPut, clientToken, policyURL, data)
+		var policy models.CancellationPolicy
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPut, coachToken, policyURL, data, &policy)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(minutes, policy.FreeCancellationMinutes)
+	})
+
+	s.Run("on-time and late cancellations", func() {
+		var respMeeting models.Meeting
+		resp := cancel(clientToken, newMeeting(time.Now().Add(24*time.Hour)).ID, &respMeeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().False(*respMeeting.LateCancellation)
+		s.Require().Equal(client.ID, *respMeeting.CancelledBy)
+
+		resp = cancel(clientToken, newMeeting(time.Now().Add(2*time.Hour)).ID, &respMeeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().True(*respMeeting.LateCancellation)
+	})
+
+	s.Run("coach cancellations are never late", func() {
+		var respMeeting models.Meeting
+		resp := cancel(coachToken, newMeeting(time.Now().Add(3*time.Hour)).ID, &respMeeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().False(*respMeeting.LateCancellation)
+	})
+
+	s.Run("late cancellation is rejected", func() {
+		allowLate := false
+		data := models.CancellationPolicyRequest{AllowLateCancellation: &allowLate}
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPut, coachToken, policyURL, data, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		resp = cancel(clientToken, newMeeting(time.Now().Add(4*time.Hour)).ID, nil)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+}
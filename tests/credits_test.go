@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/internal/rest"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestCredits() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	client, clientToken := s.createUser(ctx, user)
+	creditsURL := "/api/v1/users/" + strconv.Itoa(client.ID) + "/credits"
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	book := func(offset time.Duration) *http.Response {
+		startTime := start.Add(offset)
+		endTime := startTime.Add(time.Hour)
+		data := models.MeetingRequest{Manager: &coach.ID, StartTime: &startTime, EndTime: &endTime, Client: &client.ID}
+		var meeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, &meeting)
+		if resp.StatusCode == http.StatusCreated {
+			resp.Header.Set("X-Meeting-ID", strconv.Itoa(meeting.ID))
+		}
+		return resp
+	}
+	balance := func() int {
+		var respBalance models.CreditBalance
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, clientToken, creditsURL, nil, &respBalance)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		return respBalance.Balance
+	}
+
+	s.Run("clients without packages pay per session", func() {
+		s.Require().Equal(http.StatusCreated, book(0).StatusCode)
+	})
+
+	s.Run("grant package", func() {
+		sessions := 5
+		expiresAt := time.Now().Add(30 * 24 * time.Hour)
+		data := models.CreditPackageRequest{Sessions: &sessions, ExpiresAt: &expiresAt}
+		s.requireForbidden(ctx, http.MethodPost, clientToken, creditsURL, data)
+		var pkg models.CreditPackage
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, creditsURL, data, &pkg)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal(sessions, pkg.Remaining)
+		s.Require().Equal(sessions, balance())
+
+		expired := time.Now().Add(-time.Hour)
+		data.ExpiresAt = &expired
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, creditsURL, data, nil)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	s.Run("coaches grant only to their clients", func() {
+		sessions := 5
+		expiresAt := time.Now().Add(30 * 24 * time.Hour)
+		data := models.CreditPackageRequest{Sessions: &sessions, ExpiresAt: &expiresAt}
+		otherCoach, otherToken := s.createCoach(ctx)
+		s.requireForbidden(ctx, http.MethodPost, otherToken, creditsURL, data)
+		s.requireForbidden(ctx, http.MethodGet, otherToken, "/api/v1/users/"+strconv.Itoa(client.ID), nil)
+
+		s.bookMeeting(ctx, coach.ID, coachToken, otherCoach.ID, start.Add(20*time.Hour))
+		var problem rest.Problem
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/users/"+strconv.Itoa(otherCoach.ID)+"/credits", data, &problem)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		s.Require().Equal(models.CodeInvalidCreditPackage, problem.Code)
+	})
+
+	s.Run("booking consumes and on-time cancellation refunds", func() {
+		resp := book(2 * time.Hour)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal(4, balance())
+		cancelURL := "/api/v1/meetings/" + resp.Header.Get("X-Meeting-ID") + "/cancel"
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, clientToken, cancelURL, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(5, balance())
+
+		var entries []models.CreditEntry
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, clientToken, creditsURL+"/ledger", nil, &entries)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(entries, 3)
+		s.Require().Equal([]string{models.CreditGrant, models.CreditBooking, models.CreditRefund},
+			[]string{entries[0].Reason, entries[1].Reason, entries[2].Reason})
+	})
+
+	s.Run("series and group sessions consume credits", func() {
+		seriesStart := start.Add(6 * time.Hour)
+		seriesEnd := seriesStart.Add(time.Hour)
+		rule := "FREQ=DAILY;COUNT=2"
+		data := models.SeriesRequest{Manager: &coach.ID, Client: &client.ID, StartTime: &seriesStart, EndTime: &seriesEnd, Rule: &rule}
+		var series models.Series
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/series", data, &series)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal(3, balance())
+		url := "/api/v1/meetings/" + strconv.Itoa(series.Meetings[0].ID) + "?scope=all"
+		resp = s.sendAuthorisedRequest(ctx, http.MethodDelete, coachToken, url, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(5, balance())
+
+		groupStart := start.Add(10 * time.Hour)
+		groupEnd := groupStart.Add(time.Hour)
+		capacity := 2
+		var group models.Meeting
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings",
+			models.MeetingRequest{Manager: &coach.ID, StartTime: &groupStart, EndTime: &groupEnd, Capacity: &capacity}, &group)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		participantsURL := "/api/v1/meetings/" + strconv.Itoa(group.ID) + "/participants"
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, clientToken, participantsURL, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(4, balance())
+		resp = s.sendAuthorisedRequest(ctx, http.MethodDelete, clientToken, participantsURL, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(5, balance())
+	})
+
+	s.Run("expired packages can't be used", func() {
+		err := s.store.Exec(ctx, `UPDATE credit_packages SET expires_at = NOW() - INTERVAL '1 day' WHERE client = $1`, client.ID)
+		s.Require().NoError(err)
+		s.Require().Zero(balance())
+		s.Require().Equal(http.StatusPaymentRequired, book(4*time.Hour).StatusCode)
+	})
+}
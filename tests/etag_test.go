@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestMeetingETag() {
+	ctx := context.Background()
+	newMeeting, token := s.createMeeting(ctx, meeting)
+	meetingURL := "/api/v1/meetings/" + strconv.Itoa(newMeeting.ID)
+	auth := "Bearer " + token
+
+	resp := s.sendAuthorisedRequest(ctx, http.MethodGet, token, meetingURL, nil, nil)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	tag := resp.Header.Get("ETag")
+	s.Require().NotEmpty(tag)
+
+	startTime := newMeeting.StartTime.Add(time.Hour)
+	endTime := newMeeting.EndTime.Add(time.Hour)
+	data := models.MeetingRequest{StartTime: &startTime, EndTime: &endTime}
+	resp = s.sendRequestWithHeaders(ctx, http.MethodPatch, meetingURL, map[string]string{"Authorization": auth, "If-Match": tag}, data, nil)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	newTag := resp.Header.Get("ETag")
+	s.Require().NotEqual(tag, newTag)
+
+	s.Run("stale edit", func() {
+		resp := s.sendRequestWithHeaders(ctx, http.MethodPatch, meetingURL, map[string]string{"Authorization": auth, "If-Match": tag}, data, nil)
+		s.Require().Equal(http.StatusPreconditionFailed, resp.StatusCode)
+		resp = s.sendRequestWithHeaders(ctx, http.MethodDelete, meetingURL, map[string]string{"Authorization": auth, "If-Match": tag}, nil, nil)
+		s.Require().Equal(http.StatusPreconditionFailed, resp.StatusCode)
+		resp = s.sendRequestWithHeaders(ctx, http.MethodPatch, meetingURL, map[string]string{"Authorization": auth, "If-Match": "W/" + newTag}, data, nil)
+		s.Require().Equal(http.StatusPreconditionFailed, resp.StatusCode)
+	})
+
+	s.Run("list of tags", func() {
+		resp := s.sendRequestWithHeaders(ctx, http.MethodPatch, meetingURL, map[string]string{"Authorization": auth, "If-Match": tag + ", " + newTag}, data, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		newTag = resp.Header.Get("ETag")
+		resp = s.sendRequestWithHeaders(ctx, http.MethodPatch, meetingURL, map[string]string{"Authorization": auth, "If-Match": "*"}, data, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		newTag = resp.Header.Get("ETag")
+	})
+
+	s.Run("current delete", func() {
+		resp := s.sendRequestWithHeaders(ctx, http.MethodDelete, meetingURL, map[string]string{"Authorization": auth, "If-Match": newTag}, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+	})
+}
+
+func (s *IntegrationTestSuite) TestUserETag() {
+	ctx := context.Background()
+	testUser, token := s.createUser(ctx, user)
+	userURL := "/api/v1/users/" + strconv.Itoa(testUser.ID)
+	auth := "Bearer " + token
+
+	resp := s.sendAuthorisedRequest(ctx, http.MethodGet, token, "/api/v1/users/me", nil, nil)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	tag := resp.Header.Get("ETag")
+
+	firstName := "Petr"
+	data := models.UserRequest{FirstName: &firstName}
+	resp = s.sendRequestWithHeaders(ctx, http.MethodPatch, userURL, map[string]string{"Authorization": auth, "If-Match": tag}, data, nil)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	resp = s.sendRequestWithHeaders(ctx, http.MethodPatch, userURL, map[string]string{"Authorization": auth, "If-Match": tag}, data, nil)
+	s.Require().Equal(http.StatusPreconditionFailed, resp.StatusCode)
+	resp = s.sendRequestWithHeaders(ctx, http.MethodDelete, userURL, map[string]string{"Authorization": auth, "If-Match": tag}, nil, nil)
+	s.Require().Equal(http.StatusPreconditionFailed, resp.StatusCode)
+}
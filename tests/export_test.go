@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestExport() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	client, clientToken := s.createUser(ctx, user)
+
+	getCSV := func(token, path string) (int, [][]string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, testURL+path, nil)
+		s.Require().NoError(err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		defer func() {
+			s.Require().NoError(resp.Body.Close())
+		}()
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+		s.Require().Equal("text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		s.Require().NoError(err)
+		return resp.StatusCode, records
+	}
+
+	from := time.Now().Add(24 * time.Hour).Truncate(time.Hour).UTC()
+	var ids []int
+	for i := 0; i < 3; i++ {
+		start := from.Add(time.Duration(i) * 2 * time.Hour)
+		end := start.Add(90 * time.Minute)
+		var meeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings",
+			models.MeetingRequest{Manager: &coach.ID, StartTime: &start, EndTime: &end, Client: &client.ID}, &meeting)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		ids = append(ids, meeting.ID)
+	}
+	query := url.Values{
+		"from": {from.Format(time.RFC3339)},
+		"to":   {from.Add(4 * time.Hour).Format(time.RFC3339)},
+	}
+
+	s.Run("meetings", func() {
+		status, records := getCSV(coachToken, "/api/v1/meetings/export.csv?"+query.Encode())
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().Len(records, 3)
+		s.Require().Equal("id", records[0][0])
+		for i, record := range records[1:] {
+			s.Require().Equal([]string{
+				strconv.Itoa(ids[i]),
+				from.Add(time.Duration(i) * 2 * time.Hour).Format(time.RFC3339),
+				from.Add(time.Duration(i)*2*time.Hour + 90*time.Minute).Format(time.RFC3339),
+				"90",
+				models.StatusConfirmed,
+				strconv.Itoa(coach.ID),
+				coach.FirstName + " " + coach.LastName,
+				strconv.Itoa(client.ID),
+				client.FirstName + " " + client.LastName,
+				"1",
+				"1",
+			}, record)
+		}
+	})
+
+	s.Run("meetings of the client", func() {
+		status, records := getCSV(clientToken, "/api/v1/meetings/export.csv?"+query.Encode())
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().Len(records, 3)
+
+		status, _ = getCSV(clientToken, "/api/v1/meetings/export.csv?manager="+strconv.Itoa(coach.ID))
+		s.Require().Equal(http.StatusOK, status)
+		status, _ = getCSV(clientToken, "/api/v1/meetings/export.csv?client="+strconv.Itoa(coach.ID))
+		s.Require().Equal(http.StatusForbidden, status)
+	})
+
+	s.Run("times in the zone of the caller", func() {
+		zone := "Europe/Moscow"
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, coachToken, "/api/v1/users/"+strconv.Itoa(coach.ID), models.UserRequest{TimeZone: &zone}, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		defer func() {
+			utc := "UTC"
+			resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, coachToken, "/api/v1/users/"+strconv.Itoa(coach.ID), models.UserRequest{TimeZone: &utc}, nil)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+		}()
+		status, records := getCSV(coachToken, "/api/v1/meetings/export.csv?"+query.Encode())
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().Len(records, 3)
+		s.Require().Equal(from.In(models.Location(zone)).Format(time.RFC3339), records[1][1])
+	})
+
+	s.Run("empty export has the header", func() {
+		status, records := getCSV(coachToken, "/api/v1/meetings/export.csv?from="+url.QueryEscape(from.Add(-time.Hour).Format(time.RFC3339))+
+			"&to="+url.QueryEscape(from.Add(-time.Minute).Format(time.RFC3339)))
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().Len(records, 1)
+	})
+
+	s.Run("invalid filter", func() {
+		status, _ := getCSV(coachToken, "/api/v1/meetings/export.csv?from=yesterday")
+		s.Require().Equal(http.StatusBadRequest, status)
+	})
+
+	s.Run("users", func() {
+		status, records := getCSV(coachToken, "/api/v1/users/export.csv")
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().Equal([]string{"id", "last_name", "first_name", "phone", "email", "role", "time_zone", "created_at"}, records[0])
+		s.Require().Len(records, 2)
+		s.Require().Equal(strconv.Itoa(client.ID), records[1][0])
+		s.Require().Equal("'"+client.Phone, records[1][3])
+
+		status, records = getCSV(clientToken, "/api/v1/users/export.csv?role=coach")
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().Len(records, 1)
+	})
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestIdempotencyKey() {
+	ctx := context.Background()
+	coach, token := s.createCoach(ctx)
+	client, _ := s.createUser(ctx, user)
+	startTime := time.Now().Add(72 * time.Hour).Truncate(time.Minute)
+	endTime := startTime.Add(time.Hour)
+	data := models.MeetingRequest{Manager: &coach.ID, Client: &client.ID, StartTime: &startTime, EndTime: &endTime}
+
+	s.Run("retry replays the response", func() {
+		key := uuid.NewString()
+		var first, second models.Meeting
+		resp := s.sendIdempotentRequest(ctx, http.MethodPost, token, "/api/v1/meetings", key, data, &first)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		resp = s.sendIdempotentRequest(ctx, http.MethodPost, token, "/api/v1/meetings", key, data, &second)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal("true", resp.Header.Get("Idempotent-Replayed"))
+		s.Require().Equal(first.ID, second.ID)
+		var cnt int
+		err := s.store.QueryRow(ctx, `SELECT count(*) FROM meetings WHERE manager = $1`, coach.ID).Scan(&cnt)
+		s.Require().NoError(err)
+		s.Require().Equal(1, cnt)
+
+		otherStart := startTime.Add(2 * time.Hour)
+		otherEnd := otherStart.Add(time.Hour)
+		other := models.MeetingRequest{Manager: &coach.ID, Client: &client.ID, StartTime: &otherStart, EndTime: &otherEnd}
+		resp = s.sendIdempotentRequest(ctx, http.MethodPost, token, "/api/v1/meetings", key, other, nil)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	s.Run("keys are scoped by caller", func() {
+		key := uuid.NewString()
+		phone := randomPhone()
+		newUser := user
+		newUser.Phone = &phone
+		var first, second models.User
+		resp := s.sendIdempotentRequest(ctx, http.MethodPost, "", "/api/v1/users", key, newUser, &first)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		resp = s.sendIdempotentRequest(ctx, http.MethodPost, "", "/api/v1/users", key, newUser, &second)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal(first.ID, second.ID)
+
+		otherStart := startTime.Add(4 * time.Hour)
+		otherEnd := otherStart.Add(time.Hour)
+		other := models.MeetingRequest{Manager: &coach.ID, Client: &client.ID, StartTime: &otherStart, EndTime: &otherEnd}
+		resp = s.sendIdempotentRequest(ctx, http.MethodPost, token, "/api/v1/meetings", key, other, nil)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	})
+}
+
+func (s *IntegrationTestSuite) sendIdempotentRequest(ctx context.Context, method, token, url, key string, body, dest interface{}) *http.Response {
+	s.T().Helper()
+	headers := map[string]string{"Idempotency-Key": key}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return s.sendRequestWithHeaders(ctx, method, url, headers, body, dest)
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/internal/rest"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestImportMeetings() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	_, otherCoachToken := s.createCoach(ctx)
+	client, _ := s.createUser(ctx, user)
+	uniqueEmail := "import-" + strconv.Itoa(client.ID) + "@example.com"
+	emailClientRequest := user
+	emailClientRequest.Email = &uniqueEmail
+	emailClient, _ := s.createUser(ctx, emailClientRequest)
+	sharedClient, _ := s.createUser(ctx, user)
+	stranger, _ := s.createUser(ctx, user)
+	importURL := "/api/v1/coaches/" + strconv.Itoa(coach.ID) + "/meetings/import"
+
+	importCalendar := func(token, query, body string, dest interface{}) int {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, testURL+importURL+query, strings.NewReader(body))
+		s.Require().NoError(err)
+		req.Header.Set("Content-Type", "text/calendar")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		defer func() {
+			s.Require().NoError(resp.Body.Close())
+		}()
+		if dest != nil {
+			s.Require().NoError(json.NewDecoder(resp.Body).Decode(dest))
+		}
+		return resp.StatusCode
+	}
+
+	base := time.Now().Add(72 * time.Hour).Truncate(time.Hour).UTC()
+	busyAt := base.Add(24 * time.Hour)
+	busyEnd := busyAt.Add(time.Hour)
+	resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings",
+		models.MeetingRequest{Manager: &coach.ID, StartTime: &busyAt, EndTime: &busyEnd, Client: &client.ID}, nil)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	// Attendees are matched against the roster, so the clients need a meeting with the coach.
+	s.bookMeeting(ctx, coach.ID, coachToken, emailClient.ID, busyAt.Add(6*time.Hour))
+	s.bookMeeting(ctx, coach.ID, coachToken, sharedClient.ID, busyAt.Add(7*time.Hour))
+
+	at := func(t time.Time) string {
+		return t.Format("20060102T150405Z")
+	}
+	event := func(lines ...string) string {
+		return "BEGIN:VEVENT\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VEVENT\r\n"
+	}
+	calendar := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		event("UID:single", "DTSTART:"+at(base), "DURATION:PT1H", "SUMMARY:Legs",
+			"ATTENDEE:tel:"+coach.Phone, "ATTENDEE;CN=Client:tel:"+client.Phone) +
+		event("UID:weekly", "DTSTART:"+at(base.Add(2*time.Hour)), "DTEND:"+at(base.Add(3*time.Hour)),
+			"RRULE:FREQ=WEEKLY;COUNT=3", "EXDATE:"+at(base.Add(7*24*time.Hour+2*time.Hour)),
+			"ATTENDEE:mailto:"+strings.ToUpper(uniqueEmail)) +
+		event("UID:busy", "DTSTART:"+at(busyAt), "DURATION:PT1H", "ATTENDEE:tel:"+client.Phone) +
+		event("UID:shared", "DTSTART:"+at(base.Add(48*time.Hour)), "DURATION:PT1H", "ATTENDEE:mailto:"+*user.Email) +
+		event("UID:stranger", "DTSTART:"+at(base.Add(54*time.Hour)), "DURATION:PT1H", "ATTENDEE:tel:"+stranger.Phone) +
+		event("UID:nobody", "DTSTART:"+at(base.Add(50*time.Hour)), "DURATION:PT1H", "ATTENDEE:mailto:nobody@example.com") +
+		event("UID:cancelled", "DTSTART:"+at(base.Add(52*time.Hour)), "DURATION:PT1H", "STATUS:CANCELLED",
+			"ATTENDEE:tel:"+client.Phone) +
+		event("UID:past", "DTSTART:"+at(base.Add(-7*24*time.Hour)), "DURATION:PT1H", "ATTENDEE:tel:"+client.Phone) +
+		"END:VCALENDAR\r\n"
+
+	results := func(report models.ImportReport) map[string][]string {
+		byUID := make(map[string][]string)
+		for _, item := range report.Items {
+			byUID[item.UID] = append(byUID[item.UID], item.Result)
+		}
+		return byUID
+	}
+	want := map[string][]string{
+		"single":    {models.ImportCreated},
+		"weekly":    {models.ImportCreated, models.ImportCreated},
+		"busy":      {models.ImportConflict},
+		"shared":    {models.ImportSkipped},
+		"nobody":    {models.ImportSkipped},
+		"stranger":  {models.ImportSkipped},
+		"cancelled": {models.ImportSkipped},
+	}
+
+	s.Run("only the coach imports", func() {
+		status := importCalendar(otherCoachToken, "", calendar, nil)
+		s.Require().Equal(http.StatusForbidden, status)
+	})
+
+	s.Run("invalid calendar", func() {
+		var problem rest.Problem
+		status := importCalendar(coachToken, "", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n", &problem)
+		s.Require().Equal(http.StatusUnprocessableEntity, status)
+		s.Require().Equal(models.CodeInvalidCalendar, problem.Code)
+	})
+
+	s.Run("dry run", func() {
+		var report models.ImportReport
+		status := importCalendar(coachToken, "?dryRun=true", calendar, &report)
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().True(report.DryRun)
+		s.Require().Equal(want, results(report))
+		s.Require().Equal(3, report.Created)
+		s.Require().Equal(4, report.Skipped)
+		s.Require().Equal(1, report.Conflicts)
+		for _, item := range report.Items {
+			s.Require().Nil(item.MeetingID)
+		}
+	})
+
+	var report models.ImportReport
+	status := importCalendar(coachToken, "", calendar, &report)
+	s.Require().Equal(http.StatusCreated, status)
+	s.Require().False(report.DryRun)
+	s.Require().Equal(want, results(report))
+
+	s.Run("meetings are created", func() {
+		for _, item := range report.Items {
+			if item.Result != models.ImportCreated {
+				continue
+			}
+			s.Require().NotNil(item.MeetingID)
+			var meeting models.Meeting
+			resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/meetings/"+strconv.Itoa(*item.MeetingID), nil, &meeting)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+			s.Require().Equal(coach.ID, meeting.Manager)
+			s.Require().Equal(models.StatusConfirmed, meeting.Status)
+			s.Require().True(item.StartTime.Equal(meeting.StartTime))
+			wantClient := emailClient.ID
+			if item.UID == "single" {
+				wantClient = client.ID
+			}
+			s.Require().Equal(wantClient, meeting.Client)
+		}
+	})
+
+	s.Run("imported events are skipped", func() {
+		var again models.ImportReport
+		status := importCalendar(coachToken, "", calendar, &again)
+		s.Require().Equal(http.StatusCreated, status)
+		s.Require().Equal(0, again.Created)
+		for _, item := range again.Items {
+			if item.UID == "single" || item.UID == "weekly" {
+				s.Require().Equal(models.ImportSkipped, item.Result)
+				s.Require().Equal(models.ErrAlreadyImported.Error(), item.Reason)
+				s.Require().NotNil(item.MeetingID)
+			}
+		}
+	})
+}
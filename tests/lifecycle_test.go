@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestMeetingLifecycle() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	client, clientToken := s.createUser(ctx, user)
+	requested := models.StatusRequested
+	newMeeting := func(start time.Time, status *string) models.Meeting {
+		end := start.Add(time.Hour)
+		data := models.MeetingRequest{
+			Manager:   &coach.ID,
+			StartTime: &start,
+			EndTime:   &end,
+			Client:    &client.ID,
+			Status:    status,
+		}
+		var respMeeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, &respMeeting)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		return respMeeting
+	}
+	meetingURL := func(id int, action string) string {
+		return "/api/v1/meetings/" + strconv.Itoa(id) + "/" + action
+	}
+
+	s.Run("requested meeting is confirmed by coach", func() {
+		m := newMeeting(time.Now().Add(24*time.Hour), &requested)
+		s.Require().Equal(models.StatusRequested, m.Status)
+		s.requireForbidden(ctx, http.MethodPost, clientToken, meetingURL(m.ID, "confirm"), nil)
+		var respMeeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, meetingURL(m.ID, "confirm"), nil, &respMeeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.StatusConfirmed, respMeeting.Status)
+	})
+
+	s.Run("client cancels with reason and frees the slot", func() {
+		start := time.Now().Add(48 * time.Hour)
+		m := newMeeting(start, nil)
+		s.Require().Equal(models.StatusConfirmed, m.Status)
+		reason := "sick"
+		var respMeeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, clientToken, meetingURL(m.ID, "cancel"), models.CancelRequest{Reason: &reason}, &respMeeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.StatusCancelled, respMeeting.Status)
+		s.Require().Equal(reason, *respMeeting.CancelReason)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, meetingURL(m.ID, "confirm"), nil, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		newMeeting(start, nil)
+	})
+
+	s.Run("future meeting can't be completed", func() {
+		m := newMeeting(time.Now().Add(72*time.Hour), nil)
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, meetingURL(m.ID, "complete"), nil, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	// Meetings can't be booked in the past, so they are moved there once created.
+	pastMeeting := func(ago time.Duration) models.Meeting {
+		m := newMeeting(time.Now().Add(ago), nil)
+		err := s.store.Exec(ctx, `UPDATE meetings SET start_at = start_at - make_interval(secs => $1), end_at = end_at - make_interval(secs => $1) WHERE id = $2`,
+			(2 * ago).Seconds(), m.ID)
+		s.Require().NoError(err)
+		return m
+	}
+
+	s.Run("past meetings are completed or marked no-show", func() {
+		m := pastMeeting(3 * time.Hour)
+		var respMeeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, meetingURL(m.ID, "complete"), nil, &respMeeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.StatusCompleted, respMeeting.Status)
+
+		m = pastMeeting(5 * time.Hour)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, meetingURL(m.ID, "no-show"), nil, &respMeeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.StatusNoShow, respMeeting.Status)
+	})
+
+	s.Run("filter by status", func() {
+		var meetings []models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/meetings?status=completed,no_show", nil, &meetings)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().NotEmpty(meetings)
+		for _, m := range meetings {
+			s.Require().Contains([]string{models.StatusCompleted, models.StatusNoShow}, m.Status)
+		}
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/meetings?status=unknown", nil, nil)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/pershin-daniil/TimeSlots/internal/rest"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestLoginLimits() {
+	ctx := context.Background()
+
+	tryLogin := func(phone, password string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, testURL+"/api/v1/login", nil)
+		s.Require().NoError(err)
+		req.SetBasicAuth(phone, password)
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		s.Require().NoError(resp.Body.Close())
+		return resp
+	}
+
+	s.Run("repeated failures lock the phone", func() {
+		testUser, _ := s.createUser(ctx, user)
+		for i := 0; i < 3; i++ {
+			resp := tryLogin(testUser.Phone, "wrong")
+			s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, testURL+"/api/v1/login", nil)
+		s.Require().NoError(err)
+		req.SetBasicAuth(testUser.Phone, *user.Password)
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		defer func() {
+			s.Require().NoError(resp.Body.Close())
+		}()
+		s.Require().Equal(http.StatusTooManyRequests, resp.StatusCode)
+		retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+		s.Require().NoError(err)
+		s.Require().InDelta(60, retryAfter, 5)
+		var problem rest.Problem
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&problem))
+		s.Require().Equal(models.CodeLoginLocked, problem.Code)
+
+		var failures, locked int
+		rows, err := s.store.Query(ctx, `SELECT COUNT(*), COUNT(locked_until) FROM login_failures WHERE user_id = $1`, testUser.ID)
+		s.Require().NoError(err)
+		defer func() {
+			s.Require().NoError(rows.Close())
+		}()
+		s.Require().True(rows.Next())
+		s.Require().NoError(rows.Scan(&failures, &locked))
+		s.Require().Equal(3, failures)
+		s.Require().Equal(1, locked)
+	})
+
+	s.Run("success resets failures", func() {
+		testUser, _ := s.createUser(ctx, user)
+		for i := 0; i < 2; i++ {
+			s.Require().Equal(http.StatusUnauthorized, tryLogin(testUser.Phone, "wrong").StatusCode)
+		}
+		s.Require().Equal(http.StatusOK, tryLogin(testUser.Phone, *user.Password).StatusCode)
+		for i := 0; i < 2; i++ {
+			s.Require().Equal(http.StatusUnauthorized, tryLogin(testUser.Phone, "wrong").StatusCode)
+		}
+		s.Require().Equal(http.StatusOK, tryLogin(testUser.Phone, *user.Password).StatusCode)
+	})
+
+	s.Run("unknown phones are limited too", func() {
+		phone := randomPhone()
+		for i := 0; i < 3; i++ {
+			s.Require().Equal(http.StatusUnauthorized, tryLogin(phone, "wrong").StatusCode)
+		}
+		s.Require().Equal(http.StatusTooManyRequests, tryLogin(phone, "wrong").StatusCode)
+	})
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestMeetingsPagination() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	client, _ := s.createUser(ctx, user)
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	var created []models.Meeting
+	for i := 0; i < 5; i++ {
+		startTime := start.Add(time.Duration(i) * 2 * time.Hour)
+		endTime := startTime.Add(time.Hour)
+		data := models.MeetingRequest{Manager: &coach.ID, Client: &client.ID, StartTime: &startTime, EndTime: &endTime}
+		var respMeeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, &respMeeting)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		created = append(created, respMeeting)
+	}
+	listURL := func(query url.Values) string {
+		query.Set("manager", fmt.Sprint(coach.ID))
+		return "/api/v1/meetings?" + query.Encode()
+	}
+
+	s.Run("walk pages", func() {
+		var ids []int
+		query := url.Values{"limit": {"2"}}
+		for {
+			var meetings []models.Meeting
+			resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, listURL(query), nil, &meetings)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+			s.Require().LessOrEqual(len(meetings), 2)
+			for _, m := range meetings {
+				ids = append(ids, m.ID)
+			}
+			next := s.nextCursor(resp)
+			if next == "" {
+				break
+			}
+			query.Set("cursor", next)
+		}
+		s.Require().Equal([]int{created[0].ID, created[1].ID, created[2].ID, created[3].ID, created[4].ID}, ids)
+	})
+
+	s.Run("whole list without paging", func() {
+		var meetings []models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, listURL(url.Values{}), nil, &meetings)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(meetings, 5)
+		s.Require().Empty(resp.Header.Get("Link"))
+	})
+
+	s.Run("date range and sort", func() {
+		var meetings []models.Meeting
+		query := url.Values{
+			"from": {created[1].StartTime.Format(time.RFC3339)},
+			"to":   {created[4].StartTime.Format(time.RFC3339)},
+			"sort": {models.SortDesc},
+		}
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, listURL(query), nil, &meetings)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(meetings, 3)
+		s.Require().Equal(created[3].ID, meetings[0].ID)
+		s.Require().Equal(created[1].ID, meetings[2].ID)
+		s.Require().Empty(s.nextCursor(resp))
+	})
+
+	s.Run("invalid parameters", func() {
+		for _, query := range []url.Values{{"cursor": {"garbage"}}, {"limit": {"0"}}, {"sort": {"up"}}, {"from": {"yesterday"}}} {
+			resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, listURL(query), nil, nil)
+			s.Require().Equal(http.StatusBadRequest, resp.StatusCode, query.Encode())
+		}
+	})
+}
+
+func (s *IntegrationTestSuite) TestUsersPagination() {
+	ctx := context.Background()
+	coach, token := s.createCoach(ctx)
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	for i := 0; i < 3; i++ {
+		client, _ := s.createUser(ctx, user)
+		s.bookMeeting(ctx, coach.ID, token, client.ID, start.Add(time.Duration(i)*time.Hour))
+	}
+	var first, second []models.User
+	resp := s.sendAuthorisedRequest(ctx, http.MethodGet, token, "/api/v1/users?role=client&sort=desc&limit=2", nil, &first)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Len(first, 2)
+	next := s.nextCursor(resp)
+	s.Require().NotEmpty(next)
+	s.Require().Greater(first[0].ID, first[1].ID)
+	resp = s.sendAuthorisedRequest(ctx, http.MethodGet, token, "/api/v1/users?role=client&sort=desc&limit=2&cursor="+url.QueryEscape(next), nil, &second)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().NotEmpty(second)
+	s.Require().Greater(first[1].ID, second[0].ID)
+	for _, u := range append(first, second...) {
+		s.Require().Equal(models.RoleClient, u.Role)
+	}
+}
+
+// nextCursor returns the cursor of the next page from the Link header, empty on the last page.
+func (s *IntegrationTestSuite) nextCursor(resp *http.Response) string {
+	s.T().Helper()
+	link := resp.Header.Get("Link")
+	if link == "" {
+		return ""
+	}
+	s.Require().True(strings.HasPrefix(link, "<") && strings.HasSuffix(link, `>; rel="next"`), link)
+	next, err := url.Parse(strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`))
+	s.Require().NoError(err)
+	return next.Query().Get("cursor")
+}
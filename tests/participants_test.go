@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/internal/rest"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestGroupSession() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	first, firstToken := s.createUser(ctx, user)
+	second, secondToken := s.createUser(ctx, user)
+	third, thirdToken := s.createUser(ctx, user)
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	end := start.Add(time.Hour)
+	capacity := 2
+
+	var group models.Meeting
+	data := models.MeetingRequest{Manager: &coach.ID, StartTime: &start, EndTime: &end, Capacity: &capacity}
+	resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, &group)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	s.Require().Equal(capacity, group.Capacity)
+	s.Require().Zero(group.Client)
+	participantsURL := "/api/v1/meetings/" + strconv.Itoa(group.ID) + "/participants"
+
+	s.Run("group session can't have a single client", func() {
+		data := models.MeetingRequest{Manager: &coach.ID, StartTime: &start, EndTime: &end, Capacity: &capacity, Client: &first.ID}
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, nil)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("join until full", func() {
+		var meeting models.Meeting
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, firstToken, participantsURL, nil, &meeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, firstToken, participantsURL, nil, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, secondToken, participantsURL, nil, &meeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal([]int{first.ID, second.ID}, meeting.Participants)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, thirdToken, participantsURL, nil, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("participants can't cancel the session", func() {
+		s.requireForbidden(ctx, http.MethodPost, firstToken, "/api/v1/meetings/"+strconv.Itoa(group.ID)+"/cancel", nil)
+		var meeting models.Meeting
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/meetings/"+strconv.Itoa(group.ID), nil, &meeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.StatusConfirmed, meeting.Status)
+		s.Require().Equal([]int{first.ID, second.ID}, meeting.Participants)
+	})
+
+	s.Run("leave frees a place", func() {
+		var meeting models.Meeting
+		resp = s.sendAuthorisedRequest(ctx, http.MethodDelete, firstToken, participantsURL, nil, &meeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal([]int{second.ID}, meeting.Participants)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodDelete, firstToken, participantsURL, nil, nil)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, thirdToken, participantsURL, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/meetings/"+strconv.Itoa(group.ID), nil, &meeting)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal([]int{second.ID, third.ID}, meeting.Participants)
+	})
+
+	s.Run("participants can't be double-booked", func() {
+		otherCoach, otherToken := s.createCoach(ctx)
+		data := models.MeetingRequest{Manager: &otherCoach.ID, StartTime: &start, EndTime: &end, Client: &second.ID}
+		var respConflict rest.Problem
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, otherToken, "/api/v1/meetings", data, &respConflict)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		s.Require().Len(respConflict.Meetings, 1)
+		s.Require().Equal(group.ID, respConflict.Meetings[0].ID)
+	})
+
+	s.Run("one-to-one meetings keep their shape", func() {
+		later := end.Add(time.Hour)
+		laterEnd := later.Add(time.Hour)
+		var meeting models.Meeting
+		data := models.MeetingRequest{Manager: &coach.ID, StartTime: &later, EndTime: &laterEnd, Client: &first.ID}
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, &meeting)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal(first.ID, meeting.Client)
+		s.Require().Equal(1, meeting.Capacity)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, thirdToken, "/api/v1/meetings/"+strconv.Itoa(meeting.ID)+"/participants", nil, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+}
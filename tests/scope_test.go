@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/internal/rest"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestVisibilityScope() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	otherCoach, otherCoachToken := s.createCoach(ctx)
+	client, clientToken := s.createUser(ctx, user)
+	stranger, strangerToken := s.createUser(ctx, user)
+	startTime := time.Now().Add(72 * time.Hour).Truncate(time.Hour)
+	endTime := startTime.Add(time.Hour)
+	var meeting models.Meeting
+	resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings",
+		models.MeetingRequest{Manager: &coach.ID, Client: &client.ID, StartTime: &startTime, EndTime: &endTime}, &meeting)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	meetingURL := "/api/v1/meetings/" + strconv.Itoa(meeting.ID)
+
+	s.Run("booking", func() {
+		start, end := startTime.Add(2*time.Hour), endTime.Add(2*time.Hour)
+		s.requireForbidden(ctx, http.MethodPost, otherCoachToken, "/api/v1/meetings", models.MeetingRequest{Manager: &coach.ID, Client: &client.ID, StartTime: &start, EndTime: &end})
+		s.requireForbidden(ctx, http.MethodPost, strangerToken, "/api/v1/meetings", models.MeetingRequest{Manager: &coach.ID, Client: &client.ID, StartTime: &start, EndTime: &end})
+	})
+
+	s.Run("moving meetings to another coach", func() {
+		s.requireForbidden(ctx, http.MethodPatch, coachToken, meetingURL, models.MeetingRequest{Manager: &otherCoach.ID})
+		s.requireForbidden(ctx, http.MethodPatch, coachToken, meetingURL+"?scope=all", models.SeriesRequest{Manager: &otherCoach.ID})
+		var unchanged models.Meeting
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, meetingURL, nil, &unchanged)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(coach.ID, unchanged.Manager)
+	})
+
+	s.Run("changing the client", func() {
+		var problem rest.Problem
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, coachToken, meetingURL, models.MeetingRequest{Client: &stranger.ID}, &problem)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		s.Require().Equal(models.CodeValidationFailed, problem.Code)
+		s.Require().Len(problem.Errors, 1)
+		s.Require().Equal("client", problem.Errors[0].Field)
+		var unchanged models.Meeting
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, meetingURL, nil, &unchanged)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(client.ID, unchanged.Client)
+	})
+
+	s.Run("me", func() {
+		var me models.User
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, clientToken, "/api/v1/users/me", nil, &me)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(client.ID, me.ID)
+	})
+
+	s.Run("clients see only themselves", func() {
+		var users []models.User
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, clientToken, "/api/v1/users", nil, &users)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(users, 1)
+		s.Require().Equal(client.ID, users[0].ID)
+		s.requireForbidden(ctx, http.MethodGet, strangerToken, "/api/v1/users/"+strconv.Itoa(client.ID), nil)
+	})
+
+	s.Run("coaches see their roster", func() {
+		var users []models.User
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/users", nil, &users)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(users, 1)
+		s.Require().Equal(client.ID, users[0].ID)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/users/"+strconv.Itoa(client.ID), nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.requireForbidden(ctx, http.MethodGet, coachToken, "/api/v1/users/"+strconv.Itoa(stranger.ID), nil)
+	})
+
+	s.Run("meetings", func() {
+		var meetings []models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, clientToken, "/api/v1/meetings?mine=true", nil, &meetings)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(meetings, 1)
+		s.Require().Equal(meeting.ID, meetings[0].ID)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/meetings?mine=false", nil, nil)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, strangerToken, "/api/v1/meetings", nil, &meetings)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Empty(meetings)
+		s.requireForbidden(ctx, http.MethodGet, strangerToken, "/api/v1/meetings?client="+strconv.Itoa(client.ID), nil)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, clientToken, meetingURL, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.requireForbidden(ctx, http.MethodGet, strangerToken, meetingURL, nil)
+		s.requireForbidden(ctx, http.MethodGet, otherCoachToken, meetingURL, nil)
+	})
+}
+
+// bookMeeting books a meeting of the client with the coach, which puts the client on the roster of the coach.
+func (s *IntegrationTestSuite) bookMeeting(ctx context.Context, coachID int, coachToken string, clientID int, start time.Time) {
+	s.T().Helper()
+	end := start.Add(time.Hour)
+	resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings",
+		models.MeetingRequest{Manager: &coachID, Client: &clientID, StartTime: &start, EndTime: &end}, nil)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestSeries() {
+	ctx := context.Background()
+	coach, token := s.createCoach(ctx)
+	client, _ := s.createUser(ctx, user)
+
+	timeZone := "Europe/Berlin"
+	loc, err := time.LoadLocation(timeZone)
+	s.Require().NoError(err)
+	nextWeek := time.Now().In(loc).AddDate(0, 0, 7)
+	start := time.Date(nextWeek.Year(), nextWeek.Month(), nextWeek.Day(), 18, 0, 0, 0, loc)
+	end := start.Add(time.Hour)
+	rule := "FREQ=WEEKLY;COUNT=4"
+	data := models.SeriesRequest{
+		Manager:   &coach.ID,
+		Client:    &client.ID,
+		StartTime: &start,
+		EndTime:   &end,
+		TimeZone:  &timeZone,
+		Rule:      &rule,
+	}
+
+	var series models.Series
+	resp := s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/series", data, &series)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	s.Require().Len(series.Meetings, 4)
+	for _, meeting := range series.Meetings {
+		s.Require().Equal(18, meeting.StartTime.In(loc).Hour())
+		s.Require().Equal(series.ID, *meeting.SeriesID)
+	}
+
+	s.Run("invalid rule", func() {
+		invalidRule := "FREQ=WEEKLY"
+		invalid := data
+		invalid.Rule = &invalidRule
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/series", invalid, nil)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	s.Run("overlapping series", func() {
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/series", data, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	var nextSeries models.Series
+	s.Run("edit this and following", func() {
+		newStart := series.Meetings[2].StartTime.Add(time.Hour)
+		edit := models.SeriesRequest{StartTime: &newStart}
+		url := "/api/v1/meetings/" + strconv.Itoa(series.Meetings[2].ID) + "?scope=following"
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, token, url, edit, &nextSeries)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().NotEqual(series.ID, nextSeries.ID)
+		s.Require().Len(nextSeries.Meetings, 2)
+		s.Require().Equal(19, nextSeries.Meetings[0].StartTime.In(loc).Hour())
+
+		var oldSeries models.Series
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, token, "/api/v1/series/"+strconv.Itoa(series.ID), nil, &oldSeries)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(oldSeries.Meetings, 2)
+
+		var replaced models.Meeting
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, token, "/api/v1/meetings/"+strconv.Itoa(series.Meetings[2].ID), nil, &replaced)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.StatusCancelled, replaced.Status)
+		s.Require().NotNil(replaced.CancelledAt)
+		s.Require().Equal(coach.ID, *replaced.CancelledBy)
+	})
+
+	s.Run("cancel this occurrence", func() {
+		resp := s.sendAuthorisedRequest(ctx, http.MethodDelete, token, "/api/v1/meetings/"+strconv.Itoa(nextSeries.Meetings[0].ID)+"?scope=this", nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		var updated models.Series
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, token, "/api/v1/series/"+strconv.Itoa(nextSeries.ID), nil, &updated)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(updated.Meetings, 1)
+	})
+
+	s.Run("cancel whole series", func() {
+		var cancelled models.Series
+		url := "/api/v1/meetings/" + strconv.Itoa(series.Meetings[0].ID) + "?scope=all"
+		resp := s.sendAuthorisedRequest(ctx, http.MethodDelete, token, url, nil, &cancelled)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Empty(cancelled.Meetings)
+	})
+
+	s.Run("not in series", func() {
+		newMeeting, managerToken := s.createMeeting(ctx, meeting)
+		url := "/api/v1/meetings/" + strconv.Itoa(newMeeting.ID) + "?scope=all"
+		resp := s.sendAuthorisedRequest(ctx, http.MethodDelete, managerToken, url, nil, nil)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+}
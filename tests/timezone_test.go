@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestTimeZones() {
+	ctx := context.Background()
+	testUser, token := s.createUser(ctx, user)
+	userURL := "/api/v1/users/" + strconv.Itoa(testUser.ID)
+	s.Require().Equal("UTC", testUser.TimeZone)
+
+	s.Run("update time zone", func() {
+		zone := "Europe/Moscow"
+		var respUser models.User
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, token, userURL, models.UserRequest{TimeZone: &zone}, &respUser)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(zone, respUser.TimeZone)
+
+		var savedUser models.User
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, token, userURL, nil, &savedUser)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(zone, savedUser.TimeZone)
+	})
+
+	s.Run("unknown time zone", func() {
+		zone := "Mars/Olympus"
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, token, userURL, models.UserRequest{TimeZone: &zone}, nil)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	s.Run("meeting in local time", func() {
+		coach, coachToken := s.createCoach(ctx)
+		day := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+		data := map[string]interface{}{
+			"manager":   coach.ID,
+			"client":    testUser.ID,
+			"startTime": day + "T10:00:00",
+			"endTime":   day + "T11:00:00",
+			"timeZone":  "Europe/Moscow",
+		}
+		var respMeeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, &respMeeting)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal(7, respMeeting.StartTime.UTC().Hour())
+
+		delete(data, "timeZone")
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, nil)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
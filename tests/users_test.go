@@ -5,19 +5,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pershin-daniil/TimeSlots/internal/rest"
 	"github.com/pershin-daniil/TimeSlots/pkg/logger"
 	"github.com/pershin-daniil/TimeSlots/pkg/pgstore"
+	"github.com/pershin-daniil/TimeSlots/pkg/ratelimit"
 	"github.com/pershin-daniil/TimeSlots/pkg/service"
+	"github.com/pershin-daniil/TimeSlots/pkg/signing"
 	migrate "github.com/rubenv/sql-migrate"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/suite"
@@ -36,16 +39,15 @@ var user models.UserRequest
 
 var meeting models.MeetingRequest
 
-type errResp struct {
-	Error string `json:"error"`
-}
-
 type IntegrationTestSuite struct {
 	suite.Suite
 	log     *logrus.Logger
 	store   *pgstore.Store
 	app     rest.App
 	handler *rest.Server
+
+	mu         sync.Mutex
+	violations []string
 }
 
 func (s *IntegrationTestSuite) SetupSuite() {
@@ -88,18 +90,39 @@ func (s *IntegrationTestSuite) SetupSuite() {
 	err = s.store.Migrate(migrate.Up)
 	s.Require().NoError(err)
 	// s.notifier = notifier.New(s.log, mockTeleBot{})
-	s.app = service.NewScheduleService(s.log, s.store)
+	keys, err := signing.Generate("test")
+	s.Require().NoError(err)
+	s.app = service.NewScheduleService(s.log, s.store, nil, keys)
+
+	// All requests come from localhost, so only the limits per phone are checked.
+	limits := ratelimit.DefaultConfig()
+	limits.IPLimit = 0
+	limits.PhoneLimit = 20
+	limits.MaxFailures = 3
+	s.handler = rest.New(s.log, s.app, keys, ratelimit.New(s.store.RateLimitStore(), limits), address, version)
+	err = s.handler.ValidateContract(func(err error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.violations = append(s.violations, err.Error())
+	})
 	s.Require().NoError(err)
-
-	s.handler = rest.New(s.log, s.app, address, version)
 	go func() {
 		_ = s.handler.Run(ctx)
 	}()
 	time.Sleep(100 * time.Millisecond)
-	err = s.store.ResetTables(ctx, []string{"meetings", "users", "users_history", "meetings_history"})
+	err = s.store.ResetTables(ctx, []string{"meetings", "users", "users_history", "meetings_history", "login_failures"})
+	s.Require().NoError(err)
+	err = s.store.Exec(ctx, `DELETE FROM rate_limits`)
 	s.Require().NoError(err)
 }
 
+// TearDownSuite fails the suite if the API strayed from docs/api.yaml.
+func (s *IntegrationTestSuite) TearDownSuite() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Require().Empty(s.violations, "responses do not match docs/api.yaml")
+}
+
 func (s *IntegrationTestSuite) TestCreateUser() {
 	ctx := context.Background()
 	var respUser models.User
@@ -108,7 +131,7 @@ func (s *IntegrationTestSuite) TestCreateUser() {
 	s.Require().Equal(*user.LastName, respUser.LastName)
 	s.Require().Equal(*user.FirstName, respUser.FirstName)
 	s.Require().Equal(*user.Email, respUser.Email)
-	s.Require().Equal(*user.Phone, respUser.Phone)
+	s.Require().Equal("+79999999999", respUser.Phone)
 	s.Require().Equal(respUser.CreatedAt, respUser.UpdatedAt)
 	var cnt int
 	err := s.store.QueryRow(ctx, `SELECT count(*) FROM users_history WHERE user_id = $1`, respUser.ID).Scan(&cnt)
@@ -133,10 +156,13 @@ func (s *IntegrationTestSuite) TestGetUser() {
 
 	s.Run("get user not found", func() {
 		testUser.ID = 0
-		var respError errResp
-		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, token, "/api/v1/users/"+strconv.Itoa(testUser.ID), nil, &respError)
+		_, coachToken := s.createCoach(ctx)
+		var respError rest.Problem
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/users/"+strconv.Itoa(testUser.ID), nil, &respError)
 		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
-		s.Require().Equal(fmt.Sprintf("err getting user (id %d) from store: %v", testUser.ID, pgstore.ErrUserNotFound), respError.Error)
+		s.Require().Equal("application/problem+json", resp.Header.Get("Content-Type"))
+		s.Require().Equal(models.CodeUserNotFound, respError.Code)
+		s.Require().Equal(pgstore.ErrUserNotFound.Error(), respError.Detail)
 	})
 }
 
@@ -171,9 +197,7 @@ func (s *IntegrationTestSuite) TestUpdateUser() {
 
 	s.Run("update another user", func() {
 		testUser.ID = 0
-		var respError errResp
-		resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, token, "/api/v1/users/"+strconv.Itoa(testUser.ID), data, &respError)
-		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+		s.requireForbidden(ctx, http.MethodPatch, token, "/api/v1/users/"+strconv.Itoa(testUser.ID), data)
 	})
 }
 
@@ -192,9 +216,7 @@ func (s *IntegrationTestSuite) TestDeleteUser() {
 	})
 
 	s.Run("delete another id", func() {
-		var respError errResp
-		resp := s.sendAuthorisedRequest(ctx, http.MethodDelete, token, "/api/v1/users/2", nil, &respError)
-		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+		s.requireForbidden(ctx, http.MethodDelete, token, "/api/v1/users/2", nil)
 	})
 }
 
@@ -212,6 +234,43 @@ func (s *IntegrationTestSuite) TestCreateMeeting() {
 	s.Require().Equal(*meeting.Client, respMeeting.Client)
 }
 
+func (s *IntegrationTestSuite) TestCreateMeetingConflict() {
+	ctx := context.Background()
+	newMeeting, token := s.createMeeting(ctx, meeting)
+	anotherClient, _ := s.createUser(ctx, user)
+
+	s.Run("manager is busy", func() {
+		startTime := newMeeting.StartTime.Add(30 * time.Minute)
+		endTime := newMeeting.EndTime.Add(30 * time.Minute)
+		data := models.MeetingRequest{
+			Manager:   &newMeeting.Manager,
+			StartTime: &startTime,
+			EndTime:   &endTime,
+			Client:    &anotherClient.ID,
+		}
+		var respConflict rest.Problem
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/meetings", data, &respConflict)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		s.Require().Equal(models.CodeMeetingConflict, respConflict.Code)
+		s.Require().Len(respConflict.Meetings, 1)
+		s.Require().Equal(newMeeting.ID, respConflict.Meetings[0].ID)
+	})
+
+	s.Run("adjacent meeting", func() {
+		startTime := newMeeting.EndTime
+		endTime := newMeeting.EndTime.Add(time.Hour)
+		data := models.MeetingRequest{
+			Manager:   &newMeeting.Manager,
+			StartTime: &startTime,
+			EndTime:   &endTime,
+			Client:    &anotherClient.ID,
+		}
+		var respMeeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/meetings", data, &respMeeting)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	})
+}
+
 func (s *IntegrationTestSuite) TestGetMeeting() {
 	ctx := context.Background()
 	newMeeting, token := s.createMeeting(ctx, meeting)
@@ -227,11 +286,10 @@ func (s *IntegrationTestSuite) TestGetMeeting() {
 	})
 
 	s.Run("not found meeting", func() {
-		id := 0
-		var respError errResp
+		var respError rest.Problem
 		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, token, "/api/v1/meetings/0", nil, &respError)
 		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
-		s.Require().Equal(fmt.Sprintf("err getting meeting (id %d) from store: %v", id, pgstore.ErrMeetingNotFound), respError.Error)
+		s.Require().Equal(models.CodeMeetingNotFound, respError.Code)
 	})
 }
 
@@ -258,11 +316,10 @@ func (s *IntegrationTestSuite) TestUpdateMeeting() {
 	})
 
 	s.Run("not found meeting", func() {
-		id := 0
-		var respError errResp
+		var respError rest.Problem
 		resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, token, "/api/v1/meetings/0", data, &respError)
 		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
-		s.Require().Equal(fmt.Sprintf("err updating meeting (id %d) from store: %v", id, pgstore.ErrMeetingNotFound), respError.Error)
+		s.Require().Equal(models.CodeMeetingNotFound, respError.Code)
 	})
 }
 
@@ -279,13 +336,20 @@ func (s *IntegrationTestSuite) TestDeleteMeeting() {
 		s.Require().Equal(newMeeting.Client, respMeeting.Client)
 		s.Require().Equal(newMeeting.StartTime.UTC(), respMeeting.StartTime.UTC())
 		s.Require().Equal(newMeeting.EndTime.UTC(), respMeeting.EndTime.UTC())
+		s.Require().Equal(models.StatusCancelled, respMeeting.Status)
+	})
+
+	s.Run("already deleted meeting", func() {
+		var respError rest.Problem
+		resp := s.sendAuthorisedRequest(ctx, http.MethodDelete, token, "/api/v1/meetings/"+strconv.Itoa(newMeeting.ID), nil, &respError)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
 	})
 
 	s.Run("not found meeting", func() {
-		var respError errResp
+		var respError rest.Problem
 		resp := s.sendAuthorisedRequest(ctx, http.MethodDelete, token, "/api/v1/meetings/0", nil, &respError)
 		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
-		s.Require().Equal(fmt.Sprintf("err deleting meeting (id 0) from store: %v", pgstore.ErrMeetingNotFound), respError.Error)
+		s.Require().Equal(models.CodeMeetingNotFound, respError.Code)
 	})
 }
 
@@ -330,9 +394,42 @@ func (s *IntegrationTestSuite) sendAuthorisedRequest(ctx context.Context, method
 	return resp
 }
 
+// requireForbidden sends the request and checks it is refused with a FORBIDDEN problem.
+func (s *IntegrationTestSuite) requireForbidden(ctx context.Context, method, token, url string, body interface{}) {
+	s.T().Helper()
+	var problem rest.Problem
+	resp := s.sendAuthorisedRequest(ctx, method, token, url, body, &problem)
+	s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+	s.Require().Equal("application/problem+json", resp.Header.Get("Content-Type"))
+	s.Require().Equal(models.CodeForbidden, problem.Code)
+}
+
+func (s *IntegrationTestSuite) sendRequestWithHeaders(ctx context.Context, method, url string, headers map[string]string, body, dest interface{}) *http.Response {
+	s.T().Helper()
+	reqBody, err := json.Marshal(body)
+	s.Require().NoError(err)
+	req, err := http.NewRequestWithContext(ctx, method, testURL+url, bytes.NewReader(reqBody))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer func() {
+		err = resp.Body.Close()
+		s.Require().NoError(err)
+	}()
+	if dest != nil {
+		err = json.NewDecoder(resp.Body).Decode(&dest)
+		s.Require().NoError(err)
+	}
+	return resp
+}
+
 func (s *IntegrationTestSuite) createUser(ctx context.Context, user models.UserRequest) (models.User, string) {
 	s.T().Helper()
-	newPhone := uuid.New().String()
+	newPhone := randomPhone()
 	user.Phone = &newPhone
 	result := models.User{}
 	resp := s.sendRequest(ctx, http.MethodPost, "/api/v1/users", user, &result)
@@ -341,11 +438,24 @@ func (s *IntegrationTestSuite) createUser(ctx context.Context, user models.UserR
 	return result, token
 }
 
+// randomPhone returns a phone in E.164 format, unique enough for tests.
+func randomPhone() string {
+	return fmt.Sprintf("+79%09d", rand.Intn(1e9))
+}
+
+func (s *IntegrationTestSuite) createCoach(ctx context.Context) (models.User, string) {
+	s.T().Helper()
+	coach, _ := s.createUser(ctx, user)
+	err := s.store.Exec(ctx, `UPDATE users SET role = $1 WHERE id = $2`, models.RoleCoach, coach.ID)
+	s.Require().NoError(err)
+	coach.Role = models.RoleCoach
+	return coach, s.getToken(ctx, coach.Phone, *user.Password)
+}
+
 func (s *IntegrationTestSuite) createMeeting(ctx context.Context, meeting models.MeetingRequest) (models.Meeting, string) {
 	s.T().Helper()
 	testUser2, _ := s.createUser(ctx, user)
-	*user.Role = models.RoleCoach
-	testUser1, token := s.createUser(ctx, user)
+	testUser1, token := s.createCoach(ctx)
 	meeting.Manager = &testUser1.ID
 	meeting.Client = &testUser2.ID
 	reqBody, err := json.Marshal(meeting)
@@ -367,6 +477,11 @@ func (s *IntegrationTestSuite) createMeeting(ctx context.Context, meeting models
 }
 
 func (s *IntegrationTestSuite) getToken(ctx context.Context, phone, password string) string {
+	s.T().Helper()
+	return s.login(ctx, phone, password).Token
+}
+
+func (s *IntegrationTestSuite) login(ctx context.Context, phone, password string) models.TokenResponse {
 	s.T().Helper()
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, testURL+`/api/v1/login`, nil)
 	s.Require().NoError(err)
@@ -378,10 +493,10 @@ func (s *IntegrationTestSuite) getToken(ctx context.Context, phone, password str
 		s.Require().NoError(err)
 	}()
 	s.Require().Equal(http.StatusOK, resp.StatusCode)
-	var token models.TokenResponse
-	err = json.NewDecoder(resp.Body).Decode(&token)
+	var tokens models.TokenResponse
+	err = json.NewDecoder(resp.Body).Decode(&tokens)
 	s.Require().NoError(err)
-	return token.Token
+	return tokens
 }
 
 func (s *IntegrationTestSuite) TestGenerateHashFromPassword() {
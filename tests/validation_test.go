@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/internal/rest"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestValidation() {
+	ctx := context.Background()
+	fields := func(problem rest.Problem) []string {
+		names := make([]string, 0, len(problem.Errors))
+		for _, field := range problem.Errors {
+			names = append(names, field.Field)
+		}
+		return names
+	}
+
+	s.Run("user fields are reported at once", func() {
+		lastName := "Doe"
+		phone := "12-34"
+		email := "not an email"
+		data := models.UserRequest{LastName: &lastName, Phone: &phone, Email: &email, Password: user.Password}
+		var problem rest.Problem
+		resp := s.sendRequest(ctx, http.MethodPost, "/api/v1/users", data, &problem)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		s.Require().Equal(models.CodeValidationFailed, problem.Code)
+		s.Require().Equal([]string{"firstName", "phone", "email"}, fields(problem))
+	})
+
+	s.Run("missing password and phone are reported together", func() {
+		data := models.UserRequest{LastName: user.LastName, FirstName: user.FirstName}
+		var problem rest.Problem
+		resp := s.sendRequest(ctx, http.MethodPost, "/api/v1/users", data, &problem)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		s.Require().Equal("application/problem+json", resp.Header.Get("Content-Type"))
+		s.Require().Equal(models.CodeValidationFailed, problem.Code)
+		s.Require().Equal([]string{"phone", "password"}, fields(problem))
+	})
+
+	s.Run("phone is normalised", func() {
+		phone := "7 (921) 555-01-23"
+		data := user
+		data.Phone = &phone
+		var respUser models.User
+		resp := s.sendRequest(ctx, http.MethodPost, "/api/v1/users", data, &respUser)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal("+79215550123", respUser.Phone)
+		s.Require().NotEmpty(s.getToken(ctx, "+7 921 555 01 23", *user.Password))
+	})
+
+	coach, token := s.createCoach(ctx)
+	client, _ := s.createUser(ctx, user)
+
+	s.Run("missing meeting fields", func() {
+		var problem rest.Problem
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/meetings", models.MeetingRequest{Manager: &coach.ID}, &problem)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		s.Require().Equal([]string{"client", "startTime", "endTime"}, fields(problem))
+	})
+
+	s.Run("meeting times and users", func() {
+		start := time.Now().Add(-time.Hour)
+		end := start.Add(-time.Minute)
+		unknown := 0
+		data := models.MeetingRequest{Manager: &coach.ID, Client: &unknown, StartTime: &start, EndTime: &end}
+		var problem rest.Problem
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/meetings", data, &problem)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		s.Require().Equal([]string{"endTime", "startTime", "client"}, fields(problem))
+	})
+
+	s.Run("meeting duration", func() {
+		start := time.Now().Add(24 * time.Hour)
+		end := start.Add(models.MaxMeetingDuration + time.Minute)
+		data := models.MeetingRequest{Manager: &coach.ID, Client: &client.ID, StartTime: &start, EndTime: &end}
+		var problem rest.Problem
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, token, "/api/v1/meetings", data, &problem)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		s.Require().Equal([]string{"endTime"}, fields(problem))
+	})
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+)
+
+func (s *IntegrationTestSuite) TestWaitlist() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	client, _ := s.createUser(ctx, user)
+	first, firstToken := s.createUser(ctx, user)
+	second, secondToken := s.createUser(ctx, user)
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	end := start.Add(time.Hour)
+	waitlistURL := "/api/v1/coaches/" + strconv.Itoa(coach.ID) + "/waitlist"
+	entryURL := func(id int, action string) string {
+		return "/api/v1/waitlist/" + strconv.Itoa(id) + "/" + action
+	}
+	slot := models.WaitlistRequest{StartTime: &start, EndTime: &end}
+
+	var booked models.Meeting
+	data := models.MeetingRequest{Manager: &coach.ID, StartTime: &start, EndTime: &end, Client: &client.ID}
+	resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, &booked)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	var firstEntry, secondEntry models.WaitlistEntry
+	s.Run("join waitlist of taken slot", func() {
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, firstToken, waitlistURL, slot, &firstEntry)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal(models.WaitlistWaiting, firstEntry.Status)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, secondToken, waitlistURL, slot, &secondEntry)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, firstToken, waitlistURL, slot, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("free slot can't be waitlisted", func() {
+		freeStart := end.Add(time.Hour)
+		freeEnd := freeStart.Add(time.Hour)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, firstToken, waitlistURL, models.WaitlistRequest{StartTime: &freeStart, EndTime: &freeEnd}, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("cancelled slot is offered to the first client", func() {
+		offers, err := s.store.OfferWaitlistSlots(ctx, time.Minute)
+		s.Require().NoError(err)
+		s.Require().Empty(offers)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings/"+strconv.Itoa(booked.ID)+"/cancel", nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		offers, err = s.store.OfferWaitlistSlots(ctx, time.Minute)
+		s.Require().NoError(err)
+		s.Require().Len(offers, 1)
+		s.Require().Equal(first.ID, offers[0].ClientID)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", data, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, secondToken, entryURL(firstEntry.ID, "accept"), nil, nil)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+
+	s.Run("expired offer passes to the next client", func() {
+		err := s.store.Exec(ctx, `UPDATE waitlist_entries SET hold_until = NOW() - INTERVAL '1 minute' WHERE id = $1`, firstEntry.ID)
+		s.Require().NoError(err)
+		offers, err := s.store.OfferWaitlistSlots(ctx, time.Minute)
+		s.Require().NoError(err)
+		s.Require().Len(offers, 1)
+		s.Require().Equal(second.ID, offers[0].ClientID)
+
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, firstToken, entryURL(firstEntry.ID, "accept"), nil, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("accept offer books the slot", func() {
+		var meeting models.Meeting
+		resp = s.sendAuthorisedRequest(ctx, http.MethodPost, secondToken, entryURL(secondEntry.ID, "accept"), nil, &meeting)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal(second.ID, meeting.Client)
+		s.Require().Equal(coach.ID, meeting.Manager)
+		s.Require().Equal(start.UTC(), meeting.StartTime.UTC())
+	})
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pershin-daniil/TimeSlots/internal/rest"
+	"github.com/pershin-daniil/TimeSlots/pkg/models"
+	"github.com/pershin-daniil/TimeSlots/pkg/webhook"
+	"github.com/pershin-daniil/TimeSlots/pkg/worker"
+)
+
+type receivedEvent struct {
+	delivery string
+	event    models.WebhookEvent
+}
+
+func (s *IntegrationTestSuite) TestWebhooks() {
+	ctx := context.Background()
+	coach, coachToken := s.createCoach(ctx)
+	_, otherToken := s.createCoach(ctx)
+	client, _ := s.createUser(ctx, user)
+	webhooksURL := "/api/v1/coaches/" + strconv.Itoa(coach.ID) + "/webhooks"
+
+	var (
+		mu       sync.Mutex
+		secret   string
+		status   = http.StatusOK
+		received []receivedEvent
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		s.Require().NoError(err)
+		ts, err := strconv.ParseInt(r.Header.Get(webhook.TimestampHeader), 10, 64)
+		s.Require().NoError(err)
+		mu.Lock()
+		defer mu.Unlock()
+		s.Require().True(webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), ts, body))
+		var event models.WebhookEvent
+		s.Require().NoError(json.Unmarshal(body, &event))
+		s.Require().Equal(event.Event, r.Header.Get(webhook.EventHeader))
+		received = append(received, receivedEvent{delivery: r.Header.Get(webhook.DeliveryHeader), event: event})
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+	lastEvent := func() (receivedEvent, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(received) == 0 {
+			return receivedEvent{}, 0
+		}
+		return received[len(received)-1], len(received)
+	}
+	setStatus := func(code int) {
+		mu.Lock()
+		defer mu.Unlock()
+		status = code
+	}
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go worker.New(s.log, s.store, nil, webhook.NewSender(time.Second, true)).DeliverWebhooks(workerCtx)
+
+	s.Run("event catalogue", func() {
+		var events []models.WebhookEventType
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, "/api/v1/webhooks/events", nil, &events)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(events, len(models.WebhookEvents))
+	})
+
+	s.Run("unknown events are rejected", func() {
+		url := receiver.URL
+		data := models.WebhookRequest{URL: &url, Events: []string{"meeting.exploded"}}
+		var problem rest.Problem
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, webhooksURL, data, &problem)
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		s.Require().Equal("events", problem.Errors[0].Field)
+	})
+
+	url := receiver.URL
+	data := models.WebhookRequest{URL: &url, Events: []string{models.EventMeetingCreated, models.EventMeetingCancelled}}
+	var hook models.Webhook
+	resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, webhooksURL, data, &hook)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	s.Require().NotEmpty(hook.Secret)
+	mu.Lock()
+	secret = hook.Secret
+	mu.Unlock()
+	hookURL := webhooksURL + "/" + strconv.Itoa(hook.ID)
+
+	s.Run("webhooks belong to the coach", func() {
+		s.requireForbidden(ctx, http.MethodGet, otherToken, hookURL, nil)
+		var got models.Webhook
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, hookURL, nil, &got)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Empty(got.Secret)
+		s.Require().ElementsMatch(data.Events, got.Events)
+	})
+
+	day := 0
+	newMeeting := func() models.Meeting {
+		day++
+		start := time.Now().Add(time.Duration(day) * 24 * time.Hour).Truncate(time.Minute)
+		end := start.Add(time.Hour)
+		req := models.MeetingRequest{Manager: &coach.ID, StartTime: &start, EndTime: &end, Client: &client.ID}
+		var respMeeting models.Meeting
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken, "/api/v1/meetings", req, &respMeeting)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		return respMeeting
+	}
+	deliveries := func() []models.WebhookDelivery {
+		var result []models.WebhookDelivery
+		resp := s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, hookURL+"/deliveries", nil, &result)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		return result
+	}
+
+	s.Run("signed delivery", func() {
+		created := newMeeting()
+		s.Require().Eventually(func() bool {
+			got, _ := lastEvent()
+			return got.event.Event == models.EventMeetingCreated
+		}, 5*time.Second, 50*time.Millisecond)
+		got, _ := lastEvent()
+		meetingData, ok := got.event.Data.(map[string]interface{})
+		s.Require().True(ok)
+		s.Require().EqualValues(created.ID, meetingData["id"])
+
+		s.Require().Eventually(func() bool {
+			log := deliveries()
+			return len(log) == 1 && log[0].Status == models.DeliveryDelivered
+		}, 5*time.Second, 50*time.Millisecond)
+		log := deliveries()
+		s.Require().Equal(strconv.Itoa(log[0].ID), got.delivery)
+		s.Require().Equal(http.StatusOK, *log[0].ResponseStatus)
+		s.Require().Equal(1, log[0].Attempts)
+	})
+
+	s.Run("failed delivery is retried and redelivered", func() {
+		setStatus(http.StatusInternalServerError)
+		_, before := lastEvent()
+		newMeeting()
+		var failed models.WebhookDelivery
+		s.Require().Eventually(func() bool {
+			failed = deliveries()[0]
+			return failed.Attempts == 1
+		}, 5*time.Second, 50*time.Millisecond)
+		s.Require().Equal(models.DeliveryPending, failed.Status)
+		s.Require().Equal(http.StatusInternalServerError, *failed.ResponseStatus)
+		s.Require().NotNil(failed.LastError)
+		s.Require().True(failed.NextAttemptAt.After(time.Now()))
+
+		setStatus(http.StatusOK)
+		var redelivery models.WebhookDelivery
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPost, coachToken,
+			hookURL+"/deliveries/"+strconv.Itoa(failed.ID)+"/redeliver", nil, &redelivery)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal(failed.ID, *redelivery.RedeliveryOf)
+		s.Require().Eventually(func() bool {
+			_, count := lastEvent()
+			return count == before+2
+		}, 5*time.Second, 50*time.Millisecond)
+		got, _ := lastEvent()
+		s.Require().Equal(strconv.Itoa(redelivery.ID), got.delivery)
+	})
+
+	s.Run("unsubscribed events are not sent", func() {
+		data := models.WebhookRequest{Events: []string{models.EventMeetingCancelled}}
+		resp := s.sendAuthorisedRequest(ctx, http.MethodPatch, coachToken, hookURL, data, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		count := len(deliveries())
+		newMeeting()
+		s.Require().Len(deliveries(), count)
+	})
+
+	s.Run("delete", func() {
+		resp := s.sendAuthorisedRequest(ctx, http.MethodDelete, coachToken, hookURL, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		resp = s.sendAuthorisedRequest(ctx, http.MethodGet, coachToken, hookURL, nil, nil)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}